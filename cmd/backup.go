@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -23,7 +22,7 @@ Use the --list flag to list current backup files.
 Example files: 
   - backup_2023_05_23T15_54_19.sql.gz (database)
   - media_backup_2023_05_23T15_54_19.tar.gz (media files)`,
-	Run: backupDatabase,
+	RunE: backupDatabase,
 }
 
 func init() {
@@ -32,44 +31,54 @@ func init() {
 	backupCmd.Flags().BoolVar(&lst, "list", false, "List the available backup files")
 }
 
-func backupDatabase(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
-	dockerInterface.Env.Save()
+func backupDatabase(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 
 	if lst {
-		listBackups(dockerInterface)
-	} else {
-		backup(dockerInterface)
+		return listBackups(dockerInterface)
 	}
+	return backup(dockerInterface)
 }
 
-func listBackups(dockerInterface *internal.DockerInterface) {
+func listBackups(dockerInterface *internal.DockerInterface) error {
 	// Validate that containers are running and match the current mode
 	if err := dockerInterface.ValidateContainersRunning(); err != nil {
-		log.Fatalf("%v\n", err)
+		return err
 	}
 
 	fmt.Printf("[+] Listing available PostgreSQL database backup files with %s...\n", dockerInterface.ComposeFile)
+	if structuredOutput() {
+		backups, err := dockerInterface.ListBackups()
+		if err != nil {
+			return err
+		}
+		return printResult(backups, nil)
+	}
 	err := dockerInterface.RunComposeCmd("run", "--rm", "postgres", "backups")
 	if err != nil {
-		log.Fatalf("Error trying to list backups files with %s: %v\n", dockerInterface.ComposeFile, err)
+		return fmt.Errorf("failed to list backups files with %s: %w", dockerInterface.ComposeFile, err)
 	}
+	return nil
 }
 
-func backup(dockerInterface *internal.DockerInterface) {
+func backup(dockerInterface *internal.DockerInterface) error {
 	// Validate that containers are running and match the current mode
 	if err := dockerInterface.ValidateContainersRunning(); err != nil {
-		log.Fatalf("%v\n", err)
+		return err
 	}
 
 	fmt.Printf("[+] Backing up the PostgreSQL database with %s...\n", dockerInterface.ComposeFile)
 	err := dockerInterface.RunComposeCmd("run", "--rm", "postgres", "backup")
 	if err != nil {
-		log.Fatalf("Error trying to back up the PostgreSQL database with %s: %v\n", dockerInterface.ComposeFile, err)
+		return fmt.Errorf("failed to back up the PostgreSQL database with %s: %w", dockerInterface.ComposeFile, err)
 	}
 
 	err = dockerInterface.BackupMediaFiles()
 	if err != nil {
-		log.Fatalf("Error trying to back up media files with %s: %v\n", dockerInterface.ComposeFile, err)
+		return fmt.Errorf("failed to back up media files with %s: %w", dockerInterface.ComposeFile, err)
 	}
+	return nil
 }
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// certCmd represents the cert command
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "Inspect and manage the TLS certificate used by the Nginx web server",
+	Long: `Inspect and manage the TLS certificate used by the Nginx web server.
+
+The certificate, private key, and DH parameters are stored in the ssl/
+directory as ghostwriter.crt, ghostwriter.key, and dhparam.pem. Use the
+"gencert" command to create them.`,
+}
+
+func init() {
+	rootCmd.AddCommand(certCmd)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// certDHParamCmd represents the cert dhparam command
+var certDHParamCmd = &cobra.Command{
+	Use:   "dhparam",
+	Short: "Replace or check the Diffie-Hellman parameters used by Nginx",
+	Long: `Replace the Diffie-Hellman parameters in ssl/dhparam.pem, or check the
+current ones with --check.
+
+By default, the ffdhe2048 group from RFC 7919 is used. Use --group to pick
+ffdhe3072 or ffdhe4096, or "--group generate" with --bits to generate new
+parameters. Generating parameters can take several minutes and can be cancelled
+with Ctrl+C, which leaves the current file in place.
+
+The check confirms that the file holds a safe prime of at least 2048 bits and a
+valid generator.`,
+	RunE: certDHParam,
+}
+
+var (
+	dhParamOpts    internal.DHParamOptions
+	dhParamCheck   bool
+	dhParamRestart bool
+)
+
+func init() {
+	certCmd.AddCommand(certDHParamCmd)
+
+	dhParamOpts.Group = internal.DHGroupFFDHE2048
+	certDHParamCmd.Flags().Var(&dhParamOpts.Group, "group", "Source of the DH params: "+strings.Join(internal.AllDHGroups, ", "))
+	certDHParamCmd.Flags().IntVar(&dhParamOpts.Bits, "bits", 2048, "Size of generated DH params")
+	certDHParamCmd.Flags().BoolVar(&dhParamCheck, "check", false, "Check the current DH params instead of replacing them")
+	certDHParamCmd.Flags().BoolVar(&dhParamRestart, "restart", false, "Restart Nginx after replacing the DH params")
+}
+
+func certDHParam(cmd *cobra.Command, args []string) error {
+	dir, err := internal.GetDockerDirFromMode(mode)
+	if err != nil {
+		return err
+	}
+	sslPath := filepath.Join(dir, "ssl")
+
+	if dhParamCheck {
+		info, err := internal.ValidateDHParams(filepath.Join(sslPath, "dhparam.pem"))
+		if err != nil {
+			return fmt.Errorf("the DH params are not valid: %w", err)
+		}
+		group := "generated"
+		if info.Group != "" {
+			group = string(info.Group)
+		}
+		fmt.Printf("[+] The DH params are valid: %d bits (%s)\n", info.Bits, group)
+		return nil
+	}
+	if cmd.Flags().Changed("bits") && dhParamOpts.Group != internal.DHGroupGenerate {
+		return fmt.Errorf("--bits can only be used with --group generate")
+	}
+
+	opts := dhParamOpts
+	opts.Force = true
+	if err := internal.WriteDHParams(sslPath, &opts); err != nil {
+		return fmt.Errorf("failed to write the DH params: %w", err)
+	}
+	if !dhParamRestart {
+		fmt.Println("[*] Restart Nginx or bring containers down and up for Nginx to use the new DH params")
+		return nil
+	}
+	return restartNginx()
+}
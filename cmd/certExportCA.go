@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// certExportCACmd represents the cert export-ca command
+var certExportCACmd = &cobra.Command{
+	Use:   "export-ca",
+	Short: "Export the local certificate authority's certificate",
+	Long: `Export the certificate of the local certificate authority created by
+"gencert --ca", so it can be trusted on analyst workstations.
+
+The certificate is written in PEM format by default. Use "--format der" for
+tools that expect a binary certificate, such as the Windows certificate import
+wizard.
+
+For example: ghostwriter-cli cert export-ca --format der --out ghostwriter-ca.cer`,
+	RunE: certExportCA,
+}
+
+var (
+	exportFormat string
+	exportPath   string
+)
+
+func init() {
+	certCmd.AddCommand(certExportCACmd)
+
+	certExportCACmd.Flags().StringVar(&exportFormat, "format", "pem", "Certificate format: pem or der")
+	certExportCACmd.Flags().StringVar(&exportPath, "out", "", "File to write the certificate to (default: ghostwriter-ca.crt for PEM or ghostwriter-ca.cer for DER)")
+}
+
+func certExportCA(cmd *cobra.Command, args []string) error {
+	if exportFormat != "pem" && exportFormat != "der" {
+		return errors.New("--format must be one of: pem, der")
+	}
+	dir, err := internal.GetDockerDirFromMode(mode)
+	if err != nil {
+		return err
+	}
+
+	data, err := internal.ExportCA(filepath.Join(dir, "ssl"), exportFormat == "der")
+	if err != nil {
+		return fmt.Errorf("failed to export the CA certificate: %w", err)
+	}
+
+	path := exportPath
+	if path == "" {
+		path = "ghostwriter-ca.crt"
+		if exportFormat == "der" {
+			path = "ghostwriter-ca.cer"
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("[+] Wrote the CA certificate to %s\n", path)
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// certImportCmd represents the cert import command
+var certImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a TLS certificate issued by another certificate authority",
+	Long: `Import a TLS certificate issued by another certificate authority, such as a
+corporate PKI, for Nginx to use.
+
+The private key must match the certificate, and the certificate must chain to a
+trusted root. Include the intermediate certificates with --chain, along with the
+root if it isn't trusted by this system. The certificate must be valid for at
+least one of the hosts in DJANGO_ALLOWED_HOSTS, and a warning is printed for any
+others it doesn't cover.
+
+The certificate and its intermediates are written to ssl/ghostwriter.crt and the
+key to ssl/ghostwriter.key, readable only by the owner. The current files are
+copied to the ssl/archive/ directory first. Nginx is restarted afterwards if it
+is running.
+
+PKCS #12 bundles must use the legacy encryption from "openssl pkcs12 -export -legacy".
+Convert bundles encrypted with AES to PEM files first, e.g., with
+"openssl pkcs12 -in ghostwriter.pfx -nodes".
+
+For example:
+
+	ghostwriter-cli cert import --cert ghostwriter.crt --key ghostwriter.key --chain corp-chain.pem
+	ghostwriter-cli cert import --pkcs12 ghostwriter.pfx --password "$PFX_PASSWORD"`,
+	RunE: certImport,
+}
+
+var importOpts internal.ImportOptions
+
+func init() {
+	certCmd.AddCommand(certImportCmd)
+
+	certImportCmd.Flags().StringVar(&importOpts.CertPath, "cert", "", "PEM file with the certificate, optionally followed by its chain")
+	certImportCmd.Flags().StringVar(&importOpts.KeyPath, "key", "", "PEM file with the certificate's private key")
+	certImportCmd.Flags().StringVar(&importOpts.ChainPath, "chain", "", "PEM file with the intermediate and root certificates")
+	certImportCmd.Flags().StringVar(&importOpts.PKCS12Path, "pkcs12", "", "PKCS #12 (.pfx or .p12) bundle to import instead of PEM files")
+	certImportCmd.Flags().StringVar(&importOpts.Password, "password", "", "Password of the PKCS #12 bundle")
+}
+
+func certImport(cmd *cobra.Command, args []string) error {
+	dir, err := internal.GetDockerDirFromMode(mode)
+	if err != nil {
+		return err
+	}
+	if importOpts.PKCS12Path != "" && (importOpts.CertPath != "" || importOpts.KeyPath != "" || importOpts.ChainPath != "") {
+		return fmt.Errorf("--pkcs12 can't be used with --cert, --key, or --chain")
+	}
+
+	env, err := internal.ReadEnv(dir)
+	if err != nil {
+		return fmt.Errorf("could not read environment file: %w", err)
+	}
+	opts := importOpts
+	opts.Hosts = env.GetList("django_allowed_hosts")
+	opts.ExpiryWarningDays = env.CertExpiryWarningDays()
+
+	warnings, err := internal.ImportCertificate(dir, &opts)
+	if err != nil {
+		return fmt.Errorf("failed to import the certificate: %w", err)
+	}
+	for _, warning := range warnings {
+		fmt.Printf("[!] %s\n", warning)
+	}
+	fmt.Printf("[+] Imported the certificate to %s\n", filepath.Join(dir, "ssl", "ghostwriter.crt"))
+	return restartNginx()
+}
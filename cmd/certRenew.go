@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// certRenewCmd represents the cert renew command
+var certRenewCmd = &cobra.Command{
+	Use:   "renew",
+	Short: "Renew the TLS certificate from the local certificate authority or ACME",
+	Long: `Renew the TLS certificate from the certificate authority that issued it.
+
+For certificates requested with "gencert --acme", the saved ACME settings are
+used to request a new certificate once the current one expires within 30 days
+(or always with --force). This is safe to run daily from cron, e.g.:
+
+	0 3 * * * ghostwriter-cli cert renew --restart
+
+Nginx uses port 80, so for http-01 challenges a running Nginx container is
+stopped while the challenges are answered on a temporary listener, and started
+again once the new certificate is written. Ghostwriter is unavailable for the
+few seconds this takes. Use a dns-01 challenge to renew without stopping Nginx.
+
+Otherwise, a new certificate is issued from the local certificate authority
+created by "gencert --ca". It keeps the same Subject Alternative Names and key
+type unless --san or --key-type are used.
+
+The current certificate and key are copied to the ssl/archive/ directory. Use
+--restart to restart Nginx so it uses the new certificate.`,
+	RunE: certRenew,
+}
+
+var (
+	renewHosts        []string
+	renewDays         int
+	renewKeyAlgorithm internal.KeyAlgorithm
+	renewForce        bool
+	renewRestart      bool
+)
+
+func init() {
+	certCmd.AddCommand(certRenewCmd)
+
+	certRenewCmd.Flags().StringSliceVar(&renewHosts, "san", nil, "DNS name or IP address to include in the certificate (default: same as the current certificate)")
+	certRenewCmd.Flags().IntVar(&renewDays, "days", 365, "Number of days the certificate is valid for")
+	certRenewCmd.Flags().Var(&renewKeyAlgorithm, "key-type", "Private key algorithm (default: same as the current certificate)")
+	certRenewCmd.Flags().BoolVar(&renewForce, "force", false, "Renew an ACME certificate even if it's not close to expiring")
+	certRenewCmd.Flags().BoolVar(&renewRestart, "restart", false, "Restart Nginx after renewing the certificate")
+}
+
+func certRenew(cmd *cobra.Command, args []string) error {
+	dir, err := internal.GetDockerDirFromMode(mode)
+	if err != nil {
+		return err
+	}
+	if renewDays <= 0 {
+		return fmt.Errorf("--days must be a positive number")
+	}
+
+	if internal.ACMEConfigured(filepath.Join(dir, "ssl")) {
+		renewed, err := internal.RenewACMECertificate(dir, renewForce, stopNginxForChallenge)
+		if err != nil {
+			return fmt.Errorf("failed to renew the certificate: %w", err)
+		}
+		if !renewed {
+			return nil
+		}
+	} else {
+		err = internal.RenewCertificate(dir, &internal.CertificateOptions{
+			Hosts:        renewHosts,
+			Days:         renewDays,
+			KeyAlgorithm: renewKeyAlgorithm,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to renew the certificate: %w", err)
+		}
+	}
+	fmt.Printf("[+] Renewed %s\n", filepath.Join(dir, "ssl", "ghostwriter.crt"))
+
+	if !renewRestart {
+		fmt.Println("[*] Restart Nginx or bring containers down and up for Nginx to use the new certificate")
+		return nil
+	}
+	return restartNginx()
+}
+
+// Stops the Nginx container, if it's running, so the http-01 listener can use port 80
+// Returns a function that starts it again.
+func stopNginxForChallenge() (func() error, error) {
+	noop := func() error { return nil }
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		fmt.Printf("[!] Could not check if Nginx is running, so it will not be stopped: %s\n", err)
+		return noop, nil
+	}
+	running, err := dockerInterface.IsServiceRunning("nginx")
+	if err != nil || !running {
+		return noop, nil
+	}
+	fmt.Println("[+] Stopping Nginx to answer the http-01 challenge on port 80...")
+	if err := dockerInterface.RunComposeCmd("stop", "nginx"); err != nil {
+		return nil, fmt.Errorf("failed to stop Nginx: %w", err)
+	}
+	return func() error {
+		fmt.Println("[+] Starting Nginx...")
+		if err := dockerInterface.RunComposeCmd("start", "nginx"); err != nil {
+			return fmt.Errorf("failed to start Nginx again, run `containers up`: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// Restarts the Nginx container, if it's running, so it loads the current certificate
+func restartNginx() error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
+	running, err := dockerInterface.IsServiceRunning("nginx")
+	if err != nil || !running {
+		fmt.Println("[*] Nginx is not running, so it will use the new certificate when it starts")
+		return nil
+	}
+	fmt.Println("[+] Restarting Nginx...")
+	if err := dockerInterface.RunComposeCmd("restart", "nginx"); err != nil {
+		return fmt.Errorf("failed to restart Nginx: %w", err)
+	}
+	return nil
+}
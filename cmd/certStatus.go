@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// certStatusCmd represents the cert status command
+var certStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Display details of the TLS certificate in the ssl/ directory",
+	Long: `Display details of the TLS certificate in the ssl/ directory, including the
+subject, Subject Alternative Names, issuer, key type, and expiry date. The
+command also checks that ghostwriter.key matches the certificate and reports
+the size of the DH parameters in dhparam.pem.
+
+The "healthcheck" and "up" commands warn when the certificate expires within
+GWCLI_CERT_EXPIRY_WARNING_DAYS days (30 by default).`,
+	RunE: certStatus,
+}
+
+func init() {
+	certCmd.AddCommand(certStatusCmd)
+}
+
+func certStatus(cmd *cobra.Command, args []string) error {
+	dir, err := internal.GetDockerDirFromMode(mode)
+	if err != nil {
+		return err
+	}
+	status, err := internal.GetCertificateStatus(dir)
+	if err != nil {
+		return fmt.Errorf("failed to inspect the TLS certificate: %w", err)
+	}
+
+	return printResult(status, func(out io.Writer) {
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		defer writer.Flush()
+
+		expiry := fmt.Sprintf("%s (%d days remaining)", status.NotAfter.Format(time.RFC1123), status.DaysRemaining)
+		if status.DaysRemaining < 0 {
+			expiry = fmt.Sprintf("%s (EXPIRED)", status.NotAfter.Format(time.RFC1123))
+		}
+		dhParams := "–"
+		if status.DHParamBits > 0 {
+			dhParams = fmt.Sprintf("%d bits", status.DHParamBits)
+			if status.DHParamGroup != "" {
+				dhParams += fmt.Sprintf(" (%s)", status.DHParamGroup)
+			}
+		}
+
+		fmt.Fprintf(writer, "\n %s\t%s", "Property", "Value")
+		fmt.Fprintf(writer, "\n %s\t%s", "–––––––", "–––––––")
+		fmt.Fprintf(writer, "\n %s\t%s", "Path", status.Path)
+		fmt.Fprintf(writer, "\n %s\t%s", "Subject", status.Subject)
+		fmt.Fprintf(writer, "\n %s\t%s", "Issuer", status.Issuer)
+		fmt.Fprintf(writer, "\n %s\t%s", "Self-signed", yesNo(status.SelfSigned))
+		fmt.Fprintf(writer, "\n %s\t%s", "DNS names", listOrDash(status.DNSNames))
+		fmt.Fprintf(writer, "\n %s\t%s", "IP addresses", listOrDash(status.IPAddresses))
+		fmt.Fprintf(writer, "\n %s\t%s", "Key type", status.KeyType)
+		fmt.Fprintf(writer, "\n %s\t%s", "Key matches", yesNo(status.KeyMatches))
+		fmt.Fprintf(writer, "\n %s\t%s", "Valid from", status.NotBefore.Format(time.RFC1123))
+		fmt.Fprintf(writer, "\n %s\t%s", "Expires", expiry)
+		fmt.Fprintf(writer, "\n %s\t%s", "DH params", dhParams)
+		for _, message := range status.Errors {
+			fmt.Fprintf(writer, "\n %s\t%s", "Error", message)
+		}
+		fmt.Fprintln(writer, "")
+	})
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "Yes"
+	}
+	return "No"
+}
+
+// Joins a list for display in a table, using a dash for empty lists
+func listOrDash(values []string) string {
+	if len(values) == 0 {
+		return "–"
+	}
+	return strings.Join(values, ", ")
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	certs "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/acme"
 )
 
 // backupCmd represents the backup command
@@ -13,20 +16,123 @@ var certificatesCmd = &cobra.Command{
 	Short: "Create a new SSL/TLS certificate and DH param file for the Nginx web server",
 	Long: `Creates a new SSL/TLS certificate and DH params files for the Nginx web server in the ssl/ directory. This
 will not create a new certificate if the ssl/ghostwriter.key and ssl/ghostwriter.crt files already exist. Likewise, it
-will not generate a new DH params file if the ssl/dhparam.pem file already exist.
+will not generate a new DH params file if the ssl/dhparam.pem file already exists and is valid.
 
-Delete, move, or rename the files if you want to generate new ones.`,
-	Run: createCertificates,
+The DH params use the ffdhe2048 group from RFC 7919 by default. Use --dhparam to pick ffdhe3072 or ffdhe4096, or
+"--dhparam generate" with --dhparam-bits to generate new parameters, which can take several minutes. Using --dhparam
+replaces an existing ssl/dhparam.pem file.
+
+Use --force to replace an existing certificate. The old files are copied to the ssl/archive/ directory.
+
+The certificate's Subject Alternative Names default to the hostnames and IP addresses in DJANGO_ALLOWED_HOSTS.
+Use --san to list them instead, e.g.:
+
+	ghostwriter-cli gencert --force --san ghostwriter.example.com --san 10.0.0.5 --days 90 --key-type rsa-2048
+
+Use --ca to issue the certificate from a local certificate authority instead of self-signing it. The CA is created in
+ssl/ghostwriter-ca.crt and ssl/ghostwriter-ca.key the first time and reused afterwards, so its certificate only needs
+to be trusted on workstations once. Use "cert export-ca" to export it and "cert renew" to issue a new certificate
+from it.
+
+Use --acme to request the certificate from an ACME certificate authority such as Let's Encrypt. Only public DNS
+names are requested. The http-01 challenge answers on a temporary listener on port 80 (--acme-http-address). A
+running Nginx container is stopped while the challenges are answered and started again with the new certificate;
+stop anything else using the port first. The dns-01 challenge runs --acme-dns-hook as "<hook> present <name> <value>"
+to publish the TXT record and "<hook> cleanup <name> <value>" to remove it. The hook should only exit once the
+record is visible. The settings are saved in ssl/acme.json, so "cert renew" can renew the certificate from cron.
+Generating or importing a certificate any other way removes ssl/acme.json, so "cert renew" keeps that certificate.
+
+Use --acme-directory and --acme-ca-cert to test against a local ACME server such as Pebble, e.g.:
+
+	ghostwriter-cli gencert --force --acme --san ghostwriter.example.com \
+		--acme-directory https://localhost:14000/dir --acme-ca-cert pebble.minica.pem --acme-http-address :5002`,
+	RunE: createCertificates,
 }
 
+var (
+	certHosts        []string
+	certDays         int
+	certKeyAlgorithm = certs.KeyECDSAP384
+	certForce        bool
+	certUseCA        bool
+	certUseACME      bool
+	certACME         certs.ACMEOptions
+	certDHParams     certs.DHParamOptions
+)
+
 func init() {
 	rootCmd.AddCommand(certificatesCmd)
+
+	certificatesCmd.Flags().StringSliceVar(&certHosts, "san", nil, "DNS name or IP address to include in the certificate (default: DJANGO_ALLOWED_HOSTS)")
+	certificatesCmd.Flags().IntVar(&certDays, "days", 365, "Number of days the certificate is valid for")
+	certificatesCmd.Flags().Var(&certKeyAlgorithm, "key-type", "Private key algorithm: "+strings.Join(certs.AllKeyAlgorithms, ", "))
+	certificatesCmd.Flags().BoolVar(&certForce, "force", false, "Replace an existing certificate, archiving the old files")
+	certificatesCmd.Flags().BoolVar(&certUseCA, "ca", false, "Issue the certificate from the local certificate authority, creating it if needed")
+	certificatesCmd.Flags().BoolVar(&certUseACME, "acme", false, "Request the certificate from an ACME certificate authority")
+	certificatesCmd.Flags().StringVar(&certACME.DirectoryURL, "acme-directory", acme.LetsEncryptURL, "URL of the ACME directory")
+	certificatesCmd.Flags().StringVar(&certACME.Email, "acme-email", "", "Contact email address for the ACME account")
+	certificatesCmd.Flags().StringVar(&certACME.Challenge, "acme-challenge", certs.ChallengeHTTP01, "ACME challenge type: http-01 or dns-01")
+	certificatesCmd.Flags().StringVar(&certACME.HTTPAddress, "acme-http-address", ":80", "Address to answer http-01 challenges on")
+	certificatesCmd.Flags().StringVar(&certACME.DNSHook, "acme-dns-hook", "", "Script that publishes and removes the TXT records for dns-01 challenges")
+	certificatesCmd.Flags().StringVar(&certACME.CACert, "acme-ca-cert", "", "PEM file of additional roots to trust for the ACME directory, e.g., a test CA")
+	certDHParams.Group = certs.DHGroupFFDHE2048
+	certificatesCmd.Flags().Var(&certDHParams.Group, "dhparam", "Source of the DH params: "+strings.Join(certs.AllDHGroups, ", "))
+	certificatesCmd.Flags().IntVar(&certDHParams.Bits, "dhparam-bits", 2048, "Size of generated DH params")
 }
 
-func createCertificates(cmd *cobra.Command, args []string) {
-	path := certs.GetDockerDirFromMode(mode)
-	certErr := certs.GenerateCertificatePackage(path)
-	if certErr == nil {
-		fmt.Println("[+] Certificate generation complete!")
+func createCertificates(cmd *cobra.Command, args []string) error {
+	path, err := certs.GetDockerDirFromMode(mode)
+	if err != nil {
+		return err
+	}
+	if certDays <= 0 {
+		return fmt.Errorf("--days must be a positive number")
+	}
+	if certUseCA && certUseACME {
+		return fmt.Errorf("--ca and --acme can't be used together")
+	}
+	if cmd.Flags().Changed("dhparam-bits") && certDHParams.Group != certs.DHGroupGenerate {
+		return fmt.Errorf("--dhparam-bits can only be used with --dhparam generate")
+	}
+
+	hosts := certHosts
+	if len(hosts) == 0 {
+		env, err := certs.ReadEnv(path)
+		if err != nil {
+			return fmt.Errorf("could not read environment file: %w", err)
+		}
+		hosts = env.GetList("django_allowed_hosts")
+	}
+
+	opts := &certs.CertificateOptions{
+		Hosts:        hosts,
+		Days:         certDays,
+		KeyAlgorithm: certKeyAlgorithm,
+		Force:        certForce,
+		UseCA:        certUseCA,
+		DHParams:     certDHParams,
+	}
+	// Only replace a valid dhparam.pem when asked for a specific source
+	opts.DHParams.Force = cmd.Flags().Changed("dhparam")
+	if certUseACME {
+		// Renewals may run from a different working directory, so save the full paths of local files
+		if dnsHook := certACME.DNSHook; certs.FileExists(dnsHook) {
+			if certACME.DNSHook, err = filepath.Abs(dnsHook); err != nil {
+				return err
+			}
+		}
+		if caCert := certACME.CACert; caCert != "" {
+			if certACME.CACert, err = filepath.Abs(caCert); err != nil {
+				return err
+			}
+		}
+		certACME.FreeHTTPPort = stopNginxForChallenge
+		opts.ACME = &certACME
+	}
+	certErr := certs.GenerateCertificatePackage(path, opts)
+	if certErr != nil {
+		return certErr
 	}
+	fmt.Println("[+] Certificate generation complete!")
+	return nil
 }
@@ -2,8 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
-	"os"
+	"io"
 	"strings"
 	"text/tabwriter"
 
@@ -16,37 +15,91 @@ var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Display or adjust the configuration",
 	Long: `Run this command to display the configuration. Use subcommands to
-adjust the configuration or retrieve individual values.`,
-	Run: configDisplay,
+adjust the configuration or retrieve individual values.
+
+Passwords, keys, and other secrets are masked unless --show-secrets is used.
+
+Values can be read from other sources when the containers are managed, such as a
+password manager, instead of being kept in the environment file:
+
+	POSTGRES_PASSWORD='file:/run/secrets/postgres_password'
+	POSTGRES_PASSWORD='env:GW_POSTGRES_PASSWORD'
+	POSTGRES_PASSWORD='exec:pass show ghostwriter/postgres'
+
+Commands run with "exec:" are split on spaces and run without a shell. Trailing
+line breaks are removed from files and command output. The references are kept
+in the environment file, and the values are passed to Docker Compose.
+
+Values that depend on the --mode, such as DJANGO_SECURE_SSL_REDIRECT, come from
+the mode's profile unless they are set in the environment file. Use "config
+explain" to see where a value comes from.`,
+	RunE: configDisplay,
 }
 
+var showSecrets bool
+
 func init() {
 	rootCmd.AddCommand(configCmd)
+
+	configCmd.PersistentFlags().BoolVar(&showSecrets, "show-secrets", false, "Show the values of passwords, keys, and other secrets")
 }
 
-func configDisplay(cmd *cobra.Command, args []string) {
-	env, err := internal.ReadEnv(internal.GetDockerDirFromMode(mode))
-	if err != nil {
-		log.Fatalf("Could not read environment file: %s\n", err)
+// Masks the value of a secret unless --show-secrets is used
+func displayValue(key, value string) string {
+	if showSecrets {
+		return value
 	}
+	return internal.MaskedValue(key, value)
+}
 
-	// initialize tabwriter
-	writer := new(tabwriter.Writer)
-	// Set minwidth, tabwidth, padding, padchar, and flags
-	writer.Init(os.Stdout, 8, 8, 1, '\t', 0)
+// Reads the environment file for the current mode, with the mode's profile applied
+func readEnv() (*internal.GWEnvironment, error) {
+	dir, err := internal.GetDockerDirFromMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	env, err := internal.ReadEnv(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read environment file: %w", err)
+	}
+	env.ApplyMode(mode)
+	return env, nil
+}
 
-	defer writer.Flush()
+func configDisplay(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
 
 	fmt.Println("[+] Current configuration and available variables:")
-	fmt.Fprintf(writer, "\n %s\t%s", "Setting", "Value")
-	fmt.Fprintf(writer, "\n %s\t%s", "–––––––", "–––––––")
+	return printConfiguration(env.GetAll())
+}
 
-	configuration := env.GetAll()
+// Prints configuration values with their keys in upper case, the same as they appear in the environment file
+// Secrets are masked unless --show-secrets is used.
+func printConfiguration(configuration []internal.Configuration) error {
+	result := []internal.Configuration{}
 	for _, config := range configuration {
-		if config.Val == "" {
-			config.Val = "–"
-		}
-		fmt.Fprintf(writer, "\n %s\t%s", strings.ToUpper(config.Key), config.Val)
+		result = append(result, internal.Configuration{Key: strings.ToUpper(config.Key), Val: displayValue(config.Key, config.Val)})
 	}
-	fmt.Fprintln(writer, "")
+
+	return printResult(result, func(out io.Writer) {
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		defer writer.Flush()
+
+		fmt.Fprintf(writer, "\n %s\t%s", "Setting", "Value")
+		fmt.Fprintf(writer, "\n %s\t%s", "–––––––", "–––––––")
+		for _, config := range result {
+			if config.Val == "" {
+				config.Val = "–"
+			}
+			fmt.Fprintf(writer, "\n %s\t%s", config.Key, config.Val)
+		}
+		fmt.Fprintln(writer, "")
+	})
 }
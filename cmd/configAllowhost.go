@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -10,35 +14,203 @@ import (
 
 // configAllowhostCmd represents the configAllowhost command
 var configAllowHostCmd = &cobra.Command{
-	Use:   "allowhost <host>",
+	Use:   "allowhost <host> ...",
 	Short: "Add a hostname or IP address to the allowed hosts list",
-	Long: `Add a hostname or IP address to the allowed hosts list. Using a single "*"
+	Long: `Add hostnames or IP addresses to the allowed hosts list. Using a single "*"
 as a wildcard to allow all hostnames and IP address will work, but the use of
-wildcards in a hostname or address will not work.
+wildcards in a hostname or address will not work. Start a hostname with a "."
+to allow the domain and all of its subdomains.
 
 Using "*" is NOT recommended! It should only be used for testing purposes.
 
+Entries are checked against Django's rules and invalid ones are rejected, such as
+wildcards in hostnames, CIDR ranges, ports, and schemes. Use --force to add them
+anyway. IPv6 addresses must be in brackets.
+
+Use --file to add the entries in a file, one or more on each line, or "-" to read
+them from stdin. Use --list to list the current entries and any problems with
+them.
+
 Good examples:
 	ghostwriter-cli config allowhost 192.168.1.100
-	ghostwriter-cli config allowhost ghostwriter.local
+	ghostwriter-cli config allowhost ghostwriter.local .example.com [::1]
+	ghostwriter-cli config allowhost --file hosts.txt
 	ghostwriter-cli config allowhost *
 Bad examples:
 	ghostwriter-cli config allowhost *.example.com
-	ghostwriter-cli config allowhost 192.168.1.*`,
-	Args: cobra.ExactArgs(1),
-	Run:  configAllowHost,
+	ghostwriter-cli config allowhost 192.168.1.*
+	ghostwriter-cli config allowhost 192.168.1.0/24`,
+	RunE: configAllowHost,
+}
+
+// Flags of the commands that change a host or origin list
+type hostListFlags struct {
+	list  bool
+	file  string
+	force bool
 }
 
+var configAllowHostFlags hostListFlags
+
 func init() {
 	configCmd.AddCommand(configAllowHostCmd)
+
+	configAllowHostCmd.Flags().BoolVar(&configAllowHostFlags.list, "list", false, "List the allowed hosts and any problems with them")
+	configAllowHostCmd.Flags().StringVar(&configAllowHostFlags.file, "file", "", "File with the hosts to add, or \"-\" for stdin")
+	configAllowHostCmd.Flags().BoolVar(&configAllowHostFlags.force, "force", false, "Add hosts even if Django won't match them")
 }
 
-func configAllowHost(cmd *cobra.Command, args []string) {
-	env, err := internal.ReadEnv(internal.GetDockerDirFromMode(mode))
+func configAllowHost(cmd *cobra.Command, args []string) error {
+	if configAllowHostFlags.list {
+		if len(args) > 0 || configAllowHostFlags.file != "" {
+			return fmt.Errorf("--list can't be used with hosts to add")
+		}
+		return printHostList("django_allowed_hosts")
+	}
+	return addHosts("django_allowed_hosts", args, &configAllowHostFlags)
+}
+
+// Gets the entries given as arguments and in the file named by --file
+func readHostArgs(args []string, file string) ([]string, error) {
+	hosts := slices.Clone(args)
+	if file != "" {
+		var reader io.Reader = os.Stdin
+		if file != "-" {
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", file, err)
+			}
+			defer f.Close()
+			reader = f
+		}
+		fileHosts, err := internal.ReadHostList(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		hosts = append(hosts, fileHosts...)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no entries were given; pass them as arguments or use --file")
+	}
+	return hosts, nil
+}
+
+// Adds entries to a host or origin list after checking all of them
+func addHosts(key string, args []string, flags *hostListFlags) error {
+	hosts, err := readHostArgs(args, flags.file)
 	if err != nil {
-		log.Fatalf("Could not read environment file: %s\n", err)
+		return err
+	}
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, host := range hosts {
+		entry := internal.CheckHost(key, host)
+		switch {
+		case entry.Error != "" && flags.force:
+			fmt.Printf("[!] Adding it anyway: %s\n", entry.Error)
+		case entry.Error != "":
+			fmt.Printf("[!] %s\n", entry.Error)
+			invalid++
+		case entry.Warning != "":
+			fmt.Printf("[!] %s: %s\n", host, entry.Warning)
+		}
+	}
+	if invalid == 1 {
+		return fmt.Errorf("nothing was added because of the invalid entry; fix it or use --force to add it anyway")
+	} else if invalid > 1 {
+		return fmt.Errorf("nothing was added because of the %d invalid entries; fix them or use --force to add them anyway", invalid)
+	}
+
+	changed := false
+	for _, host := range hosts {
+		if slices.Contains(env.GetList(key), host) {
+			fmt.Printf("[*] %s is already in the list\n", host)
+			continue
+		}
+		env.AppendHost(key, host)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	if err := env.Save(); err != nil {
+		return err
+	}
+	fmt.Println("[+] Configuration successfully updated. Bring containers down and up for changes to take effect.")
+	return nil
+}
+
+// Removes entries from a host or origin list
+func removeHosts(key string, args []string, file string) error {
+	hosts, err := readHostArgs(args, file)
+	if err != nil {
+		return err
+	}
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, host := range hosts {
+		if !slices.Contains(env.GetList(key), host) {
+			fmt.Printf("[*] %s is not in the list\n", host)
+			continue
+		}
+		env.RemoveHost(key, host)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	if err := env.Save(); err != nil {
+		return err
+	}
+	if key == "django_allowed_hosts" && len(env.GetList(key)) == 0 {
+		fmt.Println("[!] The allowed hosts list is empty, so Django will reject every request")
 	}
-	env.AppendHost("django_allowed_hosts", args[0])
-	env.Save()
 	fmt.Println("[+] Configuration successfully updated. Bring containers down and up for changes to take effect.")
+	return nil
+}
+
+// Prints the entries of a host or origin list with any problems found with them
+func printHostList(key string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	entries := env.ListHosts(key)
+	return printResult(entries, func(out io.Writer) {
+		if len(entries) == 0 {
+			fmt.Fprintf(out, "[*] %s is empty\n", strings.ToUpper(key))
+			return
+		}
+
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		fmt.Fprintf(writer, "\n %s\t%s", "Entry", "Problems")
+		fmt.Fprintf(writer, "\n %s\t%s", "–––––", "––––––––")
+		invalid := 0
+		for _, entry := range entries {
+			problem := entry.Warning
+			if entry.Error != "" {
+				problem = "Invalid: " + entry.Error
+				invalid++
+			}
+			fmt.Fprintf(writer, "\n %s\t%s", entry.Host, valueOrDash(problem))
+		}
+		fmt.Fprintln(writer, "")
+		writer.Flush()
+
+		if invalid > 0 {
+			fmt.Fprintf(out, "[!] Django won't match %d of the entries; remove them and add valid ones\n", invalid)
+		}
+	})
 }
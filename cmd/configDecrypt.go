@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// configDecryptCmd represents the config decrypt command
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt the environment file",
+	Long: `Decrypt the environment file encrypted by "config encrypt". The
+plaintext values are written to .env, and .env.enc is removed.
+
+The passphrase is read from the file named by GHOSTWRITER_CLI_KEY_FILE, or else
+from GHOSTWRITER_CLI_PASSPHRASE.`,
+	RunE: configDecrypt,
+}
+
+func init() {
+	configCmd.AddCommand(configDecryptCmd)
+}
+
+func configDecrypt(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	if !env.Encrypted() {
+		return fmt.Errorf("the environment file is not encrypted")
+	}
+	if err := env.Decrypt(); err != nil {
+		return fmt.Errorf("failed to decrypt the environment file: %w", err)
+	}
+	fmt.Println("[+] The environment file is now decrypted")
+	return nil
+}
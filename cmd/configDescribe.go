@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// configDescribeCmd represents the config describe command
+var configDescribeCmd = &cobra.Command{
+	Use:   "describe [configuration] ...",
+	Short: "Describe what the configuration values do",
+	Long: `Describe what configuration values do, along with their type, allowed
+values, default, and current value, whether they are secrets, and which
+containers use them. Describes every value if none are given.
+
+Aliases, such as ADMIN_PASSWORD, describe the setting they stand for. The values
+of secrets are masked unless --show-secrets is used.
+
+For example: ghostwriter-cli config describe DJANGO_ACCOUNT_EMAIL_VERIFICATION`,
+	RunE: configDescribe,
+}
+
+func init() {
+	configCmd.AddCommand(configDescribeCmd)
+}
+
+func configDescribe(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	descriptions, err := env.DescribeSettings(args...)
+	if err != nil {
+		return err
+	}
+	for i := range descriptions {
+		descriptions[i].Value = displayValue(descriptions[i].Key, descriptions[i].Value)
+	}
+
+	return printResult(descriptions, func(out io.Writer) {
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		defer writer.Flush()
+
+		for _, setting := range descriptions {
+			fmt.Fprintf(writer, "\n %s\t%s", "Setting", setting.Key)
+			fmt.Fprintf(writer, "\n %s\t%s", "–––––––", "–––––––")
+			fmt.Fprintf(writer, "\n %s\t%s", "Description", setting.Description)
+			if len(setting.Aliases) > 0 {
+				fmt.Fprintf(writer, "\n %s\t%s", "Aliases", strings.Join(setting.Aliases, ", "))
+			}
+			fmt.Fprintf(writer, "\n %s\t%s", "Type", setting.Type)
+			if len(setting.Allowed) > 0 {
+				fmt.Fprintf(writer, "\n %s\t%s", "Allowed values", strings.Join(setting.Allowed, ", "))
+			}
+			fmt.Fprintf(writer, "\n %s\t%s", "Default", valueOrDash(setting.Default))
+			fmt.Fprintf(writer, "\n %s\t%s", "Current value", valueOrDash(setting.Value))
+			fmt.Fprintf(writer, "\n %s\t%s", "Secret", yesNo(setting.Secret))
+			fmt.Fprintf(writer, "\n %s\t%s", "Restart", listOrDash(setting.Services))
+			fmt.Fprintln(writer, "")
+		}
+	})
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "–"
+	}
+	return value
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configDiffCmd represents the config diff command
+var configDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the configuration with the defaults, another file, or the running containers",
+	Long: `Compare the environment file with the defaults and list the settings that
+were added, removed, or changed. Generated passwords are left out of the
+comparison with the defaults.
+
+Use --file to compare with another environment file instead, e.g., a backup or
+the file from another server.
+
+Use --running to compare with the environment of the running containers. This
+finds settings that were changed with "config set" without recreating the
+containers, which are still using the old values. Bring the containers down and
+up, or use "containers up", to apply them.
+
+Use --exit-code to exit with code 1 if there are differences, for scripts.
+
+For example: ghostwriter-cli config diff --running --exit-code`,
+	Args: cobra.NoArgs,
+	RunE: configDiff,
+}
+
+var (
+	configDiffFile     string
+	configDiffRunning  bool
+	configDiffExitCode bool
+)
+
+func init() {
+	configCmd.AddCommand(configDiffCmd)
+
+	configDiffCmd.Flags().StringVar(&configDiffFile, "file", "", "Environment file to compare with instead of the defaults")
+	configDiffCmd.Flags().BoolVar(&configDiffRunning, "running", false, "Compare with the environment of the running containers instead of the defaults")
+	configDiffCmd.Flags().BoolVar(&configDiffExitCode, "exit-code", false, "Exit with code 1 if there are differences")
+}
+
+func configDiff(cmd *cobra.Command, args []string) error {
+	if configDiffRunning && configDiffFile != "" {
+		return fmt.Errorf("--file can't be used with --running")
+	}
+
+	var changes []internal.ConfigChange
+	other := "Default"
+	switch {
+	case configDiffRunning:
+		dockerInterface, err := getDockerInterface(mode)
+		if err != nil {
+			return err
+		}
+		changes, err = dockerInterface.DiffRunning()
+		if err != nil {
+			return err
+		}
+		other = "Running"
+	case configDiffFile != "":
+		env, err := readEnv()
+		if err != nil {
+			return err
+		}
+		changes, err = env.DiffFile(configDiffFile)
+		if err != nil {
+			return err
+		}
+		other = "Other File"
+	default:
+		env, err := readEnv()
+		if err != nil {
+			return err
+		}
+		changes = env.DiffDefaults()
+	}
+
+	result := []internal.ConfigChange{}
+	for _, change := range changes {
+		change.Key = strings.ToUpper(change.Key)
+		change.Current = displayValue(change.Key, change.Current)
+		change.Other = displayValue(change.Key, change.Other)
+		result = append(result, change)
+	}
+
+	err := printResult(result, func(out io.Writer) {
+		if len(result) == 0 {
+			fmt.Fprintln(out, "[+] No differences found")
+			return
+		}
+
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		defer writer.Flush()
+
+		fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", "Setting", "Change", "Current", other)
+		fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", "–––––––", "––––––", "–––––––", strings.Repeat("–", len(other)))
+		for _, change := range result {
+			fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", change.Key, change.Change, valueOrDash(change.Current), valueOrDash(change.Other))
+			if len(change.Services) > 0 {
+				fmt.Fprintf(writer, " (%s)", strings.Join(change.Services, ", "))
+			}
+		}
+		fmt.Fprintln(writer, "")
+	})
+	if err != nil {
+		return err
+	}
+	if configDiffExitCode && len(result) > 0 {
+		cmd.SilenceErrors = true
+		return &ExitCodeError{Code: 1}
+	}
+	return nil
+}
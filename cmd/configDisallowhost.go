@@ -1,32 +1,28 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
-
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
 // configDisallowhostCmd represents the configDisallowhost command
 var configDisallowHostCmd = &cobra.Command{
-	Use:   "disallowhost <host>",
+	Use:   "disallowhost <host> ...",
 	Short: "Remove a hostname or IP address to the allowed hosts list",
-	Long:  "Remove a hostname or IP address to the allowed hosts list.",
-	Args:  cobra.ExactArgs(1),
-	Run:   configDisallowHost,
+	Long: `Remove hostnames or IP addresses from the allowed hosts list.
+
+Use --file to remove the entries in a file, one or more on each line, or "-" to
+read them from stdin.`,
+	RunE: configDisallowHost,
 }
 
+var configDisallowHostFile string
+
 func init() {
 	configCmd.AddCommand(configDisallowHostCmd)
+
+	configDisallowHostCmd.Flags().StringVar(&configDisallowHostFile, "file", "", "File with the hosts to remove, or \"-\" for stdin")
 }
 
-func configDisallowHost(cmd *cobra.Command, args []string) {
-	env, err := internal.ReadEnv(internal.GetDockerDirFromMode(mode))
-	if err != nil {
-		log.Fatalf("Could not read environment file: %s\n", err)
-	}
-	env.RemoveHost("django_allowed_hosts", args[0])
-	env.Save()
-	fmt.Println("[+] Configuration successfully updated. Bring containers down and up for changes to take effect.")
+func configDisallowHost(cmd *cobra.Command, args []string) error {
+	return removeHosts("django_allowed_hosts", args, configDisallowHostFile)
 }
@@ -1,34 +1,30 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
-
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
 // configDistrustOriginCmd represents the configDistrustOrigin command
 var configDistrustOriginCmd = &cobra.Command{
-	Use:   "distrustorigin <host>",
-	Short: "Remove a hostname from the trusted origins list",
-	Long: `Remove a hostname from the trusted origins list. Removing a host from this list will
-mean that Ghostwriter will block requests where the host appears in the "Origin" or
-"Referer" headers of requests and does not match the "Host" header.`,
-	Args: cobra.ExactArgs(1),
-	Run:  configDistrustOrigin,
+	Use:   "distrustorigin <origin> ...",
+	Short: "Remove an origin from the trusted origins list",
+	Long: `Remove origins from the trusted origins list. Removing an origin from this list will
+mean that Ghostwriter will block requests where the origin appears in the "Origin" or
+"Referer" headers of requests and does not match the "Host" header.
+
+Use --file to remove the entries in a file, one or more on each line, or "-" to
+read them from stdin.`,
+	RunE: configDistrustOrigin,
 }
 
+var configDistrustOriginFile string
+
 func init() {
 	configCmd.AddCommand(configDistrustOriginCmd)
+
+	configDistrustOriginCmd.Flags().StringVar(&configDistrustOriginFile, "file", "", "File with the origins to remove, or \"-\" for stdin")
 }
 
-func configDistrustOrigin(cmd *cobra.Command, args []string) {
-	env, err := internal.ReadEnv(internal.GetDockerDirFromMode(mode))
-	if err != nil {
-		log.Fatalf("Could not read environment file: %s\n", err)
-	}
-	env.RemoveHost("django_csrf_trusted_origins", args[0])
-	env.Save()
-	fmt.Println("[+] Configuration successfully updated. Bring containers down and up for changes to take effect.")
+func configDistrustOrigin(cmd *cobra.Command, args []string) error {
+	return removeHosts("django_csrf_trusted_origins", args, configDistrustOriginFile)
 }
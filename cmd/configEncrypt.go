@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configEncryptCmd represents the config encrypt command
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the environment file with a passphrase",
+	Long: `Encrypt the environment file with a passphrase, so a copy of the data
+directory doesn't expose the database and Hasura credentials. The encrypted file
+is written to .env.enc with AES-256-GCM, and the plaintext .env file is removed.
+
+The passphrase is read from the file named by GHOSTWRITER_CLI_KEY_FILE, or else
+from GHOSTWRITER_CLI_PASSPHRASE. Use --generate-key to write a random passphrase
+to a new key file instead. One of these variables must be set for every later
+command, which decrypts the file and passes the values to Docker Compose.
+
+Keep the passphrase or key file outside of the data directory. The configuration
+can't be recovered without it.
+
+Docker Compose can't read the encrypted file, so use "config decrypt" before
+running "docker compose" directly.
+
+For example:
+
+	ghostwriter-cli config encrypt --generate-key ~/.config/ghostwriter.key
+	export GHOSTWRITER_CLI_KEY_FILE=~/.config/ghostwriter.key`,
+	RunE: configEncrypt,
+}
+
+var configEncryptGenerateKey string
+
+func init() {
+	configCmd.AddCommand(configEncryptCmd)
+
+	configEncryptCmd.Flags().StringVar(&configEncryptGenerateKey, "generate-key", "", "Write a random passphrase to a new key file at this path and use it")
+}
+
+func configEncrypt(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	if env.Encrypted() {
+		return fmt.Errorf("the environment file is already encrypted")
+	}
+
+	var passphrase []byte
+	if configEncryptGenerateKey != "" {
+		key, err := internal.GenerateRandomPassword(48, true)
+		if err != nil {
+			return err
+		}
+		file, err := os.OpenFile(configEncryptGenerateKey, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create the key file: %w", err)
+		}
+		_, err = fmt.Fprintln(file, key)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write the key file: %w", err)
+		}
+		fmt.Printf("[+] Wrote a new key file to %s\n", configEncryptGenerateKey)
+		passphrase = []byte(key)
+	} else {
+		passphrase, err = internal.GetPassphrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := env.Encrypt(passphrase); err != nil {
+		return fmt.Errorf("failed to encrypt the environment file: %w", err)
+	}
+	fmt.Println("[+] The environment file is now encrypted")
+	if configEncryptGenerateKey != "" {
+		fmt.Printf("[*] Set %s=%s for future commands\n", internal.KeyFileEnvVar, configEncryptGenerateKey)
+	}
+	return nil
+}
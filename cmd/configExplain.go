@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configExplainCmd represents the config explain command
+var configExplainCmd = &cobra.Command{
+	Use:   "explain <configuration>",
+	Short: "Show where a configuration value comes from",
+	Long: `Show the value of a setting in each layer of the configuration and which
+layer the current value comes from. From lowest to highest precedence, the
+layers are:
+
+	Default               The default from Ghostwriter CLI's settings
+	Mode profile          Values for the development or production mode
+	Environment file      Values in .env, set with "config set" or by hand
+	Environment variable  Variables set in the shell running Ghostwriter CLI
+	Override              Values set by a command for that run, e.g., "test"
+
+The mode profile and overrides are passed to Docker Compose when it runs and are
+never written to the environment file. Set a value with "config set" to
+override the mode profile, and remove it from the environment file to use the
+profile again. The values of secrets are masked unless --show-secrets is used.
+
+For example: ghostwriter-cli config explain DJANGO_SECURE_SSL_REDIRECT --mode prod`,
+	Args: cobra.ExactArgs(1),
+	RunE: configExplain,
+}
+
+func init() {
+	configCmd.AddCommand(configExplainCmd)
+}
+
+// Names of the layers shown in tables
+var layerNames = map[string]string{
+	internal.LayerDefault:     "Default",
+	internal.LayerMode:        "Mode profile",
+	internal.LayerFile:        "Environment file",
+	internal.LayerEnvironment: "Environment variable",
+	internal.LayerOverride:    "Override",
+}
+
+func configExplain(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	explanation, err := env.Explain(args[0])
+	if err != nil {
+		return err
+	}
+	explanation.Value = displayValue(explanation.Key, explanation.Value)
+	for i, layer := range explanation.Layers {
+		// The default of a generated password describes it instead of holding it
+		if layer.Layer != internal.LayerDefault {
+			explanation.Layers[i].Value = displayValue(explanation.Key, layer.Value)
+		}
+	}
+
+	return printResult(explanation, func(out io.Writer) {
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "Layer", "Value", "")
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "–––––", "–––––", "")
+		for _, layer := range explanation.Layers {
+			name := layerNames[layer.Layer]
+			if layer.Layer == internal.LayerMode {
+				name = fmt.Sprintf("%s (%s)", name, explanation.Mode)
+			}
+			value := "Not set"
+			if layer.Set {
+				value = valueOrDash(layer.Value)
+			}
+			used := ""
+			if layer.Layer == explanation.Source {
+				used = "<- Used"
+			}
+			fmt.Fprintf(writer, "\n %s\t%s\t%s", name, value, used)
+		}
+		fmt.Fprintln(writer, "")
+		writer.Flush()
+
+		fmt.Fprintf(out, "[+] %s is %s, from the %s layer\n", explanation.Key, valueOrDash(explanation.Value), strings.ToLower(layerNames[explanation.Source]))
+	})
+}
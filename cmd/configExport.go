@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	yaml "github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+// configExportCmd represents the config export command
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the configuration as a profile for other deployments",
+	Long: `Export the configuration as a YAML or JSON profile that can be imported
+into other deployments with "config import".
+
+Use --exclude-secrets to leave out passwords, keys, and other secrets, which are
+otherwise included in plaintext. Use --exclude-host to leave out settings that
+depend on the server: DJANGO_ALLOWED_HOSTS, DJANGO_CSRF_TRUSTED_ORIGINS, and
+NGINX_PORT.
+
+Only the values in the environment file and the defaults are exported. Values
+that come from the mode (development or production), environment variables, or
+overrides belong to this deployment and are left out.
+
+The profile is printed unless --file is used. JSON is used if --format is json,
+or if the file name ends with .json.
+
+For example: ghostwriter-cli config export --exclude-secrets --exclude-host --file profile.yml`,
+	Args: cobra.NoArgs,
+	RunE: configExport,
+}
+
+var (
+	configExportOpts   internal.ExportOptions
+	configExportFile   string
+	configExportFormat string
+)
+
+func init() {
+	configCmd.AddCommand(configExportCmd)
+
+	configExportCmd.Flags().BoolVar(&configExportOpts.ExcludeSecrets, "exclude-secrets", false, "Leave out passwords, keys, and other secrets")
+	configExportCmd.Flags().BoolVar(&configExportOpts.ExcludeHostSpecific, "exclude-host", false, "Leave out settings that depend on the server, such as its hostnames")
+	configExportCmd.Flags().StringVar(&configExportFile, "file", "", "Write the profile to this file instead of printing it")
+	configExportCmd.Flags().StringVar(&configExportFormat, "format", "yaml", "Format of the profile, either yaml or json")
+}
+
+func configExport(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(configExportFormat)
+	if !cmd.Flags().Changed("format") && strings.HasSuffix(strings.ToLower(configExportFile), ".json") {
+		format = "json"
+	}
+	if format != "yaml" && format != "json" {
+		return fmt.Errorf("--format must be yaml or json")
+	}
+
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	profile := env.ExportProfile(&configExportOpts)
+
+	var data []byte
+	if format == "json" {
+		data, err = json.MarshalIndent(profile, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(profile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to format the profile: %w", err)
+	}
+
+	if configExportFile == "" {
+		_, err = resultWriter.Write(data)
+		return err
+	}
+	if err := os.WriteFile(configExportFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write the profile: %w", err)
+	}
+	fmt.Printf("[+] Exported %d settings to %s\n", len(profile.Settings), configExportFile)
+	if !configExportOpts.ExcludeSecrets {
+		fmt.Println("[!] The profile includes secrets, so keep it safe or use --exclude-secrets")
+	}
+	return nil
+}
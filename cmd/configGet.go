@@ -2,10 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
-	"os"
-	"strings"
-	"text/tabwriter"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -18,37 +14,48 @@ var configGetCmd = &cobra.Command{
 	Long: `Get the specified configuration values. You can provide one value or
 a list of values separated by spaces.
 
-For example: ghostwriter-cli config get ADMIN_PASSWORD POSTGRES_PASSWORD`,
-	Run: configGet,
+Passwords, keys, and other secrets are masked unless --show-secrets is used.
+Values that reference a file, environment variable, or command are shown as
+the reference. Use --raw to print only the values, one per line and unmasked,
+for scripts. References are resolved with --raw, so commands are run.
+
+For example: ghostwriter-cli config get ADMIN_PASSWORD POSTGRES_PASSWORD
+	PGPASSWORD=$(ghostwriter-cli config get --raw POSTGRES_PASSWORD)`,
+	RunE: configGet,
 }
 
+var configGetRaw bool
+
 func init() {
 	configCmd.AddCommand(configGetCmd)
-}
 
-func configGet(cmd *cobra.Command, args []string) {
-	// initialize tabwriter
-	writer := new(tabwriter.Writer)
-	// Set minwidth, tabwidth, padding, padchar, and flags
-	writer.Init(os.Stdout, 8, 8, 1, '\t', 0)
-
-	defer writer.Flush()
+	configGetCmd.Flags().BoolVar(&configGetRaw, "raw", false, "Print only the values, without masking secrets")
+}
 
-	env, err := internal.ReadEnv(internal.GetDockerDirFromMode(mode))
+func configGet(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
 	if err != nil {
-		log.Fatalf("Could not read environment file: %s\n", err)
+		return err
 	}
 
-	fmt.Println("[+] Getting configuration values:")
-	fmt.Fprintf(writer, "\n %s\t%s", "Setting", "Value")
-	fmt.Fprintf(writer, "\n %s\t%s", "–––––––", "–––––––")
+	if configGetRaw {
+		if len(args) == 0 {
+			return fmt.Errorf("--raw needs at least one configuration value")
+		}
+		for _, arg := range args {
+			value, err := env.ResolvedValue(arg)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(resultWriter, value)
+		}
+		return nil
+	}
 
+	fmt.Println("[+] Getting configuration values:")
+	configuration := []internal.Configuration{}
 	for _, arg := range args {
-		val := env.Get(arg)
-		if val == "" {
-			val = "–"
-		}
-		fmt.Fprintf(writer, "\n %s\t%s", strings.ToUpper(arg), val)
+		configuration = append(configuration, internal.Configuration{Key: arg, Val: env.Get(arg)})
 	}
-	fmt.Fprintln(writer, "")
+	return printConfiguration(configuration)
 }
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configHistoryCmd represents the config history command
+var configHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the previous versions of the environment file",
+	Long: `List the previous versions of the environment file, most recent first, with
+the settings that changed when each one was replaced.
+
+A copy of the environment file is kept in the config_history directory in the
+data directory every time a command changes it. The number of versions kept is
+set by GWCLI_CONFIG_HISTORY_LIMIT (20 by default), and 0 stops keeping them.
+
+Use "config rollback" to restore one of the versions.`,
+	Args: cobra.NoArgs,
+	RunE: configHistory,
+}
+
+func init() {
+	configCmd.AddCommand(configHistoryCmd)
+}
+
+func configHistory(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	versions, err := env.History()
+	if err != nil {
+		return err
+	}
+
+	return printResult(versions, func(out io.Writer) {
+		if len(versions) == 0 {
+			fmt.Fprintln(out, "[*] The configuration history is empty")
+			return
+		}
+
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		defer writer.Flush()
+
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "Version", "Replaced", "Changed Settings")
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "–––––––", "––––––––", "––––––––––––––––")
+		for _, version := range versions {
+			fmt.Fprintf(writer, "\n %d\t%s\t%s", version.Number, version.Replaced.Format("2006-01-02 15:04:05"), formatChangedSettings(version))
+		}
+		fmt.Fprintln(writer, "")
+	})
+}
+
+// Lists the settings changed when a version was replaced
+func formatChangedSettings(version internal.ConfigVersion) string {
+	switch {
+	case version.Changed == nil:
+		return "Unknown"
+	case len(version.Changed) == 0:
+		return "None"
+	}
+	return strings.ToUpper(strings.Join(version.Changed, ", "))
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configImportCmd represents the config import command
+var configImportCmd = &cobra.Command{
+	Use:   "import <profile>",
+	Short: "Import a profile exported from another deployment",
+	Long: `Import a YAML or JSON profile exported by "config export" and merge it into
+the environment file.
+
+Settings that still have their default values here are set from the profile.
+Settings that were changed here are reported as conflicts and keep their values,
+unless --overwrite is used. Invalid values are skipped.
+
+Generated passwords that were left out of the profile with --exclude-secrets
+keep their values here, or get new random values if this deployment doesn't have
+them yet.
+
+Values that reference a file, environment variable, or command (file:, env:, or
+exec:) are skipped and listed, since commands are run on this host whenever the
+containers are managed. Review them and use --allow-refs to import them.
+
+Use --dry-run to see the results without saving them. Bring containers down and
+up for the changes to take effect.
+
+For example: ghostwriter-cli config import profile.yml`,
+	Args: cobra.ExactArgs(1),
+	RunE: configImport,
+}
+
+var (
+	configImportOpts   internal.ProfileImportOptions
+	configImportDryRun bool
+)
+
+func init() {
+	configCmd.AddCommand(configImportCmd)
+
+	configImportCmd.Flags().BoolVar(&configImportOpts.Overwrite, "overwrite", false, "Replace values that were changed in this deployment")
+	configImportCmd.Flags().BoolVar(&configImportOpts.AllowRefs, "allow-refs", false, "Import values that reference a file, environment variable, or command")
+	configImportCmd.Flags().BoolVar(&configImportDryRun, "dry-run", false, "Show the results without saving them")
+}
+
+func configImport(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read the profile: %w", err)
+	}
+	profile, err := internal.ParseProfile(data)
+	if err != nil {
+		return err
+	}
+
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	changes := env.ImportProfile(profile, &configImportOpts)
+	if !configImportDryRun {
+		if err := env.Save(); err != nil {
+			return err
+		}
+	}
+
+	result := []internal.ProfileChange{}
+	skippedRefs := 0
+	for _, change := range changes {
+		change.Key = strings.ToUpper(change.Key)
+		result = append(result, change)
+		if change.Result == internal.ImportReference {
+			skippedRefs++
+		}
+	}
+	err = printResult(result, func(out io.Writer) {
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "Setting", "Result", "Details")
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "–––––––", "––––––", "–––––––")
+		for _, change := range result {
+			if change.Result != internal.ImportUnchanged {
+				fmt.Fprintf(writer, "\n %s\t%s\t%s", change.Key, change.Result, valueOrDash(change.Message))
+			}
+		}
+		fmt.Fprintln(writer, "")
+		writer.Flush()
+
+		if skippedRefs > 0 {
+			fmt.Fprintf(out, "[!] Skipped %d reference(s) to files, environment variables, or commands; review them and use --allow-refs to import them\n", skippedRefs)
+		}
+		if configImportDryRun {
+			fmt.Fprintln(out, "[*] Dry run, so the environment file was not changed")
+		} else {
+			fmt.Fprintln(out, "[+] Profile imported. Bring containers down and up for changes to take effect.")
+		}
+	})
+	return err
+}
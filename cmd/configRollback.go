@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configRollbackCmd represents the config rollback command
+var configRollbackCmd = &cobra.Command{
+	Use:   "rollback <version>",
+	Short: "Restore a previous version of the environment file",
+	Long: `Restore a previous version of the environment file from the configuration
+history. Versions are numbered as listed by "config history", starting from 1 for
+the most recent one.
+
+The current environment file is added to the history before it's replaced, so a
+rollback can be undone with "config rollback 1". Bring containers down and up for
+the changes to take effect.
+
+For example: ghostwriter-cli config rollback 1`,
+	Args: cobra.ExactArgs(1),
+	RunE: configRollback,
+}
+
+func init() {
+	configCmd.AddCommand(configRollbackCmd)
+}
+
+func configRollback(cmd *cobra.Command, args []string) error {
+	number, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("the version must be a number from \"config history\", not %q", args[0])
+	}
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+	changes, err := env.Rollback(number)
+	if err != nil {
+		return err
+	}
+
+	result := []internal.ConfigChange{}
+	for _, change := range changes {
+		change.Key = strings.ToUpper(change.Key)
+		change.Current = displayValue(change.Key, change.Current)
+		change.Other = displayValue(change.Key, change.Other)
+		result = append(result, change)
+	}
+	return printResult(result, func(out io.Writer) {
+		if len(result) == 0 {
+			fmt.Fprintf(out, "[+] Restored version %d, which is the same as the current configuration\n", number)
+			return
+		}
+
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "Setting", "Restored", "Replaced")
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "–––––––", "––––––––", "––––––––")
+		for _, change := range result {
+			fmt.Fprintf(writer, "\n %s\t%s\t%s", change.Key, valueOrDash(change.Current), valueOrDash(change.Other))
+		}
+		fmt.Fprintln(writer, "")
+		writer.Flush()
+
+		fmt.Fprintf(out, "[+] Restored version %d. Bring containers down and up for changes to take effect.\n", number)
+	})
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configRotateCmd represents the config rotate command
+var configRotateCmd = &cobra.Command{
+	Use:   "rotate <secret|all>...",
+	Short: "Replace secrets with new random values",
+	Long: `Replace secrets with new random values and apply them to the running
+services. Use "all" to rotate every supported secret:
+
+	` + strings.Join(internal.RotatableSecrets, "\n\t") + `
+
+The password of the PostgreSQL user is changed in the running database before the
+environment file is saved, so PostgreSQL must be running to rotate it. Running
+services that use the secrets are then recreated, starting with PostgreSQL. If
+any step fails, the previous secrets are restored.
+
+Rotating DJANGO_SECRET_KEY signs everyone out, and rotating DJANGO_JWT_SECRET_KEY
+invalidates existing API tokens.
+
+For example: ghostwriter-cli config rotate postgres_password`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: configRotate,
+}
+
+func init() {
+	configCmd.AddCommand(configRotateCmd)
+}
+
+func configRotate(cmd *cobra.Command, args []string) error {
+	keys := args
+	for _, arg := range args {
+		if strings.EqualFold(arg, "all") {
+			keys = internal.RotatableSecrets
+			break
+		}
+	}
+
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
+	if err := dockerInterface.RotateSecrets(keys); err != nil {
+		return fmt.Errorf("failed to rotate the secrets: %w", err)
+	}
+	fmt.Println("[+] Secrets successfully rotated")
+	return nil
+}
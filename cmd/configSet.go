@@ -1,8 +1,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"strings"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -15,21 +16,44 @@ var configSetCmd = &cobra.Command{
 	Long: `Set the specified configuration value. Use quotations around the value
 if it contains spaces.
 
+The value is checked against the setting's type and allowed values, and unknown
+settings are rejected with suggestions for similar ones. Use --force to set an
+unknown setting or a value that doesn't pass the checks anyway.
+
 For example: ghostwriter-cli config set DATE_FORMAT "d M Y"`,
 	Args: cobra.ExactArgs(2),
-	Run:  configSet,
+	RunE: configSet,
 }
 
+var configSetForce bool
+
 func init() {
 	configCmd.AddCommand(configSetCmd)
+
+	configSetCmd.Flags().BoolVar(&configSetForce, "force", false, "Set the value even if the setting is unknown or the value is invalid")
 }
 
-func configSet(cmd *cobra.Command, args []string) {
-	env, err := internal.ReadEnv(internal.GetDockerDirFromMode(mode))
+func configSet(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
 	if err != nil {
-		log.Fatalf("Could not read environment file: %s\n", err)
+		return err
+	}
+	if err := internal.ValidateSetting(args[0], args[1]); err != nil {
+		if !configSetForce {
+			if errors.Is(err, internal.ErrUnknownSetting) {
+				return fmt.Errorf("%w; use --force to set it anyway", err)
+			}
+			return err
+		}
+		fmt.Printf("[!] Setting the value anyway: %s\n", err)
 	}
 	env.Set(args[0], args[1])
-	env.Save()
+	if err := env.Save(); err != nil {
+		return err
+	}
 	fmt.Println("[+] Configuration successfully updated. Bring containers down and up for changes to take effect.")
+	if setting, found := internal.LookupSetting(args[0]); found && len(setting.Services) > 0 {
+		fmt.Printf("[*] This setting is used by: %s\n", strings.Join(setting.Services, ", "))
+	}
+	return nil
 }
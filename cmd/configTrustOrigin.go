@@ -2,41 +2,54 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
 // configTrustOriginCmd represents the configTrustOrigin command
 var configTrustOriginCmd = &cobra.Command{
-	Use:   "trustorigin <host>",
-	Short: "Add a hostname to the trusted origins list",
-	Long: `Add a hostname to the trusted origins list. Adding a host to this list will mean that
-Ghostwriter will allow requests where the host appears in the "Origin" or "Referer"
+	Use:   "trustorigin <origin> ...",
+	Short: "Add an origin to the trusted origins list",
+	Long: `Add origins to the trusted origins list. Adding an origin to this list will mean that
+Ghostwriter will allow requests where the origin appears in the "Origin" or "Referer"
 headers of requests and does not match the "Host" header.
 
-Use a "*" as a wildcard to trust all subdomains.
+Origins must include the scheme (http:// or https://) and may include a port, but
+not a path. Use a "*" at the start of the hostname to trust all subdomains.
+
+Entries are checked against Django's rules and invalid ones are rejected. Use
+--force to add them anyway.
+
+Use --file to add the entries in a file, one or more on each line, or "-" to read
+them from stdin. Use --list to list the current entries and any problems with
+them.
 
 Good examples:
-	ghostwriter-cli config trustorigin ghostwriter.local
-	ghostwriter-cli config trustorigin *.ghostwriter.local
+	ghostwriter-cli config trustorigin https://ghostwriter.local
+	ghostwriter-cli config trustorigin https://*.ghostwriter.local https://ghostwriter.local:8443
 Bad examples:
+	ghostwriter-cli config trustorigin ghostwriter.local
+	ghostwriter-cli config trustorigin https://ghostwriter.local/
 	ghostwriter-cli config trustorigin *`,
-	Args: cobra.ExactArgs(1),
-	Run:  configTrustOrigin,
+	RunE: configTrustOrigin,
 }
 
+var configTrustOriginFlags hostListFlags
+
 func init() {
 	configCmd.AddCommand(configTrustOriginCmd)
+
+	configTrustOriginCmd.Flags().BoolVar(&configTrustOriginFlags.list, "list", false, "List the trusted origins and any problems with them")
+	configTrustOriginCmd.Flags().StringVar(&configTrustOriginFlags.file, "file", "", "File with the origins to add, or \"-\" for stdin")
+	configTrustOriginCmd.Flags().BoolVar(&configTrustOriginFlags.force, "force", false, "Add origins even if Django won't match them")
 }
 
-func configTrustOrigin(cmd *cobra.Command, args []string) {
-	env, err := internal.ReadEnv(internal.GetDockerDirFromMode(mode))
-	if err != nil {
-		log.Fatalf("Could not read environment file: %s\n", err)
+func configTrustOrigin(cmd *cobra.Command, args []string) error {
+	if configTrustOriginFlags.list {
+		if len(args) > 0 || configTrustOriginFlags.file != "" {
+			return fmt.Errorf("--list can't be used with origins to add")
+		}
+		return printHostList("django_csrf_trusted_origins")
 	}
-	env.AppendHost("django_csrf_trusted_origins", args[0])
-	env.Save()
-	fmt.Println("[+] Configuration successfully updated. Bring containers down and up for changes to take effect.")
+	return addHosts("django_csrf_trusted_origins", args, &configTrustOriginFlags)
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/spf13/cobra"
+)
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the values in the environment file",
+	Long: `Check every value in the environment file against its setting's type and
+allowed values.
+
+Invalid values are errors and make the command exit with a non-zero code.
+Unknown settings, which may be typos, are reported as warnings with suggestions
+for similar settings.`,
+	Args: cobra.NoArgs,
+	RunE: configValidate,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func configValidate(cmd *cobra.Command, args []string) error {
+	env, err := readEnv()
+	if err != nil {
+		return err
+	}
+
+	issues := env.Validate()
+	severity := internal.SeverityOK
+	for _, issue := range issues {
+		severity = severity.Worse(issue.Severity)
+	}
+
+	err = printResult(issues, func(out io.Writer) {
+		if len(issues) == 0 {
+			fmt.Fprintln(out, "[+] The configuration is valid")
+			return
+		}
+
+		// initialize tabwriter
+		writer := new(tabwriter.Writer)
+		// Set minwidth, tabwidth, padding, padchar, and flags
+		writer.Init(out, 8, 8, 1, '\t', 0)
+
+		defer writer.Flush()
+
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "Setting", "Severity", "Problem")
+		fmt.Fprintf(writer, "\n %s\t%s\t%s", "–––––––", "––––––––", "–––––––")
+		for _, issue := range issues {
+			fmt.Fprintf(writer, "\n %s\t%s\t%s", issue.Key, issue.Severity, issue.Message)
+		}
+		fmt.Fprintln(writer, "")
+	})
+	if err != nil {
+		return err
+	}
+	if severity == internal.SeverityCritical {
+		return fmt.Errorf("the configuration has invalid values")
+	}
+	return nil
+}
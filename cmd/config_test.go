@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+	"github.com/adrg/xdg"
+)
+
+// Points the production mode's data directory at a temporary directory for the duration of the test
+// and returns the directory with the environment file
+func useDataDir(t *testing.T) string {
+	t.Helper()
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	xdg.Reload()
+	t.Cleanup(func() {
+		xdg.Reload()
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		restoreStdout()
+		mode = internal.ModeProd
+		output = OutputTable
+		configGetRaw = false
+	})
+	return filepath.Join(dataHome, "ghostwriter")
+}
+
+func TestConfigGetProductionMode(t *testing.T) {
+	useDataDir(t)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+
+	rootCmd.SetArgs([]string{"config", "get", "--raw", "DJANGO_SECURE_SSL_REDIRECT", "DJANGO_SETTINGS_MODULE", "--mode", "prod"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("expected `config get` to succeed, got %v", err)
+	}
+	if expected := "true\nconfig.settings.production\n"; out.String() != expected {
+		t.Fatalf("expected the production profile %q, got %q", expected, out.String())
+	}
+}
+
+func TestConfigGetRawResolvesReferences(t *testing.T) {
+	dir := useDataDir(t)
+	secret := filepath.Join(t.TempDir(), "postgres_password")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("could not write the secret: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("could not create the data directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("POSTGRES_PASSWORD='file:"+secret+"'\n"), 0600); err != nil {
+		t.Fatalf("could not write the environment file: %v", err)
+	}
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+
+	rootCmd.SetArgs([]string{"config", "get", "--raw", "POSTGRES_PASSWORD", "--mode", "prod"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("expected `config get --raw` to succeed, got %v", err)
+	}
+	if out.String() != "from-file\n" {
+		t.Fatalf("expected the value of the reference, got %q", out.String())
+	}
+
+	out.Reset()
+	configGetRaw = false
+	rootCmd.SetArgs([]string{"config", "get", "POSTGRES_PASSWORD", "--mode", "prod"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("expected `config get` to succeed, got %v", err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("file:"+secret)) {
+		t.Fatalf("expected the reference to be shown without --raw, got %q", out.String())
+	}
+}
+
+func TestStructuredOutputRestoresStdout(t *testing.T) {
+	useDataDir(t)
+	stdout := os.Stdout
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+
+	rootCmd.SetArgs([]string{"config", "get", "DJANGO_PORT", "--mode", "prod", "--output", "json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("expected `config get` to succeed, got %v", err)
+	}
+	if os.Stdout != stdout {
+		t.Fatalf("expected stdout to be restored after the command")
+	}
+
+	rootCmd.SetArgs([]string{"config", "get", "--raw", "--mode", "prod", "--output", "json"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatalf("expected `config get --raw` to fail without a setting")
+	}
+	if os.Stdout != stdout {
+		t.Fatalf("expected stdout to be restored after the command failed")
+	}
+}
@@ -2,9 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +17,7 @@ Note: Build will stop a container if it is already running. You will need to run
 the "up" command to start the containers after the build.
 
 Running this command is only necessary when upgrading an existing Ghostwriter installation.`,
-	Run: buildContainers,
+	RunE: buildContainers,
 }
 
 var skipseed bool
@@ -34,42 +32,46 @@ func init() {
 	)
 }
 
-func buildContainers(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func buildContainers(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Starting development environment build")
 	} else {
 		fmt.Println("[+] Starting production environment build")
 	}
-	dockerInterface.Env.Save()
+	if err := dockerInterface.Env.Save(); err != nil {
+		return err
+	}
 
 	downErr := dockerInterface.Down(nil)
 	if downErr != nil {
-		log.Fatalf("Error trying to bring down any running containers with %s: %v\n", dockerInterface.ComposeFile, downErr)
+		return fmt.Errorf("failed to bring down any running containers with %s: %w", dockerInterface.ComposeFile, downErr)
 	}
 	buildErr := dockerInterface.RunComposeCmd("build")
 	if buildErr != nil {
-		log.Fatalf("Error trying to build with %s: %v\n", dockerInterface.ComposeFile, buildErr)
+		return fmt.Errorf("failed to build with %s: %w", dockerInterface.ComposeFile, buildErr)
 	}
 
 	upErr := dockerInterface.Up()
 	if upErr != nil {
-		log.Fatalf("Error trying to bring up environment with %s: %v\n", dockerInterface.ComposeFile, upErr)
+		return fmt.Errorf("failed to bring up environment with %s: %w", dockerInterface.ComposeFile, upErr)
 	}
 	if !skipseed {
 		// Must wait for Django to complete any potential db migrations before re-seeding the database
-		for {
-			if dockerInterface.WaitForDjango() {
-				fmt.Println("[+] Re-seeding database in case initial values were added or adjusted...")
-				seedErr := dockerInterface.RunComposeCmd("run", "--rm", "django", "/seed_data")
-				if seedErr != nil {
-					log.Fatalf("Error trying to seed the database: %v\n", seedErr)
-				}
-				break
-			}
+		if err := dockerInterface.WaitForDjango(); err != nil {
+			return err
+		}
+		fmt.Println("[+] Re-seeding database in case initial values were added or adjusted...")
+		seedErr := dockerInterface.RunComposeCmd("run", "--rm", "django", "/seed_data")
+		if seedErr != nil {
+			return fmt.Errorf("failed to seed the database: %w", seedErr)
 		}
 	} else {
 		fmt.Println("[+] The `--skip-seed` flag was set, so skipped database seeding...")
 	}
 	fmt.Println("[+] All containers have been built!")
+	return nil
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -19,7 +18,7 @@ performs the equivalent of running the "docker compose down" command.
 
 Production containers are targeted by default. Use the "--mode" argument to
 target development containers`,
-	Run: containersDown,
+	RunE: containersDown,
 }
 
 func init() {
@@ -28,17 +27,21 @@ func init() {
 	containersDownCmd.PersistentFlags().BoolVar(&volumes, "volumes", false, "Delete data volumes when containers come down")
 }
 
-func containersDown(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func containersDown(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Bringing down the development environment")
 	} else {
 		fmt.Println("[+] Bringing down the production environment")
 	}
-	err := dockerInterface.Down(&internal.DownOptions{
+	err = dockerInterface.Down(&internal.DownOptions{
 		Volumes: volumes,
 	})
 	if err != nil {
-		log.Fatalf("Error trying to bring down the containers with %s: %v\n", dockerInterface.ComposeFile, err)
+		return fmt.Errorf("failed to bring down the containers with %s: %w", dockerInterface.ComposeFile, err)
 	}
+	return nil
 }
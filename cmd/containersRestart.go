@@ -2,9 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
@@ -17,15 +15,18 @@ the equivalent of running the "docker compose restart" command.
 
 Production containers are targeted by default. Use the "--mode" argument to
 target development containers`,
-	Run: containersRestart,
+	RunE: containersRestart,
 }
 
 func init() {
 	containersCmd.AddCommand(containersRestartCmd)
 }
 
-func containersRestart(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func containersRestart(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Restarting the development environment")
 	} else {
@@ -35,6 +36,7 @@ func containersRestart(cmd *cobra.Command, args []string) {
 	fmt.Printf("[+] Restarting containers with %s...\n", dockerInterface.ComposeFile)
 	startErr := dockerInterface.RunComposeCmd("restart")
 	if startErr != nil {
-		log.Fatalf("Error trying to restart the containers with %s: %v\n", dockerInterface.ComposeFile, startErr)
+		return fmt.Errorf("failed to restart the containers with %s: %w", dockerInterface.ComposeFile, startErr)
 	}
+	return nil
 }
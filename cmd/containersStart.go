@@ -2,9 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
@@ -17,15 +15,18 @@ of running the "docker compose start" command.
 
 Production containers are targeted by default. Use the "--mode" argument to
 target development containers`,
-	Run: containersStart,
+	RunE: containersStart,
 }
 
 func init() {
 	containersCmd.AddCommand(containersStartCmd)
 }
 
-func containersStart(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func containersStart(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Starting the development environment")
 	} else {
@@ -34,6 +35,7 @@ func containersStart(cmd *cobra.Command, args []string) {
 
 	startErr := dockerInterface.RunComposeCmd("start")
 	if startErr != nil {
-		log.Fatalf("Error trying to restart the containers with %s: %v\n", dockerInterface.ComposeFile, startErr)
+		return fmt.Errorf("failed to restart the containers with %s: %w", dockerInterface.ComposeFile, startErr)
 	}
+	return nil
 }
@@ -2,9 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
@@ -17,15 +15,18 @@ performs the equivalent of running the "docker compose stop" command.
 
 Production containers are targeted by default. Use the "--mode" argument to
 target development containers`,
-	Run: containersStop,
+	RunE: containersStop,
 }
 
 func init() {
 	containersCmd.AddCommand(containersStopCmd)
 }
 
-func containersStop(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func containersStop(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Stopping the development environment")
 	} else {
@@ -35,6 +36,7 @@ func containersStop(cmd *cobra.Command, args []string) {
 	fmt.Printf("[+] Stopping services with %s...\n", dockerInterface.ComposeFile)
 	stopErr := dockerInterface.RunComposeCmd("stop")
 	if stopErr != nil {
-		log.Fatalf("Error trying to stop services with %s: %v\n", dockerInterface.ComposeFile, stopErr)
+		return fmt.Errorf("failed to stop services with %s: %w", dockerInterface.ComposeFile, stopErr)
 	}
+	return nil
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -17,25 +16,32 @@ performs the equivalent of running the "docker compose up" command.
 
 Production containers are targeted by default. Use the "--mode" argument to
 target development containers`,
-	Run: containersUp,
+	RunE: containersUp,
 }
 
 func init() {
 	containersCmd.AddCommand(containersUpCmd)
 }
 
-func containersUp(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func containersUp(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Bringing up the development environment")
 	} else {
 		fmt.Println("[+] Bringing up the production environment")
 	}
-	dockerInterface.Env.Save()
-	err := dockerInterface.Up()
+	if err := dockerInterface.Env.Save(); err != nil {
+		return err
+	}
+	err = dockerInterface.Up()
 	if err != nil {
-		log.Fatalf("Error trying to bring up the containers with %s: %v\n", dockerInterface.ComposeFile, err)
+		return fmt.Errorf("failed to bring up the containers with %s: %w", dockerInterface.ComposeFile, err)
 	}
 
+	internal.CertificateExpiryNag(dockerInterface)
 	internal.CheckLatestVersionNag(dockerInterface)
+	return nil
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+)
+
+// Points commands at a `FakeRuntime` and a temporary compose project for the duration of the test
+func useFakeRuntime(t *testing.T) *internal.FakeRuntime {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "local.yml"), []byte{}, 0644); err != nil {
+		t.Fatalf("could not create mock compose file: %v", err)
+	}
+
+	runtime := internal.NewFakeRuntime()
+	original := getDockerInterface
+	getDockerInterface = func(mode internal.DockerMode) (*internal.DockerInterface, error) {
+		return internal.NewDockerInterface(dir, mode, runtime)
+	}
+	t.Cleanup(func() {
+		getDockerInterface = original
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		restoreStdout()
+		output = OutputTable
+	})
+	return runtime
+}
+
+// Runs the CLI with the specified arguments in local development mode
+func runCommand(t *testing.T, args ...string) error {
+	t.Helper()
+	rootCmd.SetArgs(append(args, "--mode", "local-dev"))
+	return rootCmd.Execute()
+}
+
+func TestContainersUpAndDown(t *testing.T) {
+	runtime := useFakeRuntime(t)
+
+	if err := runCommand(t, "containers", "up"); err != nil {
+		t.Fatalf("expected `containers up` to succeed, got %v", err)
+	}
+	if !runtime.Called("compose", "-f", "local.yml", "up", "-d") || !runtime.IsUp {
+		t.Fatalf("expected `compose up -d` to be run, got %v", runtime.Calls)
+	}
+
+	if err := runCommand(t, "containers", "down", "--volumes"); err != nil {
+		t.Fatalf("expected `containers down` to succeed, got %v", err)
+	}
+	if !runtime.Called("compose", "-f", "local.yml", "down", "--volumes") || runtime.IsUp {
+		t.Fatalf("expected `compose down --volumes` to be run, got %v", runtime.Calls)
+	}
+}
+
+func TestContainersRestart(t *testing.T) {
+	runtime := useFakeRuntime(t)
+
+	if err := runCommand(t, "containers", "restart"); err != nil {
+		t.Fatalf("expected `containers restart` to succeed, got %v", err)
+	}
+	if !runtime.Called("compose", "-f", "local.yml", "restart") {
+		t.Fatalf("expected `compose restart` to be run, got %v", runtime.Calls)
+	}
+}
+
+func TestNotInstalledExitCode(t *testing.T) {
+	useFakeRuntime(t)
+	dir := t.TempDir()
+	getDockerInterface = func(mode internal.DockerMode) (*internal.DockerInterface, error) {
+		return internal.NewDockerInterface(dir, mode, internal.NewFakeRuntime())
+	}
+
+	err := runCommand(t, "containers", "up")
+	if !errors.Is(err, internal.ErrNotInstalled) {
+		t.Fatalf("expected `ErrNotInstalled` without a compose file, got %v", err)
+	}
+	if code := exitCodeForError(err); code != exitCodeNotInstalled {
+		t.Fatalf("expected exit code %d, got %d", exitCodeNotInstalled, code)
+	}
+	if code := exitCodeForError(fmt.Errorf("wrapped: %w", internal.ErrDaemonUnavailable)); code != exitCodeDaemonUnavailable {
+		t.Fatalf("expected exit code %d for wrapped errors, got %d", exitCodeDaemonUnavailable, code)
+	}
+}
+
+func TestRunningJSONOutput(t *testing.T) {
+	runtime := useFakeRuntime(t)
+	runtime.IsUp = true
+	runtime.Services = internal.Containers{
+		{ID: "1", Image: "ghostwriter_local_django", Name: "ghostwriter_django"},
+		{ID: "2", Image: "someone/else", Name: "unrelated"},
+	}
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+
+	if err := runCommand(t, "running", "--output", "json"); err != nil {
+		t.Fatalf("expected `running` to succeed, got %v", err)
+	}
+	var containers internal.Containers
+	if err := json.Unmarshal(out.Bytes(), &containers); err != nil {
+		t.Fatalf("expected only JSON on stdout, got %q: %v", out.String(), err)
+	}
+	if len(containers) != 1 || containers[0].Name != "ghostwriter_django" {
+		t.Fatalf("expected only the Ghostwriter container, got %v", containers)
+	}
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -9,13 +8,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
-	"github.com/moby/moby/client"
 	"github.com/spf13/cobra"
 )
 
@@ -26,86 +26,360 @@ var healthcheckCmd = &cobra.Command{
 	Long: `Check the health of Ghostwriter's services.
 
 This command validates all containers are running and passing
-their respective health checks`,
-	Run: runHealthcheck,
+their respective health checks.
+
+The exit code follows the Nagios plugin conventions, so the command
+can be used with cron or a monitoring system:
+
+  0  OK        no issues were found
+  1  WARNING   only minor issues were found (e.g., a slow service)
+  2  CRITICAL  a required container or service is down
+  3  UNKNOWN   the checks could not be run
+
+The disk space on Docker's data directory and Ghostwriter CLI's data directory,
+the host's available memory, the size of the database and media volumes, and
+each container's CPU and memory usage are also checked. Disks fuller than
+healthcheck_disk_usage_max percent, less than healthcheck_mem_min MB of free
+memory, or containers using more than healthcheck_container_mem_max percent of
+their memory limit are reported as warnings. The TLS certificate is a warning when it
+expires within GWCLI_CERT_EXPIRY_WARNING_DAYS days and critical once expired.
+Resource checks that can't be run are shown as UNKNOWN but don't change the
+exit code, so it reflects the health of the services, unless --strict is used.
+
+Use the --plugin flag to print a single summary line with performance data
+instead of tables.
+
+Use the --watch flag to keep checking every --interval and only report
+changes, such as a container going down or a service recovering. A problem
+that persists is only reported again when its severity changes. Changes
+are printed and can also be sent to a JSON lines file (--notify-file), a
+webhook (--notify-webhook), or a local script (--notify-exec). Scripts
+receive the change as JSON on stdin and in GHOSTWRITER_* environment
+variables. Like exec: references, --notify-exec is split into the script
+and its arguments on spaces and run without a shell, e.g.:
+
+  --notify-exec "/usr/local/bin/alert --team ops"`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		// The summary line is the only output on stdout
+		if healthcheckPlugin {
+			redirectProgress(cmd)
+		}
+	},
+	RunE: runHealthcheck,
 }
 
+var (
+	healthcheckPlugin   bool
+	healthcheckWatch    bool
+	healthcheckStrict   bool
+	healthcheckInterval time.Duration
+	notifyFile          string
+	notifyWebhook       string
+	notifyExec          string
+)
+
+// Timeout for requests to the /status/ endpoint
+const statusTimeout = 2 * time.Second
+
+// Response time of the /status/ endpoint that is reported as a warning
+const statusWarningTime = time.Second
+
+// Containers that Ghostwriter keeps working without (with reduced functionality), so they are only a warning when stopped
+var optionalContainers = []string{"queue", "collab_server", "frontend"}
+
 func init() {
 	rootCmd.AddCommand(healthcheckCmd)
+
+	healthcheckCmd.Flags().BoolVar(&healthcheckPlugin, "plugin", false, "Print a one-line Nagios plugin summary with performance data")
+	healthcheckCmd.Flags().BoolVar(&healthcheckWatch, "watch", false, "Keep running the checks and report changes until interrupted")
+	healthcheckCmd.Flags().BoolVar(&healthcheckStrict, "strict", false, "Include resource checks that couldn't be run as UNKNOWN in the exit code")
+	healthcheckCmd.Flags().DurationVar(&healthcheckInterval, "interval", 30*time.Second, "Time between checks with --watch")
+	healthcheckCmd.Flags().StringVar(&notifyFile, "notify-file", "", "Append changes to this file as JSON lines with --watch")
+	healthcheckCmd.Flags().StringVar(&notifyWebhook, "notify-webhook", "", "POST changes as JSON to this URL with --watch")
+	healthcheckCmd.Flags().StringVar(&notifyExec, "notify-exec", "", "Run this script for each change with --watch; arguments are separated by spaces, without a shell")
 }
 
-func runHealthcheck(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
-	// initialize tabwriter
-	writer := new(tabwriter.Writer)
-	// Set minwidth, tabwidth, padding, padchar, and flags
-	writer.Init(os.Stdout, 8, 8, 1, '\t', 0)
+func runHealthcheck(cmd *cobra.Command, args []string) error {
+	if healthcheckWatch && healthcheckPlugin {
+		return errors.New("the --watch and --plugin flags cannot be used together")
+	}
+	if healthcheckWatch && healthcheckInterval <= 0 {
+		return errors.New("the --interval must be greater than zero")
+	}
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		if healthcheckPlugin {
+			fmt.Fprintf(resultWriter, "GHOSTWRITER %s - %s\n", internal.SeverityUnknown, err)
+			cmd.SilenceErrors = true
+		}
+		return &ExitCodeError{Code: internal.SeverityUnknown.ExitCode(), Err: err}
+	}
 
-	defer writer.Flush()
+	if healthcheckWatch {
+		return watchHealth(dockerInterface)
+	}
+
+	report := collectHealthReport(dockerInterface, os.Stdout)
 
-	fmt.Println("[+] Checking Ghostwriter containers and their respective health checks...")
+	if healthcheckPlugin {
+		fmt.Fprintln(resultWriter, pluginSummary(report))
+	} else {
+		err = printResult(report, func(out io.Writer) {
+			// initialize tabwriter
+			writer := new(tabwriter.Writer)
+			// Set minwidth, tabwidth, padding, padchar, and flags
+			writer.Init(out, 8, 8, 1, '\t', 0)
+
+			defer writer.Flush()
+
+			printHealthIssues(writer, "Container", report.Containers)
+			printHealthIssues(writer, "Service", report.Services)
+			printProbes(writer, report.Probes)
+			printHealthIssues(writer, "Resource", report.Resources)
+			if report.Certificate != nil {
+				printHealthIssues(writer, "Service", internal.HealthIssues{*report.Certificate})
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
 
+	if report.Status != internal.SeverityOK {
+		// The report already explains the problem
+		cmd.SilenceErrors = true
+		return &ExitCodeError{Code: report.Status.ExitCode()}
+	}
+	return nil
+}
+
+// Runs the container checks and, unless a required container is down, the service checks
+// Progress messages are written to `progress`
+func collectHealthReport(dockerInterface *internal.DockerInterface, progress io.Writer) HealthReport {
+	fmt.Fprintln(progress, "[+] Checking Ghostwriter containers and their respective health checks...")
+
+	report := HealthReport{Containers: internal.HealthIssues{}, Services: internal.HealthIssues{}, Errors: []string{}, Probes: internal.HealthIssues{}, Resources: internal.HealthIssues{}}
 	containerIssues, dockerErr := checkDockerHealth(dockerInterface)
 
 	if dockerErr != nil {
-		fmt.Printf("[!] Failed to get container information from Docker: %s\n", dockerErr)
+		fmt.Fprintf(progress, "[!] Failed to get container information from Docker: %s\n", dockerErr)
+		report.Errors = append(report.Errors, fmt.Sprintf("Failed to get container information from Docker: %s", dockerErr))
+		report.Status = internal.SeverityUnknown
 	} else {
 		if len(containerIssues) > 0 {
-			fmt.Printf("[*] Identified %d issues with one or more containers:\n\n", len(containerIssues))
-
-			fmt.Fprintf(writer, "\n %s\t%s\t%s", "Type", "Container", "Message")
-			fmt.Fprintf(writer, "\n %s\t%s\t%s", "––––––––––––", "––––––––––––", "––––––––––––")
-
-			for _, issue := range containerIssues {
-				fmt.Fprintf(writer, "\n %s\t%s\t%s", issue.Type, issue.Service, issue.Message)
-			}
+			fmt.Fprintf(progress, "[*] Identified %d issues with one or more containers:\n\n", len(containerIssues))
+			report.Containers = containerIssues
 		} else {
-			fmt.Println("[*] Identified zero container issues, now testing services...")
-			serviceIssues, svcErr := checkGhostwriterHealth(dockerInterface)
+			fmt.Fprintln(progress, "[*] Identified zero container issues, now testing services...")
+		}
+		if containerIssues.Severity() != internal.SeverityCritical {
+			serviceIssues, responseTime, svcErr := checkGhostwriterHealth(dockerInterface)
 			if svcErr != nil {
-				fmt.Printf("[!] Failed to get health status from Ghostwriter's /status/ endpoint: %s\n", svcErr)
+				fmt.Fprintf(progress, "[!] Failed to get health status from Ghostwriter's /status/ endpoint: %s\n", svcErr)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to get health status from Ghostwriter's /status/ endpoint: %s", svcErr))
+				// The required containers are up, so an unreachable endpoint means Django or Nginx is broken
+				report.Status = internal.SeverityCritical
 			} else {
-				if len(serviceIssues) > 0 {
-					fmt.Printf("[*] Identified %d issues with one or more services:\n\n", len(serviceIssues))
-
-					fmt.Fprintf(writer, "\n %s\t%s\t%s", "Type", "Service", "Message")
-					fmt.Fprintf(writer, "\n %s\t%s\t%s", "––––––––––––", "––––––––––––", "––––––––––––")
+				report.ResponseTime = responseTime.Seconds()
+				report.Services = append(report.Services, serviceIssues...)
+			}
 
-					for _, issue := range serviceIssues {
-						fmt.Fprintf(writer, "\n %s\t%s\t%s", issue.Type, issue.Service, issue.Message)
-					}
-				} else {
-					fmt.Println("[*] Identified zero issues with core services")
+			fmt.Fprintln(progress, "[+] Probing each service directly...")
+			report.Probes = dockerInterface.ProbeServices()
+			for _, probe := range report.Probes {
+				if probe.Severity != internal.SeverityOK {
+					report.Services = append(report.Services, probe)
 				}
 			}
+			if len(report.Services) > 0 {
+				fmt.Fprintf(progress, "[*] Identified %d issues with one or more services:\n\n", len(report.Services))
+			} else {
+				fmt.Fprintln(progress, "[*] Identified zero issues with core services")
+			}
+		}
+
+		fmt.Fprintln(progress, "[+] Checking disk space, memory, and container resource usage...")
+		report.Resources = dockerInterface.CheckResources()
+		if problems := len(report.Resources) - report.Resources.Count(internal.SeverityOK); problems > 0 {
+			fmt.Fprintf(progress, "[*] Identified %d issues with host or container resources\n", problems)
+		}
+	}
+
+	// The certificate is read from disk, so it can be checked even if Docker isn't working
+	report.Certificate = dockerInterface.CheckCertificate()
+	if report.Certificate != nil {
+		if report.Certificate.Severity != internal.SeverityOK {
+			fmt.Fprintf(progress, "[!] %s\n", report.Certificate.Message)
 		}
+		report.Status = report.Status.Worse(report.Certificate.Severity)
 	}
+	report.Status = report.Status.Worse(report.Containers.Severity()).Worse(report.Services.Severity()).Worse(resourceSeverity(report.Resources))
+	report.Healthy = report.Status == internal.SeverityOK
+	return report
 }
 
-type HealthIssue struct {
-	Type    string
-	Service string
-	Message string
+// Gets the severity of the resource checks for the overall status
+// Checks that couldn't be run say nothing about the services, so they're left out unless --strict is used.
+func resourceSeverity(resources internal.HealthIssues) internal.Severity {
+	if healthcheckStrict {
+		return resources.Severity()
+	}
+	return resources.KnownSeverity()
 }
 
-type HealthIssues []HealthIssue
+// Repeatedly runs the checks and sends notifications for any changes until interrupted
+func watchHealth(dockerInterface *internal.DockerInterface) error {
+	notifiers := []internal.Notifier{&internal.StdoutNotifier{Writer: resultWriter, JSON: structuredOutput()}}
+	if notifyFile != "" {
+		notifiers = append(notifiers, &internal.FileNotifier{Path: notifyFile})
+	}
+	if notifyWebhook != "" {
+		notifiers = append(notifiers, internal.NewWebhookNotifier(notifyWebhook))
+	}
+	if notifyExec != "" {
+		notifier, err := internal.NewExecNotifier(notifyExec)
+		if err != nil {
+			return err
+		}
+		notifiers = append(notifiers, notifier)
+	}
 
-func (c HealthIssues) Len() int {
-	return len(c)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(healthcheckInterval)
+	defer ticker.Stop()
+
+	fmt.Printf("[+] Checking Ghostwriter's health every %s, press Ctrl+C to stop...\n", healthcheckInterval)
+	tracker := internal.NewHealthTracker()
+	for {
+		report := collectHealthReport(dockerInterface, io.Discard)
+		for _, event := range tracker.Update(report.Issues(), time.Now()) {
+			notify(notifiers, event)
+		}
+
+		select {
+		case <-signals:
+			fmt.Println("[+] Stopped watching Ghostwriter's health")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sends the event to every notifier, reporting failures without stopping
+func notify(notifiers []internal.Notifier, event internal.HealthEvent) {
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(event); err != nil {
+			fmt.Fprintf(os.Stderr, "[!] Could not send notification: %v\n", err)
+		}
+	}
+}
+
+// Prints a table of issues, if there are any
+func printHealthIssues(writer io.Writer, column string, issues internal.HealthIssues) {
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", "Type", "Severity", column, "Message")
+	fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", "––––––––––––", "––––––––––––", "––––––––––––", "––––––––––––")
+
+	for _, issue := range issues {
+		fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", issue.Type, issue.Severity, issue.Service, issue.Message)
+	}
+	fmt.Fprintln(writer, "")
+}
+
+// Prints a table with the result and latency of every service probe
+func printProbes(writer io.Writer, probes internal.HealthIssues) {
+	if len(probes) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", "Probe", "Severity", "Latency", "Message")
+	fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", "––––––––––––", "––––––––––––", "––––––––––––", "––––––––––––")
+
+	for _, probe := range probes {
+		latency := time.Duration(probe.Latency * float64(time.Second)).Round(time.Millisecond)
+		fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s", probe.Service, probe.Severity, latency, probe.Message)
+	}
+	fmt.Fprintln(writer, "")
+}
+
+// Formats the report as a Nagios plugin status line with performance data
+func pluginSummary(report HealthReport) string {
+	issues := report.problems()
+
+	details := append([]string{}, report.Errors...)
+	for _, issue := range issues {
+		details = append(details, fmt.Sprintf("%s %s: %s", issue.Severity, issue.Service, issue.Message))
+	}
+	summary := "All containers and services are healthy"
+	if len(details) > 0 {
+		summary = strings.Join(details, "; ")
+	}
+	// Pipes separate the performance data, so they can't appear in the summary
+	summary = strings.ReplaceAll(summary, "|", "/")
+
+	perfdata := []string{
+		fmt.Sprintf("issues=%d;;;0", len(issues)),
+		fmt.Sprintf("critical=%d;;;0", issues.Count(internal.SeverityCritical)),
+		fmt.Sprintf("warning=%d;;;0", issues.Count(internal.SeverityWarning)),
+	}
+	if report.ResponseTime > 0 {
+		perfdata = append(perfdata, fmt.Sprintf("response_time=%.3fs;%.3f;%.3f;0", report.ResponseTime, statusWarningTime.Seconds(), statusTimeout.Seconds()))
+	}
+	for _, probe := range report.Probes {
+		perfdata = append(perfdata, fmt.Sprintf("%s_time=%.3fs;;;0", probe.Service, probe.Latency))
+	}
+
+	return fmt.Sprintf("GHOSTWRITER %s - %s | %s", report.Status, summary, strings.Join(perfdata, " "))
 }
 
-func (c HealthIssues) Less(i, j int) bool {
-	return c[i].Service < c[j].Service
+// Gets the container and service issues and the checks that didn't pass
+func (this HealthReport) problems() internal.HealthIssues {
+	issues := append(append(internal.HealthIssues{}, this.Containers...), this.Services...)
+	for _, resource := range this.Resources {
+		if resource.Severity != internal.SeverityOK {
+			issues = append(issues, resource)
+		}
+	}
+	if this.Certificate != nil && this.Certificate.Severity != internal.SeverityOK {
+		issues = append(issues, *this.Certificate)
+	}
+	return issues
 }
 
-func (c HealthIssues) Swap(i, j int) {
-	c[i], c[j] = c[j], c[i]
+// Issues returns every issue in the report, with errors from running the checks reported as `Check` issues.
+func (this HealthReport) Issues() internal.HealthIssues {
+	issues := this.problems()
+	for _, message := range this.Errors {
+		issues = append(issues, internal.HealthIssue{Type: "Check", Service: "healthcheck", Message: message, Severity: this.Status})
+	}
+	return issues
+}
+
+// HealthReport is the result of the `healthcheck` command.
+type HealthReport struct {
+	Healthy    bool                  `json:"healthy"`
+	Status     internal.Severity     `json:"status"`
+	Containers internal.HealthIssues `json:"containers"`
+	Services   internal.HealthIssues `json:"services"`
+	Errors     []string              `json:"errors"`
+	// Results of every service probe, including the ones that passed
+	Probes internal.HealthIssues `json:"probes"`
+	// Results of the disk space, memory, and container resource checks, including the ones that passed
+	Resources internal.HealthIssues `json:"resources"`
+	// Expiry of the TLS certificate, if there is one
+	Certificate *internal.HealthIssue `json:"certificate,omitempty"`
+	// Response time of the /status/ endpoint in seconds
+	ResponseTime float64 `json:"response_time,omitempty"`
 }
 
-func checkDockerHealth(dockerInterface *internal.DockerInterface) (HealthIssues, error) {
+func checkDockerHealth(dockerInterface *internal.DockerInterface) (internal.HealthIssues, error) {
 	var found []string
 	var imageName string
-	var issues HealthIssues
+	var issues internal.HealthIssues
 
 	var requiredImages []string
 	if dockerInterface.UseDevInfra {
@@ -117,20 +391,13 @@ func checkDockerHealth(dockerInterface *internal.DockerInterface) (HealthIssues,
 	}
 
 	// Check running containers to make sure every necessary container is up
-	cli, err := dockerInterface.GetDaemonClient()
-	if err != nil {
-		return issues, err
-	}
-
-	containers, err := cli.ContainerList(context.Background(), client.ContainerListOptions{
-		All: false,
-	})
+	containers, err := dockerInterface.Runtime.ContainerList()
 	if err != nil {
 		return issues, err
 	}
 
-	if len(containers.Items) > 0 {
-		for _, container := range containers.Items {
+	if len(containers) > 0 {
+		for _, container := range containers {
 			// Use substring matching to handle both local builds and registry images
 			for _, imgName := range append(append(internal.DevImages, internal.ProdImages...), internal.SysProdImages...) {
 				if strings.Contains(container.Image, imgName) {
@@ -142,19 +409,34 @@ func checkDockerHealth(dockerInterface *internal.DockerInterface) (HealthIssues,
 		for _, image := range requiredImages {
 			if !internal.Contains(found, image) {
 				imageName = strings.ToUpper(image[strings.LastIndex(image, "_")+1:])
-				issues = append(issues, HealthIssue{"Container", imageName, "Container is not running"})
+				severity := internal.SeverityCritical
+				if isOptionalContainer(image) {
+					severity = internal.SeverityWarning
+				}
+				issues = append(issues, internal.HealthIssue{Type: "Container", Service: imageName, Message: "Container is not running", Severity: severity})
 			}
 		}
 	} else {
-		issues = append(issues, HealthIssue{"Container", "ALL", "No Ghostwriter containers are running"})
+		issues = append(issues, internal.HealthIssue{Type: "Container", Service: "ALL", Message: "No Ghostwriter containers are running", Severity: internal.SeverityCritical})
 	}
 
 	return issues, nil
 }
 
+// Determines if Ghostwriter can run without the container for the specified image
+func isOptionalContainer(image string) bool {
+	for _, name := range optionalContainers {
+		if strings.HasSuffix(image, "_"+name) {
+			return true
+		}
+	}
+	return false
+}
+
 // CheckGhostwriterHealth fetches the latest health reports from Ghostwriter's status API endpoint.
-func checkGhostwriterHealth(dockerInterface *internal.DockerInterface) (HealthIssues, error) {
-	var issues HealthIssues
+// Returns the response time of the endpoint along with any issues.
+func checkGhostwriterHealth(dockerInterface *internal.DockerInterface) (internal.HealthIssues, time.Duration, error) {
+	var issues internal.HealthIssues
 
 	protocol := "https"
 	port := "443"
@@ -165,23 +447,25 @@ func checkGhostwriterHealth(dockerInterface *internal.DockerInterface) (HealthIs
 
 	baseUrl := protocol + "://localhost:" + port + "/status/"
 	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-	client := http.Client{Timeout: time.Second * 2, Transport: transport}
+	client := http.Client{Timeout: statusTimeout, Transport: transport}
 
 	req, err := http.NewRequest(http.MethodGet, baseUrl, nil)
 	if err != nil {
-		return issues, err
+		return issues, 0, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 
+	start := time.Now()
 	res, getErr := client.Do(req)
+	responseTime := time.Since(start)
 	if getErr != nil {
-		return issues, getErr
+		return issues, 0, getErr
 	}
 
 	// Check if response is nil (can happen in edge cases)
 	if res == nil {
-		return issues, errors.New("received nil response from HTTP request")
+		return issues, 0, errors.New("received nil response from HTTP request")
 	}
 
 	if res.Body != nil {
@@ -189,18 +473,18 @@ func checkGhostwriterHealth(dockerInterface *internal.DockerInterface) (HealthIs
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return issues, errors.New("Non-OK HTTP status suggests an issue with the Django or Nginx services (Code " + strconv.Itoa(res.StatusCode) + ")")
+		return issues, 0, errors.New("Non-OK HTTP status suggests an issue with the Django or Nginx services (Code " + strconv.Itoa(res.StatusCode) + ")")
 	}
 
 	body, readErr := io.ReadAll(res.Body)
 	if readErr != nil {
-		return issues, readErr
+		return issues, 0, readErr
 	}
 
 	var results map[string]interface{}
 	jsonErr := json.Unmarshal(body, &results)
 	if jsonErr != nil {
-		return issues, jsonErr
+		return issues, 0, jsonErr
 	}
 
 	for key := range results {
@@ -211,9 +495,18 @@ func checkGhostwriterHealth(dockerInterface *internal.DockerInterface) (HealthIs
 			} else {
 				statusMsg = fmt.Sprint(results[key])
 			}
-			issues = append(issues, HealthIssue{"Service", key, statusMsg})
+			issues = append(issues, internal.HealthIssue{Type: "Service", Service: key, Message: statusMsg, Severity: internal.SeverityCritical})
 		}
 	}
 
-	return issues, nil
+	if responseTime > statusWarningTime {
+		issues = append(issues, internal.HealthIssue{
+			Type:     "Service",
+			Service:  "status",
+			Message:  fmt.Sprintf("Slow response from the /status/ endpoint (%s)", responseTime.Round(time.Millisecond)),
+			Severity: internal.SeverityWarning,
+		})
+	}
+
+	return issues, responseTime, nil
 }
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
+)
+
+func TestHealthcheckPluginCritical(t *testing.T) {
+	useFakeRuntime(t)
+	t.Cleanup(func() { healthcheckPlugin = false })
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+
+	err := runCommand(t, "healthcheck", "--plugin")
+	if code := exitCodeForError(err); code != 2 {
+		t.Fatalf("expected CRITICAL exit code 2 with no containers running, got %d (%v)", code, err)
+	}
+	if !strings.HasPrefix(out.String(), "GHOSTWRITER CRITICAL - ") || strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("expected a single plugin status line, got %q", out.String())
+	}
+}
+
+func TestPluginSummary(t *testing.T) {
+	report := HealthReport{
+		Status: internal.SeverityWarning,
+		Containers: internal.HealthIssues{
+			{Type: "Container", Service: "QUEUE", Message: "Container is not running", Severity: internal.SeverityWarning},
+		},
+		ResponseTime: 0.25,
+	}
+	expected := "GHOSTWRITER WARNING - WARNING QUEUE: Container is not running | issues=1;;;0 critical=0;;;0 warning=1;;;0 response_time=0.250s;1.000;2.000;0"
+	if summary := pluginSummary(report); summary != expected {
+		t.Fatalf("expected %q, got %q", expected, summary)
+	}
+
+	report = HealthReport{Status: internal.SeverityOK}
+	if summary := pluginSummary(report); !strings.HasPrefix(summary, "GHOSTWRITER OK - ") {
+		t.Fatalf("expected an OK summary, got %q", summary)
+	}
+}
+
+func TestResourceSeverityIgnoresUnknown(t *testing.T) {
+	t.Cleanup(func() { healthcheckStrict = false })
+	resources := internal.HealthIssues{
+		{Type: "Resource", Service: "data_disk", Message: "10% used with 90 GB free", Severity: internal.SeverityOK},
+		{Type: "Resource", Service: "ghostwriter_django", Message: "Could not get resource usage: timeout", Severity: internal.SeverityUnknown},
+	}
+	if severity := resourceSeverity(resources); severity != internal.SeverityOK {
+		t.Fatalf("expected checks that couldn't run to be left out of the status, got %s", severity)
+	}
+
+	healthcheckStrict = true
+	if severity := resourceSeverity(resources); severity != internal.SeverityUnknown {
+		t.Fatalf("expected --strict to include checks that couldn't run, got %s", severity)
+	}
+}
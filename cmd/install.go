@@ -32,7 +32,7 @@ The command performs the following steps:
 Running after initial installation will keep the existing configuration but fetch a new version
 (for --mode=prod) or rebuild the containers (for --mode=local-*)
 `,
-	Run: installGhostwriter,
+	RunE: installGhostwriter,
 }
 
 var installVersion string
@@ -48,7 +48,10 @@ func init() {
 }
 
 func fetchAndWriteComposeFile(mode internal.DockerMode, version string) error {
-	dir := internal.GetDockerDirFromMode(mode)
+	dir, err := internal.GetDockerDirFromMode(mode)
+	if err != nil {
+		return err
+	}
 	file := "docker-compose.yml"
 
 	fmt.Println("[+] Downloading docker-compose.yml")
@@ -130,7 +133,10 @@ func updateContainers(dockerInterface internal.DockerInterface) error {
 	}
 
 	fmt.Println("[+] Waiting for Django to be ready...")
-	dockerInterface.WaitForDjango()
+	err = dockerInterface.WaitForDjango()
+	if err != nil {
+		return err
+	}
 
 	fmt.Println("[+] Migrating database...")
 	err = dockerInterface.RunDjangoManageCommand("migrate")
@@ -147,31 +153,40 @@ func updateContainers(dockerInterface internal.DockerInterface) error {
 	return nil
 }
 
-func installGhostwriter(cmd *cobra.Command, args []string) {
+func installGhostwriter(cmd *cobra.Command, args []string) error {
 	var err error
 
 	if mode == internal.ModeProd {
 		// Fetch and write docker-compose.yml file
 		err = fetchAndWriteComposeFile(mode, installVersion)
 		if err != nil {
-			log.Fatalf("%v", err)
+			return err
 		}
 	}
 
 	// Get interface
-	dockerInterface := internal.GetDockerInterface(mode)
-	dockerInterface.Env.Save()
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
+	if err := dockerInterface.Env.Save(); err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Starting development environment installation")
 	} else {
 		fmt.Println("[+] Starting production environment installation")
-		internal.GenerateCertificatePackage(dockerInterface.Dir)
-		internal.PrepareSettingsDirectory(dockerInterface.Dir)
+		if err := internal.GenerateCertificatePackage(dockerInterface.Dir, internal.DefaultCertificateOptions(dockerInterface.Env)); err != nil {
+			return err
+		}
+		if err := internal.PrepareSettingsDirectory(dockerInterface.Dir); err != nil {
+			return err
+		}
 	}
 
 	err = updateContainers(*dockerInterface)
 	if err != nil {
-		log.Fatalf("%v\n", err)
+		return err
 	}
 
 	fmt.Println("[+] Proceeding with Django superuser creation...")
@@ -185,4 +200,5 @@ func installGhostwriter(cmd *cobra.Command, args []string) {
 	fmt.Println("[+] Ghostwriter is ready to go!")
 	fmt.Printf("[+] You can log in as `%s` with this password: %s\n", dockerInterface.Env.Get("django_superuser_username"), dockerInterface.Env.Get("django_superuser_password"))
 	fmt.Println("[+] You can get your admin password by running: ghostwriter-cli config get admin_password")
+	return nil
 }
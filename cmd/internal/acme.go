@@ -0,0 +1,424 @@
+package internal
+
+// Certificates from an ACME certificate authority, such as Let's Encrypt
+// The settings are saved next to the certificate so `cert renew` can run unattended from cron.
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// Supported ACME challenge types
+const (
+	ChallengeHTTP01 = "http-01"
+	ChallengeDNS01  = "dns-01"
+)
+
+const (
+	// File names of the saved ACME settings and account key in the `ssl` directory
+	acmeConfigName = "acme.json"
+	acmeKeyName    = "acme-account.key"
+	// Renew ACME certificates this many days before they expire, as recommended by Let's Encrypt
+	acmeRenewalDays = 30
+	// Time allowed for the whole ACME exchange, including the DNS hook
+	acmeTimeout = 10 * time.Minute
+)
+
+// Returned when renewing an ACME certificate that was never requested
+var ErrNoACMEConfig = errors.New("no ACME settings found, run `gencert --acme` first")
+
+// ACMEOptions controls how certificates are requested from an ACME certificate authority.
+type ACMEOptions struct {
+	// URL of the CA's ACME directory, Let's Encrypt if empty
+	DirectoryURL string `json:"directory_url"`
+	// Contact address for the account, used by the CA for expiry notices
+	Email string `json:"email,omitempty"`
+	// Either `ChallengeHTTP01` or `ChallengeDNS01`
+	Challenge string `json:"challenge"`
+	// Address of the temporary listener for HTTP-01 challenges, ":80" if empty
+	HTTPAddress string `json:"http_address,omitempty"`
+	// Script run with `present` and `cleanup` to publish the TXT records for DNS-01 challenges
+	DNSHook string `json:"dns_hook,omitempty"`
+	// PEM file of additional roots to trust for the directory's TLS certificate, e.g., a test CA
+	CACert string `json:"ca_cert,omitempty"`
+	// DNS names to request, saved for renewals
+	Hosts []string `json:"hosts"`
+	// Algorithm of the certificate's private key, saved for renewals
+	KeyAlgorithm KeyAlgorithm `json:"key_algorithm,omitempty"`
+	// Called before the HTTP-01 listener starts to free its port, e.g., by stopping Nginx. The
+	// returned function is called once the new certificate is written, so Nginx starts with it.
+	FreeHTTPPort func() (func() error, error) `json:"-"`
+}
+
+// Gets the paths of the saved ACME settings and the account key in the `ssl` directory
+func acmePaths(sslPath string) (configPath, keyPath string) {
+	return filepath.Join(sslPath, acmeConfigName), filepath.Join(sslPath, acmeKeyName)
+}
+
+// ACMEConfigured checks if a certificate was requested with ACME in the `ssl` directory.
+func ACMEConfigured(sslPath string) bool {
+	configPath, _ := acmePaths(sslPath)
+	return FileExists(configPath)
+}
+
+// Removes the saved ACME settings once a certificate was issued or imported some other way, so
+// `cert renew` doesn't replace it. The account key is kept for later requests.
+func removeACMEConfig(sslPath string) error {
+	configPath, _ := acmePaths(sslPath)
+	if err := os.Remove(configPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove the ACME settings: %w", err)
+	}
+	fmt.Printf("[*] Removed the ACME settings in %s, so `cert renew` will keep this certificate's issuer\n", configPath)
+	return nil
+}
+
+// Gets the hosts that an ACME CA can validate: DNS names with a dot, skipping IP addresses, local names,
+// and Django's wildcards. Wildcard names are only kept for DNS-01 challenges.
+func acmeHosts(hosts []string, challenge string) []string {
+	dnsNames, _ := subjectAltNames(hosts)
+	names := []string{}
+	for _, name := range dnsNames {
+		if !strings.Contains(name, ".") || strings.HasSuffix(name, ".local") || strings.HasSuffix(name, ".internal") {
+			continue
+		}
+		if strings.HasPrefix(name, "*.") && challenge != ChallengeDNS01 {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Checks the options and fills in the defaults
+func (this *ACMEOptions) validate() error {
+	if this.DirectoryURL == "" {
+		this.DirectoryURL = acme.LetsEncryptURL
+	}
+	if this.Challenge == "" {
+		this.Challenge = ChallengeHTTP01
+	}
+	if this.HTTPAddress == "" {
+		this.HTTPAddress = ":80"
+	}
+	switch this.Challenge {
+	case ChallengeHTTP01:
+	case ChallengeDNS01:
+		if this.DNSHook == "" {
+			return errors.New("a DNS hook script is required for dns-01 challenges")
+		}
+	default:
+		return fmt.Errorf("unsupported challenge type %q, must be one of: %s, %s", this.Challenge, ChallengeHTTP01, ChallengeDNS01)
+	}
+	if this.KeyAlgorithm == KeyEd25519 {
+		return errors.New("ACME certificate authorities don't issue certificates for Ed25519 keys")
+	}
+	this.Hosts = acmeHosts(this.Hosts, this.Challenge)
+	if len(this.Hosts) == 0 {
+		return errors.New("no public DNS names to request a certificate for, use --san to list them")
+	}
+	return nil
+}
+
+// Creates an HTTP client that also trusts the roots in `CACert`
+func (this *ACMEOptions) httpClient() (*http.Client, error) {
+	if this.CACert == "" {
+		return http.DefaultClient, nil
+	}
+	data, err := os.ReadFile(this.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", this.CACert, err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", this.CACert)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	return &http.Client{Transport: transport}, nil
+}
+
+// Reads the ACME account key, creating one if it doesn't exist
+func loadOrCreateACMEKey(keyPath string) (crypto.Signer, error) {
+	if FileExists(keyPath) {
+		return readPrivateKey(keyPath)
+	}
+	key, err := KeyECDSAP256.generateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the account key: %w", err)
+	}
+	block, err := marshalPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s for writing: %w", keyPath, err)
+	}
+	defer file.Close()
+	if err := pem.Encode(file, block); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", keyPath, err)
+	}
+	return key, nil
+}
+
+// ObtainACMECertificate requests a certificate from an ACME CA and writes it and its key to the
+// `ssl` directory in `path`, archiving the current pair. The options are saved for `RenewACMECertificate`.
+func ObtainACMECertificate(path string, opts *ACMEOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	sslPath := filepath.Join(path, "ssl")
+	if err := os.MkdirAll(sslPath, 0700); err != nil {
+		return fmt.Errorf("failed to make the `ssl` directory: %w", err)
+	}
+	configPath, accountKeyPath := acmePaths(sslPath)
+
+	accountKey, err := loadOrCreateACMEKey(accountKeyPath)
+	if err != nil {
+		return err
+	}
+	httpClient, err := opts.httpClient()
+	if err != nil {
+		return err
+	}
+	client := &acme.Client{Key: accountKey, DirectoryURL: opts.DirectoryURL, HTTPClient: httpClient, UserAgent: "Ghostwriter-CLI"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), acmeTimeout)
+	defer cancel()
+
+	fmt.Printf("[*] Requesting a certificate from %s for: %s\n", opts.DirectoryURL, strings.Join(opts.Hosts, ", "))
+	account := &acme.Account{}
+	if opts.Email != "" {
+		account.Contact = []string{"mailto:" + opts.Email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("failed to register the ACME account: %w", err)
+	}
+
+	if opts.Challenge == ChallengeHTTP01 && opts.FreeHTTPPort != nil {
+		restore, err := opts.FreeHTTPPort()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := restore(); err != nil {
+				fmt.Printf("[!] %s\n", err)
+			}
+		}()
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(opts.Hosts...))
+	if err != nil {
+		return fmt.Errorf("failed to create the certificate order: %w", err)
+	}
+	if err := completeAuthorizations(ctx, client, order.AuthzURLs, opts); err != nil {
+		return err
+	}
+	if _, err := client.WaitOrder(ctx, order.URI); err != nil {
+		return fmt.Errorf("the certificate order was not approved: %w", err)
+	}
+
+	key, err := opts.KeyAlgorithm.generateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate private key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: opts.Hosts[0]},
+		DNSNames: opts.Hosts,
+	}, key)
+	if err != nil {
+		return fmt.Errorf("failed to create the certificate signing request: %w", err)
+	}
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("failed to get the issued certificate: %w", err)
+	}
+
+	certPath, keyPath, _ := certificatePaths(path)
+	if checkCerts(certPath, keyPath) == nil {
+		if err := archiveCertificates(certPath, keyPath); err != nil {
+			return err
+		}
+	}
+	if err := writeCertificatePair(certPath, keyPath, chain, key); err != nil {
+		return err
+	}
+
+	config, err := json.MarshalIndent(opts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, config, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+	fmt.Printf("[+] Successfully obtained a certificate for: %s\n", strings.Join(opts.Hosts, ", "))
+	return nil
+}
+
+// Completes a challenge for each pending authorization in the order
+func completeAuthorizations(ctx context.Context, client *acme.Client, urls []string, opts *ACMEOptions) error {
+	// Responses served by the temporary listener for HTTP-01 challenges, keyed by path
+	responses := map[string]string{}
+	var pending []*acme.Challenge
+	var authzURLs []string
+	var cleanups []func()
+	defer func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}()
+
+	for _, url := range urls {
+		authz, err := client.GetAuthorization(ctx, url)
+		if err != nil {
+			return fmt.Errorf("failed to get the authorization: %w", err)
+		}
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+		index := slices.IndexFunc(authz.Challenges, func(c *acme.Challenge) bool { return c.Type == opts.Challenge })
+		if index < 0 {
+			return fmt.Errorf("the CA doesn't offer a %s challenge for %s", opts.Challenge, authz.Identifier.Value)
+		}
+		challenge := authz.Challenges[index]
+
+		switch opts.Challenge {
+		case ChallengeHTTP01:
+			response, err := client.HTTP01ChallengeResponse(challenge.Token)
+			if err != nil {
+				return err
+			}
+			responses[client.HTTP01ChallengePath(challenge.Token)] = response
+		case ChallengeDNS01:
+			record, err := client.DNS01ChallengeRecord(challenge.Token)
+			if err != nil {
+				return err
+			}
+			domain := strings.TrimPrefix(authz.Identifier.Value, "*.")
+			if err := runDNSHook(ctx, opts.DNSHook, "present", domain, record); err != nil {
+				return err
+			}
+			cleanups = append(cleanups, func() {
+				if err := runDNSHook(context.Background(), opts.DNSHook, "cleanup", domain, record); err != nil {
+					fmt.Printf("[!] %s\n", err)
+				}
+			})
+		}
+		pending = append(pending, challenge)
+		authzURLs = append(authzURLs, url)
+	}
+
+	if len(responses) > 0 {
+		stop, err := serveHTTP01(opts.HTTPAddress, responses)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	for i, challenge := range pending {
+		if _, err := client.Accept(ctx, challenge); err != nil {
+			return fmt.Errorf("failed to accept the challenge: %w", err)
+		}
+		if _, err := client.WaitAuthorization(ctx, authzURLs[i]); err != nil {
+			return fmt.Errorf("the %s challenge failed: %w", opts.Challenge, err)
+		}
+	}
+	return nil
+}
+
+// Starts a temporary HTTP server for HTTP-01 challenges and returns a function to stop it
+func serveHTTP01(address string, responses map[string]string) (func(), error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s for the http-01 challenge (stop anything using the port or use a dns-01 challenge): %w", address, err)
+	}
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			response, found := responses[r.URL.Path]
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(response))
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go server.Serve(listener)
+	fmt.Printf("[*] Answering http-01 challenges on %s\n", listener.Addr())
+	return func() { server.Close() }, nil
+}
+
+// Runs the DNS hook to add or remove the TXT record for a DNS-01 challenge
+// The hook is called as `<hook> present|cleanup <record name> <value>` and should only exit
+// after the record has been published.
+func runDNSHook(ctx context.Context, hook, action, domain, value string) error {
+	name := "_acme-challenge." + domain
+	command := exec.CommandContext(ctx, hook, action, name, value)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.Env = append(os.Environ(),
+		"GHOSTWRITER_ACME_ACTION="+action,
+		"GHOSTWRITER_ACME_DOMAIN="+domain,
+		"GHOSTWRITER_ACME_RECORD="+name,
+		"GHOSTWRITER_ACME_VALUE="+value,
+	)
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("DNS hook %s %s failed for %s: %w", hook, action, name, err)
+	}
+	return nil
+}
+
+// RenewACMECertificate requests a new certificate with the saved ACME settings if the current
+// one expires within 30 days, or always if `force` is set. `freeHTTPPort` is used for HTTP-01
+// challenges, as described in `ACMEOptions`, and can be nil. Returns whether a certificate was issued.
+func RenewACMECertificate(path string, force bool, freeHTTPPort func() (func() error, error)) (bool, error) {
+	configPath, _ := acmePaths(filepath.Join(path, "ssl"))
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, ErrNoACMEConfig
+	} else if err != nil {
+		return false, fmt.Errorf("could not read %s: %w", configPath, err)
+	}
+	opts := &ACMEOptions{}
+	if err := json.Unmarshal(data, opts); err != nil {
+		return false, fmt.Errorf("could not parse %s: %w", configPath, err)
+	}
+
+	if !force {
+		if status, err := GetCertificateStatus(path); err == nil && status.DaysRemaining > acmeRenewalDays {
+			fmt.Printf("[*] The certificate is valid for %d more days, so it will not be renewed yet\n", status.DaysRemaining)
+			return false, nil
+		}
+	}
+	opts.FreeHTTPPort = freeHTTPPort
+	if err := ObtainACMECertificate(path, opts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,338 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme"
+)
+
+// Minimal ACME server in the style of Pebble for testing the client without a real CA
+// Signatures aren't checked, but challenges are validated against the account key.
+type testACMEServer struct {
+	*httptest.Server
+	t *testing.T
+	// Address of the client's http-01 listener
+	httpAddress string
+	// File the DNS hook writes "<name> <value>" lines to
+	dnsRecords string
+
+	mu         sync.Mutex
+	nonce      int
+	thumbprint string
+	hosts      []string
+	tokens     []string
+	valid      []bool
+	chain      []byte
+	caCert     *x509.Certificate
+	caKey      *ecdsa.PrivateKey
+}
+
+func newTestACMEServer(t *testing.T) *testACMEServer {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+	caCert, _ := x509.ParseCertificate(caDer)
+
+	server := &testACMEServer{t: t, caCert: caCert, caKey: caKey}
+	server.Server = httptest.NewTLSServer(http.HandlerFunc(server.handle))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Writes the server's TLS certificate to a file for `ACMEOptions.CACert`
+func (this *testACMEServer) writeRoot(dir string) string {
+	path := filepath.Join(dir, "test-acme-root.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: this.Certificate().Raw})
+	assert.NoError(this.t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func (this *testACMEServer) handle(w http.ResponseWriter, r *http.Request) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.nonce++
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", this.nonce))
+
+	if r.URL.Path == "/dir" {
+		json.NewEncoder(w).Encode(map[string]string{
+			"newNonce":   this.URL + "/nonce",
+			"newAccount": this.URL + "/account",
+			"newOrder":   this.URL + "/order",
+		})
+		return
+	}
+	if r.URL.Path == "/nonce" {
+		return
+	}
+
+	var jws struct{ Protected, Payload string }
+	assert.NoError(this.t, json.NewDecoder(r.Body).Decode(&jws))
+	protected, _ := base64.RawURLEncoding.DecodeString(jws.Protected)
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+
+	orderJSON := func() map[string]any {
+		status := "ready"
+		for _, valid := range this.valid {
+			if !valid {
+				status = "pending"
+			}
+		}
+		order := map[string]any{"status": status, "finalize": this.URL + "/finalize", "authorizations": []string{}}
+		for i := range this.hosts {
+			order["authorizations"] = append(order["authorizations"].([]string), fmt.Sprintf("%s/authz/%d", this.URL, i))
+		}
+		if this.chain != nil {
+			order["status"] = "valid"
+			order["certificate"] = this.URL + "/cert"
+		}
+		return order
+	}
+
+	var index int
+	switch {
+	case r.URL.Path == "/account":
+		var header struct{ JWK json.RawMessage }
+		json.Unmarshal(protected, &header)
+		thumbprint := jwkThumbprint(this.t, header.JWK)
+		w.Header().Set("Location", this.URL+"/account/1")
+		if this.thumbprint == thumbprint {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			this.thumbprint = thumbprint
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte(`{"status": "valid"}`))
+	case r.URL.Path == "/order":
+		var request struct{ Identifiers []acme.AuthzID }
+		json.Unmarshal(payload, &request)
+		this.hosts, this.tokens, this.valid, this.chain = nil, nil, nil, nil
+		for i, id := range request.Identifiers {
+			this.hosts = append(this.hosts, id.Value)
+			this.tokens = append(this.tokens, fmt.Sprintf("token-%d-%d", this.nonce, i))
+			this.valid = append(this.valid, false)
+		}
+		w.Header().Set("Location", this.URL+"/order/1")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(orderJSON())
+	case r.URL.Path == "/order/1":
+		json.NewEncoder(w).Encode(orderJSON())
+	case sscanf(r.URL.Path, "/authz/%d", &index):
+		status := "pending"
+		if this.valid[index] {
+			status = "valid"
+		}
+		challenges := []map[string]string{}
+		for _, kind := range []string{ChallengeHTTP01, ChallengeDNS01} {
+			challenges = append(challenges, map[string]string{
+				"type": kind, "url": fmt.Sprintf("%s/challenge/%d/%s", this.URL, index, kind), "token": this.tokens[index], "status": status,
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"identifier": map[string]string{"type": "dns", "value": this.hosts[index]},
+			"status":     status,
+			"challenges": challenges,
+		})
+	case sscanf(r.URL.Path, "/challenge/%d/", &index):
+		kind := filepath.Base(r.URL.Path)
+		keyAuth := this.tokens[index] + "." + this.thumbprint
+		if kind == ChallengeHTTP01 {
+			res, err := http.Get("http://" + this.httpAddress + "/.well-known/acme-challenge/" + this.tokens[index])
+			if assert.NoError(this.t, err, "Expected the http-01 listener to be reachable") {
+				body, _ := io.ReadAll(res.Body)
+				res.Body.Close()
+				this.valid[index] = string(body) == keyAuth
+			}
+		} else {
+			hash := sha256.Sum256([]byte(keyAuth))
+			record := fmt.Sprintf("_acme-challenge.%s %s", strings.TrimPrefix(this.hosts[index], "*."), base64.RawURLEncoding.EncodeToString(hash[:]))
+			records, _ := os.ReadFile(this.dnsRecords)
+			this.valid[index] = strings.Contains(string(records), record)
+		}
+		assert.True(this.t, this.valid[index], "Expected the %s challenge for %s to be valid", kind, this.hosts[index])
+		json.NewEncoder(w).Encode(map[string]string{"type": kind, "url": this.URL + r.URL.Path, "token": this.tokens[index], "status": "valid"})
+	case r.URL.Path == "/finalize":
+		var request struct{ CSR string }
+		json.Unmarshal(payload, &request)
+		csrDer, _ := base64.RawURLEncoding.DecodeString(request.CSR)
+		csr, err := x509.ParseCertificateRequest(csrDer)
+		assert.NoError(this.t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(this.nonce)),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, this.caCert, csr.PublicKey, this.caKey)
+		assert.NoError(this.t, err)
+		this.chain = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: this.caCert.Raw})...)
+		w.Header().Set("Location", this.URL+"/order/1")
+		json.NewEncoder(w).Encode(orderJSON())
+	case r.URL.Path == "/cert":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(this.chain)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func sscanf(s, format string, index *int) bool {
+	_, err := fmt.Sscanf(s, format, index)
+	return err == nil
+}
+
+// Computes the RFC 7638 thumbprint of an EC account key
+func jwkThumbprint(t *testing.T, raw json.RawMessage) string {
+	var jwk struct{ X, Y string }
+	assert.NoError(t, json.Unmarshal(raw, &jwk))
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	thumbprint, err := acme.JWKThumbprint(key)
+	assert.NoError(t, err)
+	return thumbprint
+}
+
+// Gets an unused local address for the http-01 listener
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestACMEHosts(t *testing.T) {
+	hosts := []string{"localhost", "127.0.0.1", "ghostwriter.local", "gw.example.com", ".example.com", "*", "django"}
+	assert.Equal(t, []string{"gw.example.com", "example.com"}, acmeHosts(hosts, ChallengeHTTP01))
+	assert.Equal(t, []string{"gw.example.com", "example.com", "*.example.com"}, acmeHosts(hosts, ChallengeDNS01))
+}
+
+func TestObtainACMECertificateHTTP01(t *testing.T) {
+	defer quietTests()()
+	tempDir := t.TempDir()
+	server := newTestACMEServer(t)
+	server.httpAddress = freeAddress(t)
+
+	opts := &ACMEOptions{
+		DirectoryURL: server.URL + "/dir",
+		Challenge:    ChallengeHTTP01,
+		HTTPAddress:  server.httpAddress,
+		CACert:       server.writeRoot(t.TempDir()),
+		Hosts:        []string{"localhost", "10.0.0.5", "ghostwriter.example.com"},
+	}
+	assert.NoError(t, ObtainACMECertificate(tempDir, opts), "Expected a certificate to be issued")
+
+	status, err := GetCertificateStatus(tempDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ghostwriter.example.com"}, status.DNSNames, "Expected only public names to be requested")
+	assert.Equal(t, "CN=Test ACME CA", status.Issuer)
+	assert.True(t, status.KeyMatches)
+	certPath, _, _ := certificatePaths(tempDir)
+	chain, _ := os.ReadFile(certPath)
+	assert.Equal(t, 2, strings.Count(string(chain), "BEGIN CERTIFICATE"), "Expected the intermediate to be included")
+	assert.True(t, ACMEConfigured(filepath.Join(tempDir, "ssl")), "Expected the settings to be saved")
+
+	// The certificate is valid for 90 days, so it's not renewed yet
+	renewed, err := RenewACMECertificate(tempDir, false, nil)
+	assert.NoError(t, err)
+	assert.False(t, renewed, "Expected the certificate not to be renewed early")
+
+	server.httpAddress = freeAddress(t)
+	configPath, _ := acmePaths(filepath.Join(tempDir, "ssl"))
+	saved, _ := os.ReadFile(configPath)
+	assert.NoError(t, os.WriteFile(configPath, []byte(strings.ReplaceAll(string(saved), opts.HTTPAddress, server.httpAddress)), 0600))
+	certPath, _, _ = certificatePaths(tempDir)
+	previous, _ := os.ReadFile(certPath)
+	var events []string
+	freeHTTPPort := func() (func() error, error) {
+		events = append(events, "stopped")
+		return func() error {
+			current, _ := os.ReadFile(certPath)
+			events = append(events, fmt.Sprintf("started with the new certificate: %t", !bytes.Equal(previous, current)))
+			return nil
+		}, nil
+	}
+	renewed, err = RenewACMECertificate(tempDir, true, freeHTTPPort)
+	assert.NoError(t, err)
+	assert.True(t, renewed, "Expected `force` to renew the certificate")
+	assert.Equal(t, []string{"stopped", "started with the new certificate: true"}, events, "Expected the port to be freed for the challenge and restored after the certificate is written")
+	archived, _ := os.ReadDir(filepath.Join(tempDir, "ssl", "archive"))
+	assert.Equal(t, 2, len(archived), "Expected the previous pair to be archived")
+
+	_, err = RenewACMECertificate(t.TempDir(), false, nil)
+	assert.ErrorIs(t, err, ErrNoACMEConfig)
+
+	// A certificate from the local CA replaces the ACME one for good
+	assert.NoError(t, generateCertificates(tempDir, &CertificateOptions{Hosts: []string{"ghostwriter.local"}, UseCA: true, Force: true}))
+	assert.False(t, ACMEConfigured(filepath.Join(tempDir, "ssl")), "Expected the ACME settings to be removed")
+	_, accountKey := acmePaths(filepath.Join(tempDir, "ssl"))
+	assert.True(t, FileExists(accountKey), "Expected the account key to be kept")
+	_, err = RenewACMECertificate(tempDir, true, nil)
+	assert.ErrorIs(t, err, ErrNoACMEConfig, "Expected `cert renew` to stop using ACME")
+}
+
+func TestObtainACMECertificateDNS01(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The test hook is a shell script")
+	}
+	defer quietTests()()
+	tempDir := t.TempDir()
+	server := newTestACMEServer(t)
+	server.dnsRecords = filepath.Join(t.TempDir(), "records")
+
+	hook := filepath.Join(t.TempDir(), "hook.sh")
+	script := fmt.Sprintf("#!/bin/sh\n[ \"$1\" = present ] && echo \"$2 $3\" >> %s\nexit 0\n", server.dnsRecords)
+	assert.NoError(t, os.WriteFile(hook, []byte(script), 0700))
+
+	opts := &ACMEOptions{
+		DirectoryURL: server.URL + "/dir",
+		Challenge:    ChallengeDNS01,
+		DNSHook:      hook,
+		CACert:       server.writeRoot(t.TempDir()),
+		Hosts:        []string{"ghostwriter.example.com", ".example.com"},
+		KeyAlgorithm: KeyRSA2048,
+	}
+	assert.NoError(t, ObtainACMECertificate(tempDir, opts), "Expected a certificate to be issued")
+
+	status, err := GetCertificateStatus(tempDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ghostwriter.example.com", "example.com", "*.example.com"}, status.DNSNames)
+	assert.Equal(t, "RSA 2048", status.KeyType)
+
+	assert.Error(t, ObtainACMECertificate(tempDir, &ACMEOptions{Challenge: ChallengeDNS01, Hosts: opts.Hosts}), "Expected dns-01 without a hook to fail")
+}
@@ -0,0 +1,174 @@
+package internal
+
+// Local certificate authority for issuing the Nginx certificate
+// Distributing the CA certificate to workstations once lets the leaf certificate be rotated without
+// having to trust each new certificate again.
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// File names of the local CA's certificate and private key in the `ssl` directory
+	caCertName = "ghostwriter-ca.crt"
+	caKeyName  = "ghostwriter-ca.key"
+	// Validity of a new CA certificate
+	caValidity = 10 * 365 * 24 * time.Hour
+)
+
+// Returned when an operation needs the local CA but it hasn't been created
+var ErrNoLocalCA = errors.New("no local certificate authority found, run `gencert --ca` to create one")
+
+// Gets the paths of the local CA's certificate and private key in the `ssl` directory
+func caPaths(sslPath string) (certPath, keyPath string) {
+	return filepath.Join(sslPath, caCertName), filepath.Join(sslPath, caKeyName)
+}
+
+// LocalCAExists checks if the local CA has been created in the `ssl` directory.
+func LocalCAExists(sslPath string) bool {
+	certPath, keyPath := caPaths(sslPath)
+	return checkCerts(certPath, keyPath) == nil
+}
+
+// LoadOrCreateCA loads the local CA from the `ssl` directory, creating it first if it doesn't exist.
+func LoadOrCreateCA(sslPath string) (*x509.Certificate, crypto.Signer, error) {
+	if LocalCAExists(sslPath) {
+		return loadCA(sslPath)
+	}
+	fmt.Println("[*] Creating a new local certificate authority...")
+	if err := createCA(sslPath); err != nil {
+		return nil, nil, fmt.Errorf("failed to create the local certificate authority: %w", err)
+	}
+	certPath, _ := caPaths(sslPath)
+	fmt.Printf("[+] Created the local certificate authority in %s\n", certPath)
+	fmt.Println("[*] Use `cert export-ca` to export the CA certificate and trust it on your workstations")
+	return loadCA(sslPath)
+}
+
+// Reads the local CA's certificate and private key and checks that they belong together
+func loadCA(sslPath string) (*x509.Certificate, crypto.Signer, error) {
+	certPath, keyPath := caPaths(sslPath)
+	cert, err := readCertificate(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := readPrivateKey(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !publicKeysEqual(cert.PublicKey, key.Public()) {
+		return nil, nil, fmt.Errorf("the private key in %s does not match %s", keyPath, certPath)
+	}
+	if !cert.IsCA {
+		return nil, nil, fmt.Errorf("%s is not a CA certificate", certPath)
+	}
+	return cert, key, nil
+}
+
+// Creates a self-signed CA certificate that can only issue leaf certificates
+func createCA(sslPath string) error {
+	priv, err := KeyECDSAP384.generateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate private key: %w", err)
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return err
+	}
+
+	notBefore := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"Ghostwriter"},
+			CommonName:   "Ghostwriter Local CA",
+		},
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(caValidity),
+
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, priv.Public(), priv)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+	certPath, keyPath := caPaths(sslPath)
+	return writeCertificatePair(certPath, keyPath, [][]byte{derBytes}, priv)
+}
+
+// ExportCA returns the local CA's certificate encoded as PEM or, if `der` is set, as DER.
+func ExportCA(sslPath string, der bool) ([]byte, error) {
+	if !LocalCAExists(sslPath) {
+		return nil, ErrNoLocalCA
+	}
+	certPath, _ := caPaths(sslPath)
+	cert, err := readCertificate(certPath)
+	if err != nil {
+		return nil, err
+	}
+	if der {
+		return cert.Raw, nil
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), nil
+}
+
+// Gets the algorithm of an existing public key, so a renewed certificate can use the same one
+func keyAlgorithmOf(key crypto.PublicKey) KeyAlgorithm {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().BitSize == 256 {
+			return KeyECDSAP256
+		}
+	case *rsa.PublicKey:
+		if key.N.BitLen() >= 4096 {
+			return KeyRSA4096
+		}
+		return KeyRSA2048
+	case ed25519.PublicKey:
+		return KeyEd25519
+	}
+	return KeyECDSAP384
+}
+
+// RenewCertificate issues a new leaf certificate from the local CA, archiving the current pair.
+// Hosts and the key algorithm default to the ones in the current certificate if they aren't set.
+func RenewCertificate(path string, opts *CertificateOptions) error {
+	sslPath := filepath.Join(path, "ssl")
+	if !LocalCAExists(sslPath) {
+		return ErrNoLocalCA
+	}
+
+	renewed := *opts
+	renewed.UseCA = true
+	certPath, keyPath, _ := certificatePaths(path)
+	if current, err := readCertificate(certPath); err == nil {
+		if len(renewed.Hosts) == 0 {
+			renewed.Hosts = append(append([]string{}, current.DNSNames...), ipStrings(current.IPAddresses)...)
+		}
+		if renewed.KeyAlgorithm == "" {
+			renewed.KeyAlgorithm = keyAlgorithmOf(current.PublicKey)
+		}
+		if err := archiveCertificates(certPath, keyPath); err != nil {
+			return err
+		}
+	} else if FileExists(certPath) {
+		return err
+	}
+
+	fmt.Println("[*] Issuing a new certificate from the local certificate authority...")
+	return issueCertificate(certPath, keyPath, &renewed)
+}
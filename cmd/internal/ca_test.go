@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalCA(t *testing.T) {
+	defer quietTests()()
+	tempDir := t.TempDir()
+	sslPath := filepath.Join(tempDir, "ssl")
+	assert.NoError(t, os.MkdirAll(sslPath, 0700))
+
+	_, err := ExportCA(sslPath, false)
+	assert.ErrorIs(t, err, ErrNoLocalCA, "Expected an error before the CA is created")
+	assert.ErrorIs(t, RenewCertificate(tempDir, &CertificateOptions{}), ErrNoLocalCA)
+
+	opts := &CertificateOptions{Hosts: []string{"ghostwriter.local", "10.0.0.5"}, KeyAlgorithm: KeyECDSAP256, UseCA: true}
+	assert.NoError(t, generateCertificates(tempDir, opts), "Expected the certificate to be issued from a new CA")
+	assert.True(t, LocalCAExists(sslPath), "Expected the CA to be created")
+
+	// The CA certificate exported as PEM and DER should verify the leaf
+	pemBytes, err := ExportCA(sslPath, false)
+	assert.NoError(t, err)
+	block, _ := pem.Decode(pemBytes)
+	assert.NotNil(t, block, "Expected a PEM certificate")
+	derBytes, err := ExportCA(sslPath, true)
+	assert.NoError(t, err)
+	assert.Equal(t, block.Bytes, derBytes, "Expected the DER export to match the PEM export")
+
+	caCert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	certPath, _, _ := certificatePaths(tempDir)
+	leaf, err := readCertificate(certPath)
+	assert.NoError(t, err)
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "ghostwriter.local"})
+	assert.NoError(t, err, "Expected the leaf to be trusted through the CA")
+
+	// Renewing keeps the CA, hosts, and key type
+	assert.NoError(t, RenewCertificate(tempDir, &CertificateOptions{Days: 30}))
+	renewed, err := readCertificate(certPath)
+	assert.NoError(t, err)
+	assert.NotEqual(t, leaf.SerialNumber, renewed.SerialNumber, "Expected a new certificate")
+	_, err = renewed.Verify(x509.VerifyOptions{Roots: roots, DNSName: "ghostwriter.local"})
+	assert.NoError(t, err, "Expected the renewed leaf to be issued by the same CA")
+	assert.Equal(t, []string{"10.0.0.5"}, ipStrings(renewed.IPAddresses))
+
+	status, err := GetCertificateStatus(tempDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "ECDSA P-256", status.KeyType)
+	assert.True(t, status.KeyMatches)
+	assert.Equal(t, 29, status.DaysRemaining)
+	assert.False(t, status.SelfSigned)
+}
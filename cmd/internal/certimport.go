@@ -0,0 +1,249 @@
+package internal
+
+// Import of certificates issued by an external certificate authority, such as a corporate PKI
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Hosts in the default `django_allowed_hosts` that are only used inside Docker or on the server itself,
+// so an externally issued certificate isn't expected to include them
+var internalHosts = []string{"localhost", "127.0.0.1", "::1", "django", "nginx", "host.docker.internal"}
+
+// ImportOptions controls the files read by `ImportCertificate`.
+type ImportOptions struct {
+	// PEM file with the certificate, optionally followed by its chain
+	CertPath string
+	// PEM file with the certificate's private key
+	KeyPath string
+	// PEM file with the intermediate certificates and, if it isn't trusted by the system, the root
+	ChainPath string
+	// PKCS #12 bundle with the certificate, key, and chain, used instead of the PEM files
+	PKCS12Path string
+	// Password of the PKCS #12 bundle
+	Password string
+	// Hosts the certificate should be valid for, usually `django_allowed_hosts`
+	Hosts []string
+	// Warn if the certificate expires within this many days, usually `gwcli_cert_expiry_warning_days`
+	ExpiryWarningDays int
+}
+
+// Certificates and private key read from the files being imported
+type importBundle struct {
+	certs []*x509.Certificate
+	key   crypto.Signer
+}
+
+// Adds the certificates and private key in PEM blocks to the bundle
+// PKCS #12 bundles decoded by `pkcs12.ToPEM` label PKCS #1 and SEC 1 keys as "PRIVATE KEY",
+// so those are tried if the key isn't PKCS #8.
+func (this *importBundle) addBlocks(blocks []*pem.Block, source string) error {
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("could not parse a certificate in %s: %w", source, err)
+			}
+			this.certs = append(this.certs, cert)
+			continue
+		}
+		key, err := parsePrivateKey(block)
+		if err != nil && block.Type == "PRIVATE KEY" {
+			if rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes); rsaErr == nil {
+				key, err = rsaKey, nil
+			} else if ecKey, ecErr := x509.ParseECPrivateKey(block.Bytes); ecErr == nil {
+				key, err = ecKey, nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("could not parse the private key in %s: %w", source, err)
+		}
+		if key != nil {
+			if this.key != nil {
+				return fmt.Errorf("found more than one private key in %s", source)
+			}
+			this.key = key
+		}
+	}
+	return nil
+}
+
+// Reads all PEM blocks in a file
+func readPEMBlocks(path string) ([]*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var blocks []*pem.Block
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return blocks, nil
+}
+
+// Reads the certificates and private key from either the PKCS #12 bundle or the PEM files
+func (this *ImportOptions) read() (*importBundle, error) {
+	bundle := &importBundle{}
+	if this.PKCS12Path != "" {
+		data, err := os.ReadFile(this.PKCS12Path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", this.PKCS12Path, err)
+		}
+		blocks, err := pkcs12.ToPEM(data, this.Password)
+		if err != nil {
+			// Only the legacy 3DES and RC2 encryption is supported, not the AES default of OpenSSL 3
+			return nil, fmt.Errorf("could not decode the PKCS #12 bundle %s (convert AES-encrypted bundles to PEM files with `openssl pkcs12 -nodes`): %w", this.PKCS12Path, err)
+		}
+		if err := bundle.addBlocks(blocks, this.PKCS12Path); err != nil {
+			return nil, err
+		}
+	} else {
+		if this.CertPath == "" || this.KeyPath == "" {
+			return nil, errors.New("a certificate and private key, or a PKCS #12 bundle, are required")
+		}
+		for _, path := range []string{this.CertPath, this.KeyPath, this.ChainPath} {
+			if path == "" {
+				continue
+			}
+			blocks, err := readPEMBlocks(path)
+			if err != nil {
+				return nil, err
+			}
+			if err := bundle.addBlocks(blocks, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(bundle.certs) == 0 {
+		return nil, errors.New("no certificates found to import")
+	}
+	if bundle.key == nil {
+		return nil, errors.New("no private key found to import")
+	}
+	return bundle, nil
+}
+
+// Finds the certificate for the private key and verifies its chain
+// Self-signed certificates in the bundle are trusted as roots along with the system's roots.
+// Returns the certificate followed by its intermediates, without the root.
+func (this *importBundle) verify() ([]*x509.Certificate, error) {
+	index := slices.IndexFunc(this.certs, func(cert *x509.Certificate) bool {
+		return publicKeysEqual(cert.PublicKey, this.key.Public())
+	})
+	if index < 0 {
+		return nil, errors.New("the private key does not match any of the certificates")
+	}
+	leaf := this.certs[index]
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range this.certs {
+		if isSelfSigned(cert) {
+			roots.AddCert(cert)
+		} else if cert != leaf {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("the certificate chain is not valid (include the intermediate and root certificates with --chain): %w", err)
+	}
+	chain := chains[0]
+	if len(chain) > 1 {
+		chain = chain[:len(chain)-1]
+	}
+	return chain, nil
+}
+
+// Checks which of the hosts the certificate is valid for, skipping "*" and internal hosts
+// Django's leading-dot subdomain wildcards are covered by the domain itself or a matching DNS wildcard.
+func checkCertificateHosts(cert *x509.Certificate, hosts []string) (covered, missing []string) {
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" || host == "*" || slices.Contains(internalHosts, host) {
+			continue
+		}
+		name := strings.TrimPrefix(strings.Trim(host, "[]"), ".")
+		if ip := net.ParseIP(name); ip != nil && ip.IsLoopback() {
+			continue
+		}
+		if cert.VerifyHostname(name) == nil || (strings.HasPrefix(host, ".") && slices.Contains(cert.DNSNames, "*"+host)) {
+			covered = append(covered, host)
+		} else {
+			missing = append(missing, host)
+		}
+	}
+	return covered, missing
+}
+
+// ImportCertificate validates an externally issued certificate and its private key and installs
+// them as the Nginx certificate in the `ssl` directory of `path`, archiving the current pair.
+// The certificate must be valid for at least one of `opts.Hosts`; any other hosts it doesn't
+// cover are returned as warnings.
+func ImportCertificate(path string, opts *ImportOptions) ([]string, error) {
+	bundle, err := opts.read()
+	if err != nil {
+		return nil, err
+	}
+	chain, err := bundle.verify()
+	if err != nil {
+		return nil, err
+	}
+	leaf := chain[0]
+
+	var warnings []string
+	covered, missing := checkCertificateHosts(leaf, opts.Hosts)
+	if len(covered) == 0 && len(missing) > 0 {
+		return nil, fmt.Errorf("the certificate is not valid for any of the allowed hosts: %s", strings.Join(missing, ", "))
+	}
+	for _, host := range missing {
+		warnings = append(warnings, fmt.Sprintf("The certificate is not valid for %s", host))
+	}
+	if days := daysUntil(leaf.NotAfter, time.Now()); days <= opts.ExpiryWarningDays {
+		warnings = append(warnings, fmt.Sprintf("The certificate expires in %d days on %s", days, leaf.NotAfter.Format(time.DateOnly)))
+	}
+
+	sslPath := filepath.Join(path, "ssl")
+	if err := os.MkdirAll(sslPath, 0700); err != nil {
+		return nil, fmt.Errorf("failed to make the `ssl` directory: %w", err)
+	}
+	certPath, keyPath, _ := certificatePaths(path)
+	if err := archiveCertificates(certPath, keyPath); err != nil {
+		return nil, err
+	}
+
+	derChain := make([][]byte, len(chain))
+	for i, cert := range chain {
+		derChain[i] = cert.Raw
+	}
+	if err := writeCertificatePair(certPath, keyPath, derChain, bundle.key); err != nil {
+		return nil, err
+	}
+	if err := removeACMEConfig(sslPath); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
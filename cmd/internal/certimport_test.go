@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Issues a test certificate signed by `parent`, or self-signed if `parent` is nil
+func issueTestCertificate(t *testing.T, name string, isCA bool, dnsNames []string, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+// Writes certificates to a PEM file
+func writeTestPEM(t *testing.T, path string, certs ...*x509.Certificate) string {
+	var data []byte
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	assert.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestImportCertificate(t *testing.T) {
+	defer quietTests()()
+	tempDir := t.TempDir()
+	sourceDir := t.TempDir()
+
+	root, rootKey := issueTestCertificate(t, "Corp Root CA", true, nil, nil, nil)
+	intermediate, intermediateKey := issueTestCertificate(t, "Corp Issuing CA", true, nil, root, rootKey)
+	leaf, leafKey := issueTestCertificate(t, "ghostwriter.corp.example", false, []string{"ghostwriter.corp.example"}, intermediate, intermediateKey)
+	_, otherKey := issueTestCertificate(t, "other", false, nil, nil, nil)
+
+	certPath := writeTestPEM(t, filepath.Join(sourceDir, "leaf.crt"), leaf)
+	chainPath := writeTestPEM(t, filepath.Join(sourceDir, "chain.pem"), intermediate, root)
+	rootOnlyPath := writeTestPEM(t, filepath.Join(sourceDir, "root.pem"), root)
+	keyPath := filepath.Join(sourceDir, "leaf.key")
+	otherKeyPath := filepath.Join(sourceDir, "other.key")
+	for path, key := range map[string]crypto.Signer{keyPath: leafKey, otherKeyPath: otherKey} {
+		block, err := marshalPrivateKey(key)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+	}
+	hosts := []string{"localhost", "127.0.0.1", "django", "nginx", "ghostwriter.corp.example", "ghostwriter.local"}
+
+	_, err := ImportCertificate(tempDir, &ImportOptions{CertPath: certPath, KeyPath: otherKeyPath, ChainPath: chainPath, Hosts: hosts})
+	assert.ErrorContains(t, err, "does not match", "Expected an error for a key that doesn't match")
+
+	_, err = ImportCertificate(tempDir, &ImportOptions{CertPath: certPath, KeyPath: keyPath, ChainPath: rootOnlyPath, Hosts: hosts})
+	assert.ErrorContains(t, err, "chain is not valid", "Expected an error for a missing intermediate")
+
+	_, err = ImportCertificate(tempDir, &ImportOptions{CertPath: certPath, KeyPath: keyPath, ChainPath: chainPath, Hosts: []string{"ghostwriter.local"}})
+	assert.ErrorContains(t, err, "not valid for any of the allowed hosts")
+
+	_, err = ImportCertificate(tempDir, &ImportOptions{CertPath: certPath, Hosts: hosts})
+	assert.Error(t, err, "Expected an error without a private key")
+
+	installedCert, installedKey, _ := certificatePaths(tempDir)
+	assert.False(t, FileExists(installedCert), "Expected failed imports to leave the `ssl` directory alone")
+
+	warnings, err := ImportCertificate(tempDir, &ImportOptions{CertPath: certPath, KeyPath: keyPath, ChainPath: chainPath, Hosts: hosts})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"The certificate is not valid for ghostwriter.local"}, warnings)
+
+	blocks, err := readPEMBlocks(installedCert)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(blocks), "Expected the certificate and intermediate, without the root")
+	assert.Equal(t, leaf.Raw, blocks[0].Bytes)
+	assert.Equal(t, intermediate.Raw, blocks[1].Bytes)
+
+	status, err := GetCertificateStatus(tempDir)
+	assert.NoError(t, err)
+	assert.True(t, status.KeyMatches, "Expected the imported key to match the certificate")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(installedKey)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Expected the key to only be readable by the owner")
+	}
+
+	// Importing again archives the current pair
+	warnings, err = ImportCertificate(tempDir, &ImportOptions{CertPath: certPath, KeyPath: keyPath, ChainPath: chainPath, Hosts: hosts, ExpiryWarningDays: 120})
+	assert.NoError(t, err)
+	assert.Contains(t, warnings, fmt.Sprintf("The certificate expires in %d days on %s", daysUntil(leaf.NotAfter, time.Now()), leaf.NotAfter.Format(time.DateOnly)), "Expected the configured warning period to be used")
+	archived, _ := os.ReadDir(filepath.Join(tempDir, "ssl", "archive"))
+	assert.Equal(t, 2, len(archived), "Expected the previous pair to be archived")
+}
+
+func TestImportBundleKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	assert.NoError(t, err)
+
+	// Keys decoded from PKCS #12 bundles are labelled "PRIVATE KEY" without being PKCS #8
+	bundle := &importBundle{}
+	assert.NoError(t, bundle.addBlocks([]*pem.Block{{Type: "PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}}, "test.pfx"))
+	assert.True(t, rsaKey.Equal(bundle.key), "Expected the PKCS #1 key to be parsed")
+
+	bundle = &importBundle{}
+	assert.NoError(t, bundle.addBlocks([]*pem.Block{{Type: "PRIVATE KEY", Bytes: ecDER}}, "test.pfx"))
+	assert.True(t, ecKey.Equal(bundle.key), "Expected the SEC 1 key to be parsed")
+
+	err = bundle.addBlocks([]*pem.Block{{Type: "EC PRIVATE KEY", Bytes: ecDER}}, "test.pem")
+	assert.ErrorContains(t, err, "more than one private key")
+
+	bundle = &importBundle{}
+	err = bundle.addBlocks([]*pem.Block{{Type: "PRIVATE KEY", Bytes: []byte("invalid")}}, "test.pem")
+	assert.Error(t, err, "Expected an error for an invalid key")
+}
+
+func TestCheckCertificateHosts(t *testing.T) {
+	cert := &x509.Certificate{DNSNames: []string{"ghostwriter.example.com", "*.example.com"}}
+	covered, missing := checkCertificateHosts(cert, []string{"*", "localhost", "nginx", "ghostwriter.example.com", ".example.com", "other.example.org", "[::1]"})
+	assert.Equal(t, []string{"ghostwriter.example.com", ".example.com"}, covered)
+	assert.Equal(t, []string{"other.example.org"}, missing)
+}
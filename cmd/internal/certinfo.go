@@ -0,0 +1,228 @@
+package internal
+
+// Inspection of the TLS certificate, private key, and DH parameters used by Nginx
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Default number of days before a certificate expires to start warning about it
+const defaultCertExpiryWarningDays = 30
+
+// CertificateStatus describes the certificate package in the `ssl/` directory.
+type CertificateStatus struct {
+	Path        string    `json:"path"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dns_names"`
+	IPAddresses []string  `json:"ip_addresses"`
+	KeyType     string    `json:"key_type"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	// Whole days until the certificate expires, negative if it has already expired
+	DaysRemaining int  `json:"days_remaining"`
+	SelfSigned    bool `json:"self_signed"`
+	// Whether `ghostwriter.key` is the private key for the certificate
+	KeyMatches bool `json:"key_matches"`
+	// Size of the prime in `dhparam.pem`, or zero if the file is missing or invalid
+	DHParamBits int `json:"dhparam_bits"`
+	// Name of the RFC 7919 group in `dhparam.pem`, or empty for generated parameters
+	DHParamGroup DHGroup `json:"dhparam_group"`
+	// Problems reading the key or DH parameters, which don't prevent inspecting the certificate
+	Errors []string `json:"errors"`
+}
+
+// Gets the paths of the certificate, private key, and DH parameters in the data directory
+func certificatePaths(dir string) (certPath, keyPath, dhPath string) {
+	sslPath := filepath.Join(dir, "ssl")
+	return filepath.Join(sslPath, "ghostwriter.crt"), filepath.Join(sslPath, "ghostwriter.key"), filepath.Join(sslPath, "dhparam.pem")
+}
+
+// GetCertificateStatus inspects the certificate package in the `ssl/` directory of `dir`.
+// An error is only returned if the certificate itself can't be read.
+func GetCertificateStatus(dir string) (*CertificateStatus, error) {
+	certPath, keyPath, dhPath := certificatePaths(dir)
+	cert, err := readCertificate(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &CertificateStatus{
+		Path:          certPath,
+		Subject:       cert.Subject.String(),
+		Issuer:        cert.Issuer.String(),
+		DNSNames:      append([]string{}, cert.DNSNames...),
+		IPAddresses:   ipStrings(cert.IPAddresses),
+		KeyType:       describePublicKey(cert.PublicKey),
+		NotBefore:     cert.NotBefore,
+		NotAfter:      cert.NotAfter,
+		DaysRemaining: daysUntil(cert.NotAfter, time.Now()),
+		SelfSigned:    isSelfSigned(cert),
+		Errors:        []string{},
+	}
+	key, err := readPrivateKey(keyPath)
+	if err != nil {
+		status.Errors = append(status.Errors, err.Error())
+	} else {
+		status.KeyMatches = publicKeysEqual(cert.PublicKey, key.Public())
+	}
+
+	if params, err := readDHParams(dhPath); err != nil {
+		status.Errors = append(status.Errors, err.Error())
+	} else {
+		status.DHParamBits = params.P.BitLen()
+		status.DHParamGroup = params.group()
+	}
+
+	return status, nil
+}
+
+// CertExpiryWarningDays gets `gwcli_cert_expiry_warning_days`, the number of days before the
+// certificate expires to start warning about it.
+func (this *GWEnvironment) CertExpiryWarningDays() int {
+	days, err := strconv.Atoi(this.Get("gwcli_cert_expiry_warning_days"))
+	if err != nil || days < 0 {
+		return defaultCertExpiryWarningDays
+	}
+	return days
+}
+
+// CheckCertificate checks the certificate's expiry against `gwcli_cert_expiry_warning_days`
+// and that the private key matches it. Returns nil if there is no certificate to check.
+func (this *DockerInterface) CheckCertificate() *HealthIssue {
+	certPath, _, _ := certificatePaths(this.Dir)
+	if !FileExists(certPath) {
+		return nil
+	}
+	warningDays := this.Env.CertExpiryWarningDays()
+
+	issue := &HealthIssue{Type: "Certificate", Service: "nginx", Severity: SeverityOK}
+	status, err := GetCertificateStatus(this.Dir)
+	switch {
+	case err != nil:
+		issue.Severity = SeverityUnknown
+		issue.Message = err.Error()
+	case status.DaysRemaining < 0:
+		issue.Severity = SeverityCritical
+		issue.Message = fmt.Sprintf("The TLS certificate expired on %s", status.NotAfter.Format(time.DateOnly))
+	case !status.KeyMatches:
+		issue.Severity = SeverityCritical
+		issue.Message = "The private key in ghostwriter.key does not match the TLS certificate"
+	case status.DaysRemaining <= warningDays:
+		issue.Severity = SeverityWarning
+		issue.Message = fmt.Sprintf("The TLS certificate expires in %d days on %s", status.DaysRemaining, status.NotAfter.Format(time.DateOnly))
+	default:
+		issue.Message = fmt.Sprintf("The TLS certificate is valid until %s", status.NotAfter.Format(time.DateOnly))
+	}
+	return issue
+}
+
+// CertificateExpiryNag prints a warning if the certificate has expired or is about to expire.
+func CertificateExpiryNag(docker *DockerInterface) {
+	issue := docker.CheckCertificate()
+	if issue == nil || issue.Severity == SeverityOK {
+		return
+	}
+	fmt.Printf("[!] %s\n", issue.Message)
+	fmt.Println("[!] Run the `gencert` command or replace the files in the `ssl/` directory to use a new certificate")
+}
+
+// Reads the first certificate in a PEM file
+func readCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the certificate in %s: %w", path, err)
+		}
+		return cert, nil
+	}
+	return nil, fmt.Errorf("no certificate found in %s", path)
+}
+
+// Reads a PEM private key in PKCS #1, PKCS #8, or SEC 1 (EC) format
+func readPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		key, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the private key in %s: %w", path, err)
+		}
+		if key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no private key found in %s", path)
+}
+
+// Parses a private key from a PEM block, returning nil if the block isn't a private key
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	return nil, nil
+}
+
+// Checks if the certificate is signed by its own key, regardless of whether it's marked as a CA
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// Compares two public keys of any supported type
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// Describes a public key's algorithm and size, e.g., "ECDSA P-384" or "RSA 2048"
+func describePublicKey(key crypto.PublicKey) string {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", key.N.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return fmt.Sprintf("%T", key)
+}
+
+// Counts the whole days from `now` until `t`, rounding down
+func daysUntil(t time.Time, now time.Time) int {
+	return int(math.Floor(t.Sub(now).Hours() / 24))
+}
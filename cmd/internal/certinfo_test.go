@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Writes a self-signed certificate and its key to the `ssl` directory, expiring after `validFor`
+func writeTestCertificate(t *testing.T, dir string, validFor time.Duration) *ecdsa.PrivateKey {
+	sslPath := filepath.Join(dir, "ssl")
+	assert.NoError(t, os.MkdirAll(sslPath, 0700))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ghostwriter.local"},
+		DNSNames:     []string{"ghostwriter.local"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	assert.NoError(t, os.WriteFile(filepath.Join(sslPath, "ghostwriter.crt"), certPem, 0644))
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+	assert.NoError(t, os.WriteFile(filepath.Join(sslPath, "ghostwriter.key"), keyPem, 0600))
+	return key
+}
+
+func TestGetCertificateStatus(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCertificate(t, dir, 10*24*time.Hour+time.Hour)
+
+	// Any odd number works for reading the size
+	prime := new(big.Int).Lsh(big.NewInt(1), 2047)
+	prime.Add(prime, big.NewInt(1))
+	dhDer, err := asn1.Marshal(dhParameters{P: prime, G: big.NewInt(2)})
+	assert.NoError(t, err)
+	dhPem := pem.EncodeToMemory(&pem.Block{Type: "DH PARAMETERS", Bytes: dhDer})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ssl", "dhparam.pem"), dhPem, 0644))
+
+	status, err := GetCertificateStatus(dir)
+	assert.NoError(t, err, "Expected `GetCertificateStatus()` to return no error")
+	assert.Equal(t, "CN=ghostwriter.local", status.Subject)
+	assert.Equal(t, []string{"ghostwriter.local"}, status.DNSNames)
+	assert.Equal(t, []string{"127.0.0.1"}, status.IPAddresses)
+	assert.Equal(t, "ECDSA P-256", status.KeyType)
+	assert.Equal(t, 10, status.DaysRemaining)
+	assert.True(t, status.SelfSigned, "Expected the certificate to be self-signed")
+	assert.True(t, status.KeyMatches, "Expected the key to match the certificate")
+	assert.Equal(t, 2048, status.DHParamBits)
+	assert.Empty(t, status.Errors)
+
+	// Replace the key with a different one
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	otherDer, err := x509.MarshalECPrivateKey(other)
+	assert.NoError(t, err)
+	otherPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: otherDer})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ssl", "ghostwriter.key"), otherPem, 0600))
+	status, err = GetCertificateStatus(dir)
+	assert.NoError(t, err)
+	assert.False(t, status.KeyMatches, "Expected a different key not to match")
+
+	_, err = GetCertificateStatus(t.TempDir())
+	assert.Error(t, err, "Expected an error without a certificate")
+}
+
+func TestCheckCertificate(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, _ := newFakeDockerInterface(t)
+	assert.Nil(t, dockerInterface.CheckCertificate(), "Expected no result without a certificate")
+
+	writeTestCertificate(t, dockerInterface.Dir, 90*24*time.Hour)
+	assert.Equal(t, SeverityOK, dockerInterface.CheckCertificate().Severity)
+
+	dockerInterface.Env.Set("gwcli_cert_expiry_warning_days", "120")
+	issue := dockerInterface.CheckCertificate()
+	assert.Equal(t, SeverityWarning, issue.Severity, "Expected a warning within the configured window")
+	assert.Contains(t, issue.Message, "expires in 89 days")
+
+	writeTestCertificate(t, dockerInterface.Dir, -time.Hour)
+	assert.Equal(t, SeverityCritical, dockerInterface.CheckCertificate().Severity, "Expected an expired certificate to be critical")
+}
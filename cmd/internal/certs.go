@@ -1,186 +1,419 @@
 package internal
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
-
-	"github.com/Luzifer/go-dhparam"
 )
 
+// Check if the SSL certificates are present in the specified "certPath" and "keyPath".
+func checkCerts(certPath string, keyPath string) error {
+	if _, err := os.Stat(certPath); os.IsNotExist(err) {
+		return err
+	} else if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Algorithm and size of the private key for a certificate
+type KeyAlgorithm string
+
 const (
-	// Bit size for Diffie-Helman parameters
-	bitSize = 2048
+	KeyECDSAP256 KeyAlgorithm = "ecdsa-p256"
+	KeyECDSAP384 KeyAlgorithm = "ecdsa-p384"
+	KeyRSA2048   KeyAlgorithm = "rsa-2048"
+	KeyRSA4096   KeyAlgorithm = "rsa-4096"
+	KeyEd25519   KeyAlgorithm = "ed25519"
 )
 
-// Callback function for "go-dhparam".
-func dhCallback(r dhparam.GeneratorResult) {
-	switch r {
-	case dhparam.GeneratorFoundPossiblePrime:
-		os.Stdout.WriteString(".")
-	case dhparam.GeneratorFirstConfirmation:
-		os.Stdout.WriteString("+")
-	case dhparam.GeneratorSafePrimeFound:
-		os.Stdout.WriteString("*\n")
+var AllKeyAlgorithms = []string{string(KeyECDSAP256), string(KeyECDSAP384), string(KeyRSA2048), string(KeyRSA4096), string(KeyEd25519)}
+
+// cobra pvalue.Value implementation for argument parsing
+func (e *KeyAlgorithm) String() string {
+	return string(*e)
+}
+func (e *KeyAlgorithm) Set(v string) error {
+	if !slices.Contains(AllKeyAlgorithms, v) {
+		return errors.New("must be one of: " + strings.Join(AllKeyAlgorithms, ", "))
 	}
+	*e = KeyAlgorithm(v)
+	return nil
+}
+func (e *KeyAlgorithm) Type() string {
+	return "KeyAlgorithm"
 }
 
-// Generate Diffie-Helman parameters using "go-dhparam".
-func generateDHParam() ([]byte, error) {
-	dh, err := dhparam.Generate(bitSize, dhparam.GeneratorTwo, dhCallback)
-	if err != nil {
-		return nil, err
+// Generates a private key with the algorithm
+func (e KeyAlgorithm) generateKey() (crypto.Signer, error) {
+	switch e {
+	case KeyECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyECDSAP384, "":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyEd25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
 	}
-	bytes, err := dh.ToPEM()
+	return nil, fmt.Errorf("unsupported key algorithm: %s", e)
+}
+
+// CertificateOptions controls the certificate created by `GenerateCertificatePackage`.
+type CertificateOptions struct {
+	// DNS names and IP addresses to include as Subject Alternative Names
+	Hosts []string
+	// Number of days the certificate is valid for, 365 if zero
+	Days int
+	// Algorithm of the private key, ECDSA P-384 if empty
+	KeyAlgorithm KeyAlgorithm
+	// Replace an existing certificate, copying the old files to `ssl/archive/`
+	Force bool
+	// Sign the certificate with the local CA in `ssl/`, creating the CA if it doesn't exist
+	UseCA bool
+	// Request the certificate from an ACME CA instead of generating it
+	ACME *ACMEOptions
+	// Source of the DH parameters in `dhparam.pem`
+	DHParams DHParamOptions
+}
+
+// DefaultCertificateOptions returns the options used by the `install` command, with the SANs
+// taken from `django_allowed_hosts`.
+func DefaultCertificateOptions(env *GWEnvironment) *CertificateOptions {
+	return &CertificateOptions{Hosts: env.GetList("django_allowed_hosts")}
+}
+
+// Splits hosts into DNS names and IP addresses for the Subject Alternative Name extension
+// Django's leading-dot wildcards match the domain and its subdomains, so they become the domain and
+// a DNS wildcard. A lone "*" is skipped.
+func subjectAltNames(hosts []string) ([]string, []net.IP) {
+	dnsNames := []string{}
+	ipAddresses := []net.IP{}
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" || host == "*" {
+			continue
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+			if !slices.ContainsFunc(ipAddresses, ip.Equal) {
+				ipAddresses = append(ipAddresses, ip)
+			}
+			continue
+		}
+		names := []string{host}
+		if strings.HasPrefix(host, ".") {
+			names = []string{host[1:], "*" + host}
+		}
+		for _, name := range names {
+			if !slices.Contains(dnsNames, name) {
+				dnsNames = append(dnsNames, name)
+			}
+		}
+	}
+	return dnsNames, ipAddresses
+}
+
+// Copies the existing certificate and key into `ssl/archive/` with a timestamp
+// The current files are left in place until the new pair replaces them.
+func archiveCertificates(certPath, keyPath string) error {
+	archivePath := filepath.Join(filepath.Dir(certPath), "archive")
+	if err := os.MkdirAll(archivePath, 0700); err != nil {
+		return fmt.Errorf("failed to make the archive directory: %w", err)
+	}
+	timestamp := time.Now().Format("2006_01_02T15_04_05")
+	for _, path := range []string{certPath, keyPath} {
+		if !FileExists(path) {
+			continue
+		}
+		ext := filepath.Ext(path)
+		name := strings.TrimSuffix(filepath.Base(path), ext)
+		archived := filepath.Join(archivePath, fmt.Sprintf("%s_%s%s", name, timestamp, ext))
+		// Don't overwrite a pair archived within the same second
+		for i := 1; FileExists(archived); i++ {
+			archived = filepath.Join(archivePath, fmt.Sprintf("%s_%s_%d%s", name, timestamp, i, ext))
+		}
+		if _, err := MigrateFile(path, archived, 0600, false); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", path, err)
+		}
+		fmt.Printf("[+] Archived %s to %s\n", filepath.Base(path), archived)
+	}
+	return nil
+}
+
+// Encodes a private key as PEM, using SEC 1 for ECDSA keys like previous versions and PKCS #8 for others
+func marshalPrivateKey(key crypto.Signer) (*pem.Block, error) {
+	if ecKey, ok := key.(*ecdsa.PrivateKey); ok {
+		der, err := x509.MarshalECPrivateKey(ecKey)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
 	if err != nil {
 		return nil, err
 	}
-	return bytes, nil
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
 }
 
-// Generate the Diffie-Helman parameters and then write the file to disk in the
-// output directory ("outputDir") with the specified "name".
-func writeDHParams(outputDir, name string) error {
-	fileName := filepath.Join(outputDir, name+".pem")
-	if FileExists(fileName) {
-		fmt.Printf("[*] Skipping DH params because %s already exists\n", fileName)
-		return nil
+// Writes the certificate chain and private key as PEM files, with the key only readable by the owner
+// The chain starts with the certificate itself, followed by any intermediate certificates.
+//
+// Two files can't be replaced at once, so both are fully written to temporary files first and then
+// renamed over the current pair one after the other. Nginx only reads the pair when it starts or
+// reloads, which happens after both are replaced. Anything reading the files in between can briefly
+// see the new key with the old certificate. If the certificate can't be replaced, the previous key
+// is put back so the pair still matches.
+func writeCertificatePair(certPath, keyPath string, chain [][]byte, key crypto.Signer) error {
+	keyBlock, err := marshalPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("unable to marshal private key: %w", err)
+	}
+	var certPEM []byte
+	for _, derBytes := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})...)
 	}
-	fmt.Println("[*] Generating a new `dhparam.pem` file (this could take a few minutes)")
-	b, err := generateDHParam()
+
+	keyTemp, err := stageFile(keyPath, pem.EncodeToMemory(keyBlock), 0600)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("[+] Writing DH parameters to %s\n", fileName)
-	if err := os.WriteFile(fileName, b, 0644); err != nil {
+	defer os.Remove(keyTemp)
+	certTemp, err := stageFile(certPath, certPEM, 0644)
+	if err != nil {
 		return err
 	}
+	defer os.Remove(certTemp)
+
+	previousKey, err := os.ReadFile(keyPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", keyPath, err)
+	}
+	if err := os.Rename(keyTemp, keyPath); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", keyPath, err)
+	}
+	if err := os.Rename(certTemp, certPath); err != nil {
+		if previousKey != nil {
+			if restoreErr := writeFileAtomic(keyPath, previousKey, 0600); restoreErr != nil {
+				return fmt.Errorf("failed to replace %s: %w (the previous key could not be restored: %v)", certPath, err, restoreErr)
+			}
+		}
+		return fmt.Errorf("failed to replace %s: %w", certPath, err)
+	}
 	return nil
 }
 
-// Check if the SSL certificates are present in the specified "certPath" and "keyPath".
-func checkCerts(certPath string, keyPath string) error {
-	if _, err := os.Stat(certPath); os.IsNotExist(err) {
-		return err
-	} else if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+// Writes `data` to a temporary file in the same directory as `path` and returns its path, so it
+// can be renamed over `path`. The caller removes the temporary file if it isn't renamed.
+func stageFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s for writing: %w", path, err)
+	}
+	tempPath := tempFile.Name()
+
+	// Temporary files are created with 0600, so the key is never readable by others
+	if err := tempFile.Chmod(perm); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", fmt.Errorf("failed to set the permissions of %s: %w", path, err)
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return tempPath, nil
+}
+
+// Writes a file by renaming a temporary file in the same directory over it
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tempPath, err := stageFile(path, data, perm)
+	if err != nil {
 		return err
 	}
+	defer os.Remove(tempPath)
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
 	return nil
 }
 
 // Generate the TLS certificates and Diffie-Helman parameters file using Go.
-func generateCertificates(path string) error {
+func generateCertificates(path string, opts *CertificateOptions) error {
 	certPath := filepath.Join(path, "ssl", "ghostwriter.crt")
 	keyPath := filepath.Join(path, "ssl", "ghostwriter.key")
-	if checkCerts(certPath, keyPath) == nil {
+	exists := checkCerts(certPath, keyPath) == nil
+	if exists && !opts.Force {
 		fmt.Printf("[!] Found existing certificate files, so new ones will not be generated...\n")
-		fmt.Printf("[*] Rename or delete ssl/ghostwriter.key and ssl/ghostwriter.crt if you want to replace these keys\n")
+		fmt.Printf("[*] Use `--force` or rename or delete ssl/ghostwriter.key and ssl/ghostwriter.crt if you want to replace these keys\n")
 		return nil
 	}
-	fmt.Printf("[*] Did not find existing TLS/SSL certs for the Nginx container, so generating them now...\n")
+	if opts.ACME != nil {
+		// The current pair is only archived once the CA has issued the new certificate
+		acmeOpts := *opts.ACME
+		acmeOpts.Hosts = opts.Hosts
+		acmeOpts.KeyAlgorithm = opts.KeyAlgorithm
+		return ObtainACMECertificate(path, &acmeOpts)
+	}
+	if exists {
+		if err := archiveCertificates(certPath, keyPath); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("[*] Did not find existing TLS/SSL certs for the Nginx container, so generating them now...\n")
+	}
+	if err := issueCertificate(certPath, keyPath, opts); err != nil {
+		return err
+	}
+	return removeACMEConfig(filepath.Dir(certPath))
+}
 
-	// Generate the ECDSA private key
-	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+// Generates a private key and a certificate for it, signed by the local CA if `opts.UseCA` is
+// set or self-signed otherwise, and writes them to `certPath` and `keyPath`
+func issueCertificate(certPath, keyPath string, opts *CertificateOptions) error {
+	priv, err := opts.KeyAlgorithm.generateKey()
 	if err != nil {
-		log.Printf("Failed to generate private key: %s\n", err)
-		return err
+		return fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	// Set dates to today and one year from now
+	// Set dates to today and the end of the validity period
+	days := opts.Days
+	if days <= 0 {
+		days = 365
+	}
 	notBefore := time.Now()
-	oneYear := 365 * 24 * time.Hour
-	notAfter := notBefore.Add(oneYear)
+	notAfter := notBefore.Add(time.Duration(days) * 24 * time.Hour)
 
-	// Generate a serial number for the certificate
-	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
-	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	serialNumber, err := newSerialNumber()
 	if err != nil {
-		log.Printf("Failed to generate the serial number: %s\n", err)
 		return err
 	}
 
+	dnsNames, ipAddresses := subjectAltNames(opts.Hosts)
+	commonName := "nginx"
+	if len(dnsNames) > 0 {
+		commonName = dnsNames[0]
+	}
+
+	// Only RSA keys are used for key encipherment
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, ok := priv.(*rsa.PrivateKey); ok {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
 	// Template the certificate with necessary values
+	// The signature algorithm is picked based on the key
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
 		Subject: pkix.Name{
 			Organization: []string{"Ghostwriter"},
-			CommonName:   "nginx",
+			CommonName:   commonName,
 		},
-		SignatureAlgorithm: x509.ECDSAWithSHA384,
-		PublicKeyAlgorithm: x509.ECDSA,
-		NotBefore:          notBefore,
-		NotAfter:           notAfter,
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
 
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		KeyUsage:              keyUsage,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
 	}
 
-	// Create the certificate using our private key and the template
-	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
-	if err != nil {
-		log.Printf("Failed to create certificate: %s\n", err)
-		return err
+	// Sign the certificate with our private key, or with the CA's key
+	parent, signer := &template, priv
+	if opts.UseCA {
+		caCert, caKey, err := LoadOrCreateCA(filepath.Dir(certPath))
+		if err != nil {
+			return err
+		}
+		parent, signer = caCert, caKey
 	}
 
-	certOut, err := os.Create(certPath)
+	// Create the certificate using the template
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, parent, priv.Public(), signer)
 	if err != nil {
-		log.Printf("Failed to open %s for writing: %s\n", certPath, err)
-		return err
+		return fmt.Errorf("failed to create certificate: %w", err)
 	}
-	pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
-	certOut.Close()
-
-	keyOut, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		log.Printf("Failed to open %s for writing: %s/n", keyPath, err)
+	if err := writeCertificatePair(certPath, keyPath, [][]byte{derBytes}, priv); err != nil {
 		return err
 	}
-	marshalKey, err := x509.MarshalECPrivateKey(priv)
+	fmt.Printf("[+] Successfully generated new TLS/SSL certificates valid for %d days for: %s\n", days, strings.Join(append(dnsNames, ipStrings(ipAddresses)...), ", "))
+
+	return nil
+}
+
+// Generates a random serial number for a certificate
+func newSerialNumber() (*big.Int, error) {
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
-		log.Printf("Unable to marshal ECDSA private key: %v\n", err)
-		return err
+		return nil, fmt.Errorf("failed to generate the serial number: %w", err)
 	}
-	pem.Encode(keyOut, &pem.Block{Type: "EC PRIVATE KEY", Bytes: marshalKey})
-	keyOut.Close()
-	fmt.Printf("[+] Successfully generated new TLS/SSL certificates\n")
+	return serialNumber, nil
+}
 
-	return nil
+func ipStrings(ips []net.IP) []string {
+	out := []string{}
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
 }
 
 // GenerateCertificatePackage generate TLS certificates and Diffie-Helman parameters file using Go.
-func GenerateCertificatePackage(path string) error {
+// The default options are used if `opts` is nil.
+func GenerateCertificatePackage(path string, opts *CertificateOptions) error {
+	if opts == nil {
+		opts = &CertificateOptions{}
+	}
+
 	// Ensure the ``ssl`` directory exists to receive the keys
 	sslPath := filepath.Join(path, "ssl")
 	if !DirExists(sslPath) {
 		err := os.MkdirAll(sslPath, os.ModePerm)
 		if err != nil {
-			log.Fatalf("Failed to make the `ssl` directory")
+			return fmt.Errorf("failed to make the `ssl` directory: %w", err)
 		}
 		fmt.Println("[+] Successfully made the `ssl` directory")
 	}
 
 	fmt.Println("[*] Generating new `ghostwriter.crt` and `ghostwriter.key` files")
-	certErr := generateCertificates(path)
+	certErr := generateCertificates(path, opts)
 	if certErr != nil {
 		fmt.Printf("[!] Failed to generate TLS/SSL certificate files: %s\n", certErr)
+		certErr = fmt.Errorf("failed to generate TLS/SSL certificate files: %w", certErr)
 	}
 
-	dhErr := writeDHParams(sslPath, "dhparam")
+	dhErr := WriteDHParams(sslPath, &opts.DHParams)
 	if dhErr != nil {
 		fmt.Printf("[!] Failed to generate Diffie-Helman parameters: %s\n", dhErr)
+		dhErr = fmt.Errorf("failed to generate Diffie-Helman parameters: %w", dhErr)
 	}
 
-	return nil
+	return errors.Join(certErr, dhErr)
 }
 
 // PrepareSettingsDirectory creates the settings directory for custom Django configuration files
@@ -17,7 +17,7 @@ func TestGenerateCertificatePackage(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	t.Log("Testing `GenerateCertificatePackage()` and generating DH parameters can take several minutes...")
-	GenerateCertificatePackage(tempDir)
+	GenerateCertificatePackage(tempDir, nil)
 
 	// Paths we expect to exist after generating the certificate package
 	sslDir := filepath.Join(tempDir, "ssl")
@@ -33,3 +33,64 @@ func TestGenerateCertificatePackage(t *testing.T) {
 	assert.True(t, FileExists(keyPath), "Expected `ghostwriter.key` file to exist")
 	assert.True(t, FileExists(crtPath), "Expected `ghostwriter.crt` file to exist")
 }
+
+func TestSubjectAltNames(t *testing.T) {
+	dnsNames, ipAddresses := subjectAltNames([]string{"localhost", "127.0.0.1", "*", ".example.com", "::1", "localhost", ""})
+	assert.Equal(t, []string{"localhost", "example.com", "*.example.com"}, dnsNames, "Expected leading dots to cover the domain and its subdomains")
+	assert.Equal(t, []string{"127.0.0.1", "::1"}, ipStrings(ipAddresses))
+}
+
+func TestWriteCertificatePairKeepsMatchingPair(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "ghostwriter.key")
+	certPath := filepath.Join(dir, "ghostwriter.crt")
+	assert.NoError(t, os.WriteFile(keyPath, []byte("previous key"), 0600))
+	// A directory can't be replaced with a file, so renaming the certificate fails
+	assert.NoError(t, os.Mkdir(certPath, 0700))
+
+	key, err := KeyECDSAP256.generateKey()
+	assert.NoError(t, err)
+	assert.Error(t, writeCertificatePair(certPath, keyPath, [][]byte{[]byte("certificate")}, key))
+	data, err := os.ReadFile(keyPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "previous key", string(data), "Expected the previous key to be restored")
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2, "Expected the temporary files to be removed")
+}
+
+func TestGenerateCertificatesOptions(t *testing.T) {
+	defer quietTests()()
+	tempDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(tempDir, "ssl"), 0700))
+
+	expectedKeyTypes := map[KeyAlgorithm]string{
+		KeyECDSAP256: "ECDSA P-256",
+		KeyECDSAP384: "ECDSA P-384",
+		KeyRSA2048:   "RSA 2048",
+		KeyEd25519:   "Ed25519",
+	}
+	for algorithm, keyType := range expectedKeyTypes {
+		opts := &CertificateOptions{Hosts: []string{"ghostwriter.local", "10.0.0.5"}, Days: 30, KeyAlgorithm: algorithm, Force: true}
+		assert.NoError(t, generateCertificates(tempDir, opts), "Expected %s certificate to be generated", algorithm)
+
+		status, err := GetCertificateStatus(tempDir)
+		assert.NoError(t, err)
+		assert.Equal(t, keyType, status.KeyType)
+		assert.True(t, status.KeyMatches, "Expected the %s key to match the certificate", algorithm)
+		assert.Equal(t, []string{"ghostwriter.local"}, status.DNSNames)
+		assert.Equal(t, []string{"10.0.0.5"}, status.IPAddresses)
+		assert.Equal(t, 29, status.DaysRemaining)
+	}
+
+	// Each forced replacement after the first archived the previous pair
+	archived, err := os.ReadDir(filepath.Join(tempDir, "ssl", "archive"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2*(len(expectedKeyTypes)-1), len(archived), "Expected every replaced pair to be archived")
+
+	// Without --force, the existing certificate is kept
+	before, _ := os.ReadFile(filepath.Join(tempDir, "ssl", "ghostwriter.crt"))
+	assert.NoError(t, generateCertificates(tempDir, &CertificateOptions{}))
+	after, _ := os.ReadFile(filepath.Join(tempDir, "ssl", "ghostwriter.crt"))
+	assert.Equal(t, before, after, "Expected the certificate not to be replaced without `Force`")
+}
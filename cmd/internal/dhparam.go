@@ -0,0 +1,341 @@
+package internal
+
+// Diffie-Hellman parameters for Nginx's DHE cipher suites
+// The RFC 7919 groups are used by default because generating new parameters can take minutes on small VMs.
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Luzifer/go-dhparam"
+)
+
+const (
+	// Default size of generated DH parameters
+	defaultDHParamBits = 2048
+	// Smallest DH parameters accepted, as recommended by NIST SP 800-57
+	minDHParamBits = 2048
+	// Largest DH parameters that can be generated in a reasonable time
+	maxDHParamBits = 8192
+	// Number of Miller-Rabin rounds when checking that generated or existing parameters are safe primes
+	dhPrimeRounds = 20
+)
+
+// Source of the DH parameters: one of the RFC 7919 groups or newly generated parameters
+type DHGroup string
+
+const (
+	DHGroupFFDHE2048 DHGroup = "ffdhe2048"
+	DHGroupFFDHE3072 DHGroup = "ffdhe3072"
+	DHGroupFFDHE4096 DHGroup = "ffdhe4096"
+	DHGroupGenerate  DHGroup = "generate"
+)
+
+var AllDHGroups = []string{string(DHGroupFFDHE2048), string(DHGroupFFDHE3072), string(DHGroupFFDHE4096), string(DHGroupGenerate)}
+
+// cobra pvalue.Value implementation for argument parsing
+func (e *DHGroup) String() string {
+	return string(*e)
+}
+func (e *DHGroup) Set(v string) error {
+	if !slices.Contains(AllDHGroups, v) {
+		return errors.New("must be one of: " + strings.Join(AllDHGroups, ", "))
+	}
+	*e = DHGroup(v)
+	return nil
+}
+func (e *DHGroup) Type() string {
+	return "DHGroup"
+}
+
+// Predefined groups from RFC 7919, Appendix A, encoded as PKCS #3 DH parameters with a generator of 2
+var ffdheGroups = map[DHGroup]string{
+	DHGroupFFDHE2048: `-----BEGIN DH PARAMETERS-----
+MIIBCAKCAQEA//////////+t+FRYortKmq/cViAnPTzx2LnFg84tNpWp4TZBFGQz
++8yTnc4kmz75fS/jY2MMddj2gbICrsRhetPfHtXV/WVhJDP1H18GbtCFY2VVPe0a
+87VXE15/V8k1mE8McODmi3fipona8+/och3xWKE2rec1MKzKT0g6eXq8CrGCsyT7
+YdEIqUuyyOP7uWrat2DX9GgdT0Kj3jlN9K5W7edjcrsZCwenyO4KbXCeAvzhzffi
+7MA0BM0oNC9hkXL+nOmFg/+OTxIy7vKBg8P+OxtMb61zO7X8vC7CIAXFjvGDfRaD
+ssbzSibBsu/6iGtCOGEoXJf//////////wIBAg==
+-----END DH PARAMETERS-----
+`,
+	DHGroupFFDHE3072: `-----BEGIN DH PARAMETERS-----
+MIIBiAKCAYEA//////////+t+FRYortKmq/cViAnPTzx2LnFg84tNpWp4TZBFGQz
++8yTnc4kmz75fS/jY2MMddj2gbICrsRhetPfHtXV/WVhJDP1H18GbtCFY2VVPe0a
+87VXE15/V8k1mE8McODmi3fipona8+/och3xWKE2rec1MKzKT0g6eXq8CrGCsyT7
+YdEIqUuyyOP7uWrat2DX9GgdT0Kj3jlN9K5W7edjcrsZCwenyO4KbXCeAvzhzffi
+7MA0BM0oNC9hkXL+nOmFg/+OTxIy7vKBg8P+OxtMb61zO7X8vC7CIAXFjvGDfRaD
+ssbzSibBsu/6iGtCOGEfz9zeNVs7ZRkDW7w09N75nAI4YbRvydbmyQd62R0mkff3
+7lmMsPrBhtkcrv4TCYUTknC0EwyTvEN5RPT9RFLi103TZPLiHnH1S/9croKrnJ32
+nuhtK8UiNjoNq8Uhl5sN6todv5pC1cRITgq80Gv6U93vPBsg7j/VnXwl5B0rZsYu
+N///////////AgEC
+-----END DH PARAMETERS-----
+`,
+	DHGroupFFDHE4096: `-----BEGIN DH PARAMETERS-----
+MIICCAKCAgEA//////////+t+FRYortKmq/cViAnPTzx2LnFg84tNpWp4TZBFGQz
++8yTnc4kmz75fS/jY2MMddj2gbICrsRhetPfHtXV/WVhJDP1H18GbtCFY2VVPe0a
+87VXE15/V8k1mE8McODmi3fipona8+/och3xWKE2rec1MKzKT0g6eXq8CrGCsyT7
+YdEIqUuyyOP7uWrat2DX9GgdT0Kj3jlN9K5W7edjcrsZCwenyO4KbXCeAvzhzffi
+7MA0BM0oNC9hkXL+nOmFg/+OTxIy7vKBg8P+OxtMb61zO7X8vC7CIAXFjvGDfRaD
+ssbzSibBsu/6iGtCOGEfz9zeNVs7ZRkDW7w09N75nAI4YbRvydbmyQd62R0mkff3
+7lmMsPrBhtkcrv4TCYUTknC0EwyTvEN5RPT9RFLi103TZPLiHnH1S/9croKrnJ32
+nuhtK8UiNjoNq8Uhl5sN6todv5pC1cRITgq80Gv6U93vPBsg7j/VnXwl5B0rZp4e
+8W5vUsMWTfT7eTDp5OWIV7asfV9C1p9tGHdjzx1VA0AEh/VbpX4xzHpxNciG77Qx
+iu1qHgEtnmgyqQdgCpGBMMRtx3j5ca0AOAkpmaMzy4t6Gh25PXFAADwqTs6p+Y0K
+zAqCkc3OyX3Pjsm1Wn+IpGtNtahR9EGC4caKAH5eZV9q//////////8CAQI=
+-----END DH PARAMETERS-----
+`,
+}
+
+// DHParamOptions controls the DH parameters written by `WriteDHParams`.
+type DHParamOptions struct {
+	// Predefined group to use or `DHGroupGenerate`, ffdhe2048 if empty
+	Group DHGroup
+	// Size of generated parameters, 2048 if zero
+	Bits int
+	// Replace an existing file even if its parameters are valid
+	Force bool
+}
+
+// DHParamInfo describes a valid DH parameters file.
+type DHParamInfo struct {
+	// Size of the prime
+	Bits int `json:"bits"`
+	// Name of the RFC 7919 group, or empty for generated parameters
+	Group DHGroup `json:"group"`
+}
+
+// PKCS #3 DH parameters, as found in "DH PARAMETERS" PEM blocks
+type dhParameters struct {
+	P *big.Int
+	G *big.Int
+	// Private value length, which OpenSSL may include
+	Length int `asn1:"optional"`
+}
+
+// Parses the first DH parameters in PEM data
+func parseDHParams(data []byte) (*dhParameters, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "DH PARAMETERS" {
+		return nil, errors.New("no DH parameters found")
+	}
+	var params dhParameters
+	if _, err := asn1.Unmarshal(block.Bytes, &params); err != nil {
+		return nil, err
+	}
+	if params.P == nil || params.P.Sign() <= 0 || params.G == nil {
+		return nil, errors.New("invalid prime or generator")
+	}
+	return &params, nil
+}
+
+// Reads the DH parameters in a PEM file
+func readDHParams(path string) (*dhParameters, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	params, err := parseDHParams(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the DH parameters in %s: %w", path, err)
+	}
+	return params, nil
+}
+
+// Gets the name of the RFC 7919 group with the same prime and generator, or an empty string
+func (this *dhParameters) group() DHGroup {
+	for name, data := range ffdheGroups {
+		group, err := parseDHParams([]byte(data))
+		if err == nil && group.P.Cmp(this.P) == 0 && group.G.Cmp(this.G) == 0 {
+			return name
+		}
+	}
+	return ""
+}
+
+// Checks that the prime is large enough and a safe prime, i.e., (p-1)/2 is also prime, and that the
+// generator is in range. The RFC 7919 groups are known to be safe, so their primes aren't tested again.
+func (this *dhParameters) validate() (*DHParamInfo, error) {
+	info := &DHParamInfo{Bits: this.P.BitLen(), Group: this.group()}
+	if info.Bits < minDHParamBits {
+		return info, fmt.Errorf("the prime is %d bits, but at least %d bits are needed", info.Bits, minDHParamBits)
+	}
+	pMinusOne := new(big.Int).Sub(this.P, big.NewInt(1))
+	if this.G.Cmp(big.NewInt(2)) < 0 || this.G.Cmp(pMinusOne) >= 0 {
+		return info, fmt.Errorf("the generator %s is not between 2 and p-2", this.G)
+	}
+	if info.Group != "" {
+		return info, nil
+	}
+	if !this.P.ProbablyPrime(dhPrimeRounds) {
+		return info, errors.New("the modulus is not prime")
+	}
+	if !new(big.Int).Rsh(this.P, 1).ProbablyPrime(dhPrimeRounds) {
+		return info, errors.New("the prime is not a safe prime")
+	}
+	return info, nil
+}
+
+// ValidateDHParams checks that a DH parameters file is usable by Nginx: it has a safe prime of at least
+// 2048 bits and a valid generator.
+func ValidateDHParams(path string) (*DHParamInfo, error) {
+	params, err := readDHParams(path)
+	if err != nil {
+		return nil, err
+	}
+	return params.validate()
+}
+
+// Generates new DH parameters in the background, calling `progress` as candidate primes are found
+// Returns the context's error if it's cancelled before the parameters are found.
+func generateDHParams(ctx context.Context, bits int, progress dhparam.GeneratorCallback) ([]byte, error) {
+	type result struct {
+		dh  *dhparam.DH
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		dh, err := dhparam.GenerateWithContext(ctx, bits, dhparam.GeneratorTwo, progress)
+		done <- result{dh, err}
+	}()
+
+	// The generator only checks the context between candidates, so don't wait for it
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-done:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return result.dh.ToPEM()
+	}
+}
+
+// Counts the candidate primes tested while generating DH parameters
+type dhProgress struct {
+	candidates atomic.Int64
+	primes     atomic.Int64
+}
+
+// Callback function for "go-dhparam".
+func (this *dhProgress) callback(r dhparam.GeneratorResult) {
+	switch r {
+	case dhparam.GeneratorFoundPossiblePrime:
+		this.candidates.Add(1)
+	case dhparam.GeneratorFirstConfirmation:
+		this.primes.Add(1)
+	}
+}
+
+// Shows the progress of the generation until `done` is closed
+// Terminals get a bar that's redrawn in place; otherwise, a line is printed every 30 seconds.
+func (this *dhProgress) show(bits int, done <-chan struct{}) {
+	out := os.Stdout
+	interactive := false
+	if info, err := out.Stat(); err == nil {
+		interactive = info.Mode()&os.ModeCharDevice != 0
+	}
+	interval := 30 * time.Second
+	if interactive {
+		interval = 200 * time.Millisecond
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	const width = 20
+	for tick := 0; ; tick++ {
+		select {
+		case <-done:
+			if interactive {
+				fmt.Fprintln(out)
+			}
+			return
+		case <-ticker.C:
+		}
+		elapsed := time.Since(start).Truncate(time.Second)
+		status := fmt.Sprintf("%s elapsed, %d candidates tested, %d primes found", elapsed, this.candidates.Load(), this.primes.Load())
+		if !interactive {
+			fmt.Fprintf(out, "[*] Still generating %d-bit DH parameters: %s\n", bits, status)
+			continue
+		}
+		// The total time can't be predicted, so the bar bounces back and forth
+		position := tick % (2 * (width - 3))
+		if position >= width-3 {
+			position = 2*(width-3) - position
+		}
+		bar := strings.Repeat(" ", position) + "===" + strings.Repeat(" ", width-3-position)
+		fmt.Fprintf(out, "\r[%s] %s (press Ctrl+C to cancel) ", bar, status)
+	}
+}
+
+// WriteDHParams writes the DH parameters to `dhparam.pem` in the `ssl` directory. An existing file
+// is kept if its parameters are valid, unless `opts.Force` is set. Generating new parameters can be
+// cancelled with Ctrl+C.
+func WriteDHParams(sslPath string, opts *DHParamOptions) error {
+	fileName := filepath.Join(sslPath, "dhparam.pem")
+	if FileExists(fileName) && !opts.Force {
+		info, err := ValidateDHParams(fileName)
+		if err == nil {
+			fmt.Printf("[*] Keeping the existing %d-bit DH parameters in %s\n", info.Bits, fileName)
+			return nil
+		}
+		fmt.Printf("[!] Replacing the DH parameters in %s because they're not valid: %s\n", fileName, err)
+	}
+
+	group := opts.Group
+	if group == "" {
+		group = DHGroupFFDHE2048
+	}
+	var data []byte
+	if group == DHGroupGenerate {
+		bits := opts.Bits
+		if bits == 0 {
+			bits = defaultDHParamBits
+		}
+		if bits < minDHParamBits || bits > maxDHParamBits {
+			return fmt.Errorf("the DH parameters must be between %d and %d bits", minDHParamBits, maxDHParamBits)
+		}
+
+		fmt.Printf("[*] Generating new %d-bit DH parameters (this could take a few minutes)\n", bits)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		progress := &dhProgress{}
+		done := make(chan struct{})
+		shown := make(chan struct{})
+		go func() {
+			progress.show(bits, done)
+			close(shown)
+		}()
+		var err error
+		data, err = generateDHParams(ctx, bits, progress.callback)
+		close(done)
+		<-shown
+		if errors.Is(err, context.Canceled) {
+			return errors.New("generating the DH parameters was cancelled")
+		} else if err != nil {
+			return err
+		}
+	} else {
+		pemData, found := ffdheGroups[group]
+		if !found {
+			return fmt.Errorf("unknown DH group: %s", group)
+		}
+		fmt.Printf("[*] Using the %s group from RFC 7919 for the DH parameters\n", group)
+		data = []byte(pemData)
+	}
+
+	fmt.Printf("[+] Writing DH parameters to %s\n", fileName)
+	return writeFileAtomic(fileName, data, 0644)
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Computes an RFC 7919 prime: p = 2^b - 2^{b-64} + {[2^{b-130} e] + X} * 2^64 - 1
+func rfc7919Prime(bits uint, x int64) *big.Int {
+	// Sum the series for e with enough precision for the largest group
+	const precision = 5000
+	e := new(big.Float).SetPrec(precision).SetInt64(1)
+	term := new(big.Float).SetPrec(precision).SetInt64(1)
+	for k := int64(1); k < 800; k++ {
+		term.Quo(term, new(big.Float).SetPrec(precision).SetInt64(k))
+		e.Add(e, term)
+	}
+	scaled, _ := new(big.Float).SetPrec(precision).SetMantExp(e, int(bits-130)).Int(nil)
+
+	one := big.NewInt(1)
+	p := new(big.Int).Lsh(one, bits)
+	p.Sub(p, new(big.Int).Lsh(one, bits-64))
+	scaled.Add(scaled, big.NewInt(x))
+	p.Add(p, scaled.Lsh(scaled, 64))
+	return p.Sub(p, one)
+}
+
+func TestFFDHEGroups(t *testing.T) {
+	expected := map[DHGroup]*big.Int{
+		DHGroupFFDHE2048: rfc7919Prime(2048, 560316),
+		DHGroupFFDHE3072: rfc7919Prime(3072, 2625351),
+		DHGroupFFDHE4096: rfc7919Prime(4096, 5736041),
+	}
+	for group, prime := range expected {
+		params, err := parseDHParams([]byte(ffdheGroups[group]))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, prime.Cmp(params.P), "Expected the %s prime to match RFC 7919", group)
+		assert.Equal(t, int64(2), params.G.Int64())
+		assert.Equal(t, group, params.group())
+	}
+}
+
+// Writes DH parameters with the prime and generator to a PEM file
+func writeTestDHParams(t *testing.T, path string, p *big.Int, g int64) {
+	der, err := asn1.Marshal(dhParameters{P: p, G: big.NewInt(g)})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "DH PARAMETERS", Bytes: der}), 0644))
+}
+
+func TestValidateDHParams(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dhparam.pem")
+
+	assert.NoError(t, os.WriteFile(path, []byte(ffdheGroups[DHGroupFFDHE3072]), 0644))
+	info, err := ValidateDHParams(path)
+	assert.NoError(t, err)
+	assert.Equal(t, &DHParamInfo{Bits: 3072, Group: DHGroupFFDHE3072}, info)
+
+	ffdhe2048, err := parseDHParams([]byte(ffdheGroups[DHGroupFFDHE2048]))
+	assert.NoError(t, err)
+	writeTestDHParams(t, path, ffdhe2048.P, 1)
+	_, err = ValidateDHParams(path)
+	assert.ErrorContains(t, err, "generator")
+
+	// A prime that isn't safe: 2^2203 - 1 is a Mersenne prime, but 2^2202 - 1 is divisible by 3
+	mersenne := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 2203), big.NewInt(1))
+	writeTestDHParams(t, path, mersenne, 2)
+	info, err = ValidateDHParams(path)
+	assert.ErrorContains(t, err, "not a safe prime")
+	assert.Equal(t, 2203, info.Bits)
+
+	composite := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 2047), big.NewInt(1))
+	writeTestDHParams(t, path, composite, 2)
+	_, err = ValidateDHParams(path)
+	assert.ErrorContains(t, err, "not prime")
+
+	// 2^127 - 1 is prime, but too small
+	writeTestDHParams(t, path, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1)), 2)
+	_, err = ValidateDHParams(path)
+	assert.ErrorContains(t, err, "at least 2048 bits")
+
+	assert.NoError(t, os.WriteFile(path, []byte("not a PEM file"), 0644))
+	_, err = ValidateDHParams(path)
+	assert.Error(t, err)
+}
+
+func TestWriteDHParams(t *testing.T) {
+	defer quietTests()()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dhparam.pem")
+
+	assert.NoError(t, WriteDHParams(dir, &DHParamOptions{}))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, ffdheGroups[DHGroupFFDHE2048], string(data), "Expected ffdhe2048 by default")
+
+	// Valid parameters are kept unless forced
+	assert.NoError(t, WriteDHParams(dir, &DHParamOptions{Group: DHGroupFFDHE4096}))
+	info, err := ValidateDHParams(path)
+	assert.NoError(t, err)
+	assert.Equal(t, DHGroupFFDHE2048, info.Group)
+	assert.NoError(t, WriteDHParams(dir, &DHParamOptions{Group: DHGroupFFDHE4096, Force: true}))
+	info, err = ValidateDHParams(path)
+	assert.NoError(t, err)
+	assert.Equal(t, DHGroupFFDHE4096, info.Group)
+
+	// Invalid parameters are replaced
+	assert.NoError(t, os.WriteFile(path, []byte("invalid"), 0644))
+	assert.NoError(t, WriteDHParams(dir, &DHParamOptions{Group: DHGroupFFDHE3072}))
+	info, err = ValidateDHParams(path)
+	assert.NoError(t, err)
+	assert.Equal(t, DHGroupFFDHE3072, info.Group)
+
+	assert.Error(t, WriteDHParams(dir, &DHParamOptions{Group: DHGroupGenerate, Bits: 1024, Force: true}), "Expected an error for small parameters")
+}
+
+func TestGenerateDHParams(t *testing.T) {
+	progress := &dhProgress{}
+	data, err := generateDHParams(context.Background(), 256, progress.callback)
+	assert.NoError(t, err)
+	params, err := parseDHParams(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 256, params.P.BitLen())
+	assert.True(t, params.P.ProbablyPrime(dhPrimeRounds) && new(big.Int).Rsh(params.P, 1).ProbablyPrime(dhPrimeRounds), "Expected a safe prime")
+	assert.Positive(t, progress.candidates.Load())
+	assert.Positive(t, progress.primes.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = generateDHParams(ctx, 4096, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,177 @@
+package internal
+
+// Comparing the configuration with the defaults, another environment file, or the running containers
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Kinds of differences found by `DiffConfig`
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// ConfigChange is a setting that differs between the current configuration and another one.
+type ConfigChange struct {
+	Key string `json:"key"`
+	// One of `ChangeAdded`, `ChangeRemoved`, or `ChangeChanged`
+	Change string `json:"change"`
+	// Value in the current configuration, empty if the setting was removed
+	Current string `json:"current"`
+	// Value in the other configuration, empty if the setting was added
+	Other string `json:"other"`
+	// Running services with the other value, when comparing with the containers
+	Services []string `json:"services,omitempty"`
+}
+
+// DiffConfig compares two sets of values keyed by setting.
+// Settings only in `current` are added, and settings only in `other` are removed.
+func DiffConfig(current, other map[string]string) []ConfigChange {
+	changes := []ConfigChange{}
+	for key, value := range current {
+		otherValue, found := other[key]
+		if !found {
+			changes = append(changes, ConfigChange{Key: key, Change: ChangeAdded, Current: value})
+		} else if otherValue != value {
+			changes = append(changes, ConfigChange{Key: key, Change: ChangeChanged, Current: value, Other: otherValue})
+		}
+	}
+	for key, value := range other {
+		if _, found := current[key]; !found {
+			changes = append(changes, ConfigChange{Key: key, Change: ChangeRemoved, Other: value})
+		}
+	}
+	slices.SortFunc(changes, func(a, b ConfigChange) int { return strings.Compare(a.Key, b.Key) })
+	return changes
+}
+
+// Values gets every value keyed by setting, leaving out aliases.
+func (this *GWEnvironment) Values() map[string]string {
+	values := map[string]string{}
+	for _, entry := range this.GetAll() {
+		if _, alias := settingAliases[entry.Key]; !alias {
+			values[entry.Key] = entry.Val
+		}
+	}
+	return values
+}
+
+// DefaultValues gets the default value of every setting, leaving out generated passwords,
+// which are different for every installation.
+func DefaultValues() map[string]string {
+	values := map[string]string{}
+	for _, setting := range settings {
+		if setting.PasswordLength == 0 {
+			values[setting.Key] = fmt.Sprint(setting.Default)
+		}
+	}
+	return values
+}
+
+// DiffDefaults compares the configuration with the defaults, including the profile of the mode.
+// Generated passwords are left out, and unknown settings are reported as added.
+func (this *GWEnvironment) DiffDefaults() []ConfigChange {
+	current := this.Values()
+	for _, setting := range settings {
+		if setting.PasswordLength > 0 {
+			delete(current, setting.Key)
+		}
+	}
+	defaults := DefaultValues()
+	for key, value := range this.profile {
+		defaults[key] = value
+	}
+	return DiffConfig(current, defaults)
+}
+
+// Reads the values in an environment file at `path`, with the values of the mode profile and the
+// defaults for the missing ones
+func readEnvValues(path string, profile map[string]string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	values, err := parseEnvValues(data, profile)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// ReadEnvValues reads the values in an environment file at `path`, keyed by setting. Settings
+// missing from the file get their defaults, except for generated passwords.
+func ReadEnvValues(path string) (map[string]string, error) {
+	return readEnvValues(path, nil)
+}
+
+// DiffFile compares the configuration with the environment file at `path`, applying the same mode
+// profile to both. Passwords generated because they're missing from the environment file are left
+// out, since they're different every time it's read.
+func (this *GWEnvironment) DiffFile(path string) ([]ConfigChange, error) {
+	other, err := readEnvValues(path, this.profile)
+	if err != nil {
+		return nil, err
+	}
+	current := this.Values()
+	for _, setting := range settings {
+		if _, saved := this.fileValue(setting.Key); setting.PasswordLength > 0 && !saved {
+			delete(current, setting.Key)
+		}
+	}
+	return DiffConfig(current, other), nil
+}
+
+// DiffRunning compares the configuration with the environment of the running containers, to find
+// settings that were changed without recreating the services that use them. Settings a container
+// doesn't have as an environment variable are left out.
+func (this *DockerInterface) DiffRunning() ([]ConfigChange, error) {
+	projectName, err := this.GetComposeProjectName()
+	if err != nil {
+		return nil, err
+	}
+
+	current := this.Env.Values()
+	changes := map[string]*ConfigChange{}
+	running := 0
+	for _, service := range allServices {
+		// Containers that don't exist are treated as stopped, the same as when restarting Nginx
+		details, err := this.Runtime.Inspect(fmt.Sprintf("%s-%s-1", projectName, service))
+		if err != nil || !details.Running {
+			continue
+		}
+		running++
+
+		containerEnv := map[string]string{}
+		for _, variable := range details.Env {
+			name, value, _ := strings.Cut(variable, "=")
+			containerEnv[name] = value
+		}
+		for _, setting := range settings {
+			value, found := containerEnv[strings.ToUpper(setting.Key)]
+			if !found || !slices.Contains(setting.Services, service) || value == current[setting.Key] {
+				continue
+			}
+			change, found := changes[setting.Key]
+			if !found {
+				change = &ConfigChange{Key: setting.Key, Change: ChangeChanged, Current: current[setting.Key], Other: value}
+				changes[setting.Key] = change
+			}
+			change.Services = append(change.Services, service)
+		}
+	}
+	if running == 0 {
+		return nil, fmt.Errorf("no Ghostwriter containers are running")
+	}
+
+	result := []ConfigChange{}
+	for _, key := range slices.Sorted(maps.Keys(changes)) {
+		result = append(result, *changes[key])
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffConfig(t *testing.T) {
+	changes := DiffConfig(
+		map[string]string{"a": "1", "b": "2", "c": "3"},
+		map[string]string{"b": "2", "c": "4", "d": "5"},
+	)
+	assert.Equal(t, []ConfigChange{
+		{Key: "a", Change: ChangeAdded, Current: "1"},
+		{Key: "c", Change: ChangeChanged, Current: "3", Other: "4"},
+		{Key: "d", Change: ChangeRemoved, Other: "5"},
+	}, changes)
+	assert.Empty(t, DiffConfig(map[string]string{"a": "1"}, map[string]string{"a": "1"}))
+}
+
+func TestDiffDefaultsAndFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DJANGO_PORT='8001'\nSMTP_HOST='mail'\nHASURA_GRAPHQL_ADMIN_SECRET='admin'\n"), 0600))
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []ConfigChange{
+		{Key: "django_port", Change: ChangeChanged, Current: "8001", Other: "8000"},
+		{Key: "smtp_host", Change: ChangeAdded, Current: "mail"},
+	}, env.DiffDefaults(), "Expected generated passwords to be left out")
+
+	other := filepath.Join(t.TempDir(), "other.env")
+	assert.NoError(t, os.WriteFile(other, []byte("DJANGO_PORT='8001'\nDJANGO_DATE_FORMAT='Y M d'\nHASURA_PASSWORD='admin'\n"), 0600))
+	expected := []ConfigChange{
+		{Key: "django_date_format", Change: ChangeChanged, Current: "d M Y", Other: "Y M d"},
+		{Key: "smtp_host", Change: ChangeAdded, Current: "mail"},
+	}
+	changes, err := env.DiffFile(other)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, changes, "Expected aliases to be compared with the settings they point to and generated passwords to be left out")
+	reread, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	changes, err = reread.DiffFile(other)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, changes, "Expected the same result every time")
+
+	assert.NoError(t, os.WriteFile(other, []byte("POSTGRES_PASSWORD='secret'\nHASURA_PASSWORD='admin'\n"), 0600))
+	changes, err = env.DiffFile(other)
+	assert.NoError(t, err)
+	assert.Contains(t, changes, ConfigChange{Key: "postgres_password", Change: ChangeRemoved, Other: "secret"}, "Expected passwords only in the other file to be removed")
+
+	_, err = env.DiffFile(other + ".missing")
+	assert.Error(t, err)
+}
+
+func TestDiffRunning(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newRotateDockerInterface(t)
+	runtime.Environments["ghostwriter-django-1"] = []string{"DJANGO_PORT=8000", "POSTGRES_PASSWORD=" + dockerInterface.Env.Get("postgres_password"), "PATH=/usr/bin"}
+	runtime.Environments["ghostwriter-graphql_engine-1"] = []string{"DJANGO_PORT=8000", "HASURA_GRAPHQL_LOG_LEVEL=warn"}
+	runtime.Environments["ghostwriter-nginx-1"] = []string{"DJANGO_PORT=8001"}
+
+	dockerInterface.Env.Set("django_port", "8001")
+	changes, err := dockerInterface.DiffRunning()
+	assert.NoError(t, err)
+	assert.Equal(t, []ConfigChange{
+		{Key: "django_port", Change: ChangeChanged, Current: "8001", Other: "8000", Services: []string{"graphql_engine", "django"}},
+	}, changes)
+
+	runtime.IsUp = false
+	_, err = dockerInterface.DiffRunning()
+	assert.ErrorContains(t, err, "no Ghostwriter containers are running")
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package internal
+
+import "syscall"
+
+// Gets the total and available bytes of the filesystem containing `path`
+func diskSpace(path string) (total uint64, free uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return uint64(stat.Blocks) * blockSize, uint64(stat.Bavail) * blockSize, nil
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package internal
+
+import "golang.org/x/sys/windows"
+
+// Gets the total and available bytes of the volume containing `path`
+func diskSpace(path string) (total uint64, free uint64, err error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var totalFree uint64
+	err = windows.GetDiskFreeSpaceEx(pathPtr, &free, &total, &totalFree)
+	return total, free, err
+}
@@ -1,18 +1,34 @@
 package internal
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// Gets the docker interface for the local development mode, or skips the test if no container
+// engine is available to run it
+func requireDockerInterface(t *testing.T) *DockerInterface {
+	t.Helper()
+	dockerInterface, err := GetDockerInterface(ModeLocalDev)
+	if errors.Is(err, ErrRuntimeMissing) || errors.Is(err, ErrDaemonUnavailable) || errors.Is(err, ErrComposeMissing) || errors.Is(err, ErrPermissionDenied) {
+		t.Skipf("No container engine available: %s", err)
+	}
+	require.NoError(t, err, "Expected `GetDockerInterface()` to return no error")
+	return dockerInterface
+}
+
 func TestEvaluateDockerComposeStatus(t *testing.T) {
 	// Mock the Ghostwriter Docker YAML files
-	localMockYaml := filepath.Join(GetCwdFromExe(), "local.yml")
+	cwd, err := GetCwdFromExe()
+	assert.NoError(t, err, "Expected `GetCwdFromExe()` to return no error")
+	localMockYaml := filepath.Join(cwd, "local.yml")
 	local, localErr := os.Create(localMockYaml)
-	prodMockYaml := filepath.Join(GetCwdFromExe(), "production.yml")
+	prodMockYaml := filepath.Join(cwd, "production.yml")
 	prod, prodErr := os.Create(prodMockYaml)
 	assert.Equal(t, nil, localErr, "Expected `os.Create()` to return no error")
 	assert.Equal(t, nil, prodErr, "Expected `os.Create()` to return no error")
@@ -22,7 +38,7 @@ func TestEvaluateDockerComposeStatus(t *testing.T) {
 	defer local.Close()
 	defer prod.Close()
 
-	GetDockerInterface(ModeLocalDev)
+	requireDockerInterface(t)
 }
 
 // Note: The media backup and restore functions (RunDockerComposeMediaBackup and RunDockerComposeMediaRestore)
@@ -32,7 +48,7 @@ func TestEvaluateDockerComposeStatus(t *testing.T) {
 func TestVerifyVolumeExists(t *testing.T) {
 	defer quietTests()()
 
-	dockerInterface := GetDockerInterface(ModeLocalDev)
+	dockerInterface := requireDockerInterface(t)
 
 	// Test with a volume that definitely doesn't exist
 	exists := dockerInterface.VerifyVolumeExists("nonexistent_test_volume_12345")
@@ -42,7 +58,7 @@ func TestVerifyVolumeExists(t *testing.T) {
 func TestListVolumes(t *testing.T) {
 	defer quietTests()()
 
-	dockerInterface := GetDockerInterface(ModeLocalDev)
+	dockerInterface := requireDockerInterface(t)
 
 	// List all volumes with a filter that shouldn't match anything unusual
 	volumes, err := dockerInterface.ListVolumes("test_filter_12345_nonexistent")
@@ -66,7 +82,7 @@ volumes:
 	err := os.WriteFile(composeFile, []byte(composeContent), 0644)
 	assert.NoError(t, err, "Expected to create test compose file")
 
-	dockerInterface := GetDockerInterface(ModeLocalDev)
+	dockerInterface := requireDockerInterface(t)
 	dockerInterface.Dir = tempDir
 	dockerInterface.ComposeFile = "test-compose.yml"
 
@@ -75,3 +91,23 @@ volumes:
 	assert.NoError(t, err, "Expected GetVolumeNameFromConfig to succeed")
 	assert.Contains(t, volumeName, "postgres_data", "Expected volume name to contain postgres_data")
 }
+
+func TestParseBackupList(t *testing.T) {
+	out := "2048 1684857300.5000000000 media_backup_2023_05_23T15_55_00.tar.gz\n" +
+		"1024 1684857259.1234567890 backup_2023_05_23T15_54_19.sql.gz\n\n"
+	backups, err := parseBackupList(out)
+	assert.NoError(t, err, "Expected `parseBackupList()` to return no error")
+	assert.Equal(t, 2, len(backups), "Expected two backup files")
+	assert.Equal(t, "backup_2023_05_23T15_54_19.sql.gz", backups[0].Name, "Expected backups to be sorted by creation time")
+	assert.Equal(t, "database", backups[0].Type)
+	assert.Equal(t, int64(1024), backups[0].Size)
+	assert.Equal(t, int64(1684857259), backups[0].Created.Unix())
+	assert.Equal(t, "media", backups[1].Type)
+
+	backups, err = parseBackupList("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(backups), "Expected no backups for empty output")
+
+	_, err = parseBackupList("not a listing")
+	assert.Error(t, err, "Expected an error for malformed output")
+}
@@ -0,0 +1,246 @@
+package internal
+
+// Reading and writing environment files while keeping their comments, ordering, and quoting
+//
+// The syntax follows Docker Compose's:
+//
+//	# Comments and blank lines
+//	KEY=unquoted value         # trailing comments after a space
+//	export KEY='single-quoted value, read literally'
+//	KEY="double-quoted value with \"escapes\", \$ signs, and\nline breaks"
+//
+// Quoted values can span several lines. Lines that can't be parsed are kept as they are.
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Names of variables, which are case-insensitive to Ghostwriter CLI
+var dotenvKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// A line of an environment file, or several lines for a quoted value that spans them
+type dotenvEntry struct {
+	// Text of the entry without the final line break, written back unchanged unless the value changes
+	raw string
+	// Key as written in the file, or empty for comments, blank lines, and lines that couldn't be parsed
+	key   string
+	value string
+	// Whether the line starts with `export`
+	export bool
+	// Quote character around the value, or zero if it's unquoted
+	quote byte
+	// Comment after the value, including the whitespace before it, kept when the value changes
+	comment string
+}
+
+// Parsed environment file
+type dotenvFile struct {
+	entries []*dotenvEntry
+}
+
+// Parses the contents of an environment file
+func parseDotenv(data []byte) (*dotenvFile, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	file := &dotenvFile{}
+	if text == "" {
+		return file, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		entry := &dotenvEntry{raw: lines[i]}
+		file.entries = append(file.entries, entry)
+
+		rest := strings.TrimSpace(lines[i])
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			continue
+		}
+		export := false
+		if after, found := strings.CutPrefix(rest, "export"); found && (strings.HasPrefix(after, " ") || strings.HasPrefix(after, "\t")) {
+			export = true
+			rest = strings.TrimLeft(after, " \t")
+		}
+		key, value, found := strings.Cut(rest, "=")
+		key = strings.TrimSpace(key)
+		if !found || !dotenvKeyPattern.MatchString(key) {
+			continue
+		}
+		entry.key = key
+		entry.export = export
+		value = strings.TrimLeft(value, " \t")
+
+		if value == "" || (value[0] != '\'' && value[0] != '"') {
+			// Unquoted values end at a comment
+			for i := 1; i < len(value); i++ {
+				if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+					trimmed := strings.TrimRight(value[:i], " \t")
+					entry.comment = value[len(trimmed):]
+					value = trimmed
+					break
+				}
+			}
+			entry.value = strings.TrimSpace(value)
+			continue
+		}
+
+		// Find the closing quote, which may be on a later line
+		entry.quote = value[0]
+		body := value[1:]
+		first := i
+		end := closingQuote(body, entry.quote)
+		for end < 0 {
+			if i+1 >= len(lines) {
+				return nil, fmt.Errorf("line %d: the value of %s is missing its closing %c", first+1, key, entry.quote)
+			}
+			i++
+			body += "\n" + lines[i]
+			end = closingQuote(body, entry.quote)
+		}
+		entry.raw = strings.Join(lines[first:i+1], "\n")
+		entry.value = body[:end]
+		if after := body[end+1:]; strings.HasPrefix(strings.TrimLeft(after, " \t"), "#") {
+			entry.comment = after
+		}
+		if entry.quote == '"' {
+			entry.value = unescapeDotenv(entry.value)
+		}
+	}
+	return file, nil
+}
+
+// Finds the index of the closing quote, skipping escaped characters in double-quoted values
+func closingQuote(body string, quote byte) int {
+	for i := 0; i < len(body); i++ {
+		switch {
+		case quote == '"' && body[i] == '\\':
+			i++
+		case body[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// Replaces the escape sequences in a double-quoted value
+func unescapeDotenv(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '\\', '"', '$', '\'':
+			b.WriteByte(value[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// Formats a value for the file, keeping the quote character used before when the value allows it.
+// Single quotes are used by default, since Compose reads the value literally.
+func formatDotenvValue(value string, quote byte) string {
+	if value == "" {
+		return ""
+	}
+	if quote == 0 && !strings.ContainsAny(value, " \t\n\r#'\"$\\") {
+		return value
+	}
+	if quote != '"' && !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// Gets the values keyed by lower-case key. The last value wins if a key is repeated.
+func (this *dotenvFile) Values() map[string]string {
+	values := map[string]string{}
+	for _, entry := range this.entries {
+		if entry.key != "" {
+			values[strings.ToLower(entry.key)] = entry.value
+		}
+	}
+	return values
+}
+
+// Sets the value of a key, which is case-insensitive. Only the last entry for the key is rewritten,
+// and only if the value changed. New keys are added to the end in upper case.
+func (this *dotenvFile) Set(key, value string) {
+	for i := len(this.entries) - 1; i >= 0; i-- {
+		entry := this.entries[i]
+		if !strings.EqualFold(entry.key, key) {
+			continue
+		}
+		if entry.value == value {
+			return
+		}
+		formatted := formatDotenvValue(value, entry.quote)
+		entry.value = value
+		entry.quote = 0
+		if formatted != "" && (formatted[0] == '\'' || formatted[0] == '"') {
+			entry.quote = formatted[0]
+		}
+		// An empty value is quoted so the comment isn't read as the value
+		if formatted == "" && entry.comment != "" {
+			formatted = "''"
+			entry.quote = '\''
+		}
+		entry.raw = entry.key + "=" + formatted + entry.comment
+		if entry.export {
+			entry.raw = "export " + entry.raw
+		}
+		return
+	}
+
+	formatted := formatDotenvValue(value, '\'')
+	entry := &dotenvEntry{key: strings.ToUpper(key), value: value, raw: strings.ToUpper(key) + "=" + formatted}
+	if formatted != "" {
+		entry.quote = formatted[0]
+	}
+	this.entries = append(this.entries, entry)
+}
+
+// Gets the value of the last entry for any of the keys, which are case-insensitive
+func (this *dotenvFile) Lookup(keys ...string) (string, bool) {
+	for i := len(this.entries) - 1; i >= 0; i-- {
+		entry := this.entries[i]
+		for _, key := range keys {
+			if entry.key != "" && strings.EqualFold(entry.key, key) {
+				return entry.value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Removes every entry for a key, which is case-insensitive
+func (this *dotenvFile) Delete(key string) {
+	this.entries = slices.DeleteFunc(this.entries, func(entry *dotenvEntry) bool {
+		return entry.key != "" && strings.EqualFold(entry.key, key)
+	})
+}
+
+// Gets the contents of the file
+func (this *dotenvFile) Bytes() []byte {
+	var b strings.Builder
+	for _, entry := range this.entries {
+		b.WriteString(entry.raw)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotenv(t *testing.T) {
+	data := "# Ghostwriter\r\n" +
+		"\n" +
+		"DJANGO_PORT=8000 # comment\n" +
+		"export SMTP_HOST='mail.example.com'\n" +
+		"DJANGO_SECRET_KEY=\"a \\\"quoted\\\" \\$value\"\n" +
+		"CERT='line one\n" +
+		"line two'\n" +
+		"not a variable\n" +
+		"EMPTY=\n" +
+		"DJANGO_PORT=8001\n"
+	doc, err := parseDotenv([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"django_port":       "8001",
+		"smtp_host":         "mail.example.com",
+		"django_secret_key": `a "quoted" $value`,
+		"cert":              "line one\nline two",
+		"empty":             "",
+	}, doc.Values(), "Expected the last value of a repeated key to win")
+	assert.Equal(t, "# Ghostwriter\n\nDJANGO_PORT=8000 # comment\nexport SMTP_HOST='mail.example.com'\nDJANGO_SECRET_KEY=\"a \\\"quoted\\\" \\$value\"\nCERT='line one\nline two'\nnot a variable\nEMPTY=\nDJANGO_PORT=8001\n",
+		string(doc.Bytes()), "Expected the file to be written back unchanged")
+
+	_, err = parseDotenv([]byte("A=1\nB='open\nC=2\n"))
+	assert.EqualError(t, err, "line 2: the value of B is missing its closing '")
+}
+
+func TestDotenvSet(t *testing.T) {
+	doc, err := parseDotenv([]byte("# Ports\nexport django_port=8000\nSMTP_HOST=\"mail\"\nKEY='a'\nKEY='b'\n"))
+	assert.NoError(t, err)
+
+	doc.Set("DJANGO_PORT", "8001")
+	doc.Set("smtp_host", "mail")
+	doc.Set("key", "it's")
+	doc.Set("new_value", "line one\nline two")
+	doc.Set("empty", "")
+	assert.Equal(t, "# Ports\n"+
+		"export django_port=8001\n"+
+		"SMTP_HOST=\"mail\"\n"+
+		"KEY='a'\n"+
+		"KEY=\"it's\"\n"+
+		"NEW_VALUE='line one\nline two'\n"+
+		"EMPTY=\n", string(doc.Bytes()))
+
+	doc.Set("smtp_host", `a "b" $c \d`)
+	doc.Set("new_value", "it's\nhere")
+	values := doc.Values()
+	reparsed, err := parseDotenv(doc.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, values, reparsed.Values(), "Expected escaped values to be read back the same")
+	assert.Equal(t, `a "b" $c \d`, values["smtp_host"])
+	assert.Equal(t, "it's\nhere", values["new_value"])
+}
+
+func TestDotenvSetKeepsComments(t *testing.T) {
+	doc, err := parseDotenv([]byte("SMTP_HOST=mail  # relay\nDJANGO_PORT='8000'\t# behind Nginx\nKEY=a#b\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"smtp_host": "mail", "django_port": "8000", "key": "a#b"}, doc.Values())
+
+	doc.Set("smtp_host", "mail.example.com")
+	doc.Set("django_port", "8001")
+	doc.Set("key", "c")
+	assert.Equal(t, "SMTP_HOST=mail.example.com  # relay\nDJANGO_PORT='8001'\t# behind Nginx\nKEY=c\n", string(doc.Bytes()))
+
+	doc.Set("smtp_host", "")
+	assert.Equal(t, "SMTP_HOST=''  # relay", strings.Split(string(doc.Bytes()), "\n")[0])
+	reparsed, err := parseDotenv(doc.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, doc.Values(), reparsed.Values(), "Expected the values to be read back the same")
+}
+
+func TestEnvironmentKeepsFormatting(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	original := "# Local changes\nexport DJANGO_PORT=8001\n\nSMTP_HOST=\"mail\" # relay\n"
+	assert.NoError(t, os.WriteFile(path, []byte(original), 0600))
+
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "8001", env.Get("django_port"))
+	assert.Equal(t, "mail", env.Get("smtp_host"))
+
+	env.Set("django_port", "8002")
+	assert.NoError(t, env.Save())
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# Local changes\nexport DJANGO_PORT=8002\n\nSMTP_HOST=\"mail\" # relay\n")
+
+	env, err = ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "8002", env.Get("django_port"))
+	assert.NoError(t, env.Save())
+	saved, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(saved), "Expected saving without changes to keep the file the same")
+}
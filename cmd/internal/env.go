@@ -5,10 +5,10 @@ package internal
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -17,36 +17,150 @@ import (
 type GWEnvironment struct {
 	filepath string
 	env      *viper.Viper
+	// Contents of the environment file, which keep its comments and formatting when saved
+	doc *dotenvFile
+	// Passphrase of the encrypted environment file, or nil if the file isn't encrypted
+	passphrase []byte
+	// Values read from external sources by `ResolveRefs`, keyed by setting
+	refs map[string]secretRef
+	// Name and values of the mode profile, which are never saved to the file
+	profileName string
+	profile     map[string]string
+	// Settings changed with `Set`, which are saved even if they match the mode profile
+	modified map[string]bool
+	// Values set with `Override` for the running command only, keyed by setting
+	overrides map[string]string
 }
 
 func ReadEnv(dir string) (*GWEnvironment, error) {
+	encryptedPath := filepath.Join(dir, EncryptedEnvFile)
 	filepath := filepath.Join(dir, ".env")
-	// Create empty file if it doesn't exist
-	file, err := os.OpenFile(filepath, os.O_RDWR|os.O_CREATE, 0600)
+
+	var data []byte
+	var passphrase []byte
+	if FileExists(encryptedPath) {
+		if FileExists(filepath) {
+			return nil, fmt.Errorf("both %s and %s exist; remove the one that is not in use", filepath, encryptedPath)
+		}
+		var err error
+		passphrase, err = GetPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := os.ReadFile(encryptedPath)
+		if err != nil {
+			return nil, err
+		}
+		data, err = decryptEnv(encrypted, passphrase)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Create empty file if it doesn't exist
+		file, err := os.OpenFile(filepath, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+		err = file.Close()
+		if err != nil {
+			return nil, err
+		}
+		data, err = os.ReadFile(filepath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := parseDotenv(data)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("could not parse %s: %w", filepath, err)
 	}
-	err = file.Close()
+	dropLegacyModeValues(doc)
+	env, err := newEnvConfig(doc)
 	if err != nil {
 		return nil, err
 	}
 
+	return &GWEnvironment{filepath: filepath, env: env, doc: doc, passphrase: passphrase}, nil
+}
+
+// Loads the values of the environment file into Viper, with the defaults for the missing ones
+func newEnvConfig(doc *dotenvFile) (*viper.Viper, error) {
 	env := viper.New()
-	env.SetConfigType("env")
-	env.SetConfigFile(filepath)
 	env.AutomaticEnv()
-	err = env.ReadInConfig()
+	config := map[string]any{}
+	for key, value := range doc.Values() {
+		config[key] = value
+	}
+	err := env.MergeConfigMap(config)
 	if err != nil {
 		return nil, err
 	}
 
-	setDefaultConfigValues(env)
+	err = setDefaultConfigValues(env)
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
 
-	return &GWEnvironment{filepath: filepath, env: env}, nil
+// Updates the contents of the environment file with the current values and formats them.
+// Only the lines of changed values are rewritten, and new values are added to the end in order.
+// Values read from external sources are replaced with their references. Overrides are left out,
+// and so are the settings of the mode profiles unless the file has them or they were changed.
+// Settings from environment variables keep the value in the file, if any, unless they were changed.
+func (this *GWEnvironment) marshal() ([]byte, error) {
+	keys := this.env.AllKeys()
+	slices.Sort(keys)
+	entries := []Configuration{}
+	for _, key := range keys {
+		if _, profile := devProfile[key]; profile && !this.IsSet(key) && !this.modified[key] {
+			continue
+		}
+		if !this.modified[key] && os.Getenv(strings.ToUpper(key)) != "" {
+			continue
+		}
+		entry := Configuration{Key: key, Val: this.env.GetString(key)}
+		if ref, found := this.lookupRef(entry.Key); found {
+			if entry.Val != ref.value {
+				return nil, fmt.Errorf("%s is read from %s, so change it there instead", strings.ToUpper(entry.Key), ref.ref)
+			}
+			entry.Val = ref.ref
+		}
+		entries = append(entries, entry)
+	}
+	for _, entry := range entries {
+		this.doc.Set(entry.Key, entry.Val)
+	}
+	return this.doc.Bytes(), nil
+}
+
+func (this *GWEnvironment) Save() error {
+	content, err := this.marshal()
+	if err != nil {
+		return fmt.Errorf("could not save environmental variables: %w", err)
+	}
+	return this.write(content)
 }
 
-func (this *GWEnvironment) Save() {
-	// Viper's write does not sort keys, so implement our own that does.
+// Replaces the environment file with `content`, encrypting it if the file is encrypted.
+// The previous file is added to the configuration history first if its values are different.
+func (this *GWEnvironment) write(content []byte) error {
+	if err := this.archive(content); err != nil {
+		return fmt.Errorf("could not add the environment file to the configuration history: %w", err)
+	}
+
+	if this.passphrase != nil {
+		data, err := encryptEnv(content, this.passphrase)
+		if err != nil {
+			return fmt.Errorf("could not encrypt environmental variables: %w", err)
+		}
+		if err := writeFileAtomic(this.encryptedPath(), data, 0600); err != nil {
+			return fmt.Errorf("could not save environmental variables: %w", err)
+		}
+		return nil
+	}
+
 	// Use the write-and-rename pattern to atomically update.
 
 	// Preserve existing file permissions, or use 0600 as default
@@ -58,68 +172,129 @@ func (this *GWEnvironment) Save() {
 	dir := filepath.Dir(this.filepath)
 	file, err := os.CreateTemp(dir, ".env")
 	if err != nil {
-		log.Fatalf("Could not create environmental variables file: %s\n", err)
+		return fmt.Errorf("could not create environmental variables file: %w", err)
 	}
 
-	entries := this.GetAll()
-	for _, entry := range entries {
-		if len(entry.Val) == 0 {
-			_, err = fmt.Fprintf(file, "%s=\n", strings.ToUpper(entry.Key))
-		} else {
-			_, err = fmt.Fprintf(file, "%s='%s'\n", strings.ToUpper(entry.Key), entry.Val)
-		}
-		if err != nil {
-			file.Close()
-			os.Remove(file.Name())
-			log.Fatalf("Could not write to environmental variables file: %s\n", err)
-		}
+	_, err = file.Write(content)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("could not write to environmental variables file: %w", err)
 	}
 
 	file.Sync()
 	err = file.Close()
 	if err != nil {
 		os.Remove(file.Name())
-		log.Fatalf("Could not write to environmental variables file: %s\n", err)
+		return fmt.Errorf("could not write to environmental variables file: %w", err)
 	}
 
 	err = os.Rename(file.Name(), this.filepath)
 	if err != nil {
 		os.Remove(file.Name())
-		log.Fatalf("Could not save environmental variables: %s\n", err)
+		return fmt.Errorf("could not save environmental variables: %w", err)
 	}
 
 	// Apply preserved permissions
 	err = os.Chmod(this.filepath, perm)
 	if err != nil {
-		log.Fatalf("Could not set permissions on environmental variables file: %s\n", err)
+		return fmt.Errorf("could not set permissions on environmental variables file: %w", err)
 	}
+	return nil
 }
 
-func (this *GWEnvironment) SetDev() {
-	this.env.Set("hasura_graphql_dev_mode", true)
-	this.env.Set("django_secure_ssl_redirect", false)
-	this.env.Set("django_settings_module", "config.settings.local")
-	this.env.Set("django_csrf_cookie_secure", false)
-	this.env.Set("django_session_cookie_secure", false)
+// Gets the path of the encrypted environment file
+func (this *GWEnvironment) encryptedPath() string {
+	return filepath.Join(filepath.Dir(this.filepath), EncryptedEnvFile)
 }
 
-func (this *GWEnvironment) SetProd() {
-	this.env.Set("hasura_graphql_dev_mode", false)
-	this.env.Set("django_secure_ssl_redirect", true)
-	this.env.Set("django_settings_module", "config.settings.production")
-	this.env.Set("django_csrf_cookie_secure", true)
-	this.env.Set("django_session_cookie_secure", true)
+// Encrypted reports whether the environment file is encrypted.
+func (this *GWEnvironment) Encrypted() bool {
+	return this.passphrase != nil
+}
+
+// Encrypt replaces the environment file with one encrypted with the passphrase.
+// The plaintext file is removed once the encrypted one is written, and the configuration history
+// is encrypted too.
+func (this *GWEnvironment) Encrypt(passphrase []byte) error {
+	this.passphrase = passphrase
+	if err := this.Save(); err != nil {
+		this.passphrase = nil
+		return err
+	}
+	if err := os.Remove(this.filepath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove the plaintext environment file: %w", err)
+	}
+	if err := this.convertHistory(nil); err != nil {
+		return fmt.Errorf("could not encrypt the configuration history: %w", err)
+	}
+	return nil
+}
+
+// Decrypt replaces the encrypted environment file and the configuration history with plaintext ones.
+func (this *GWEnvironment) Decrypt() error {
+	passphrase := this.passphrase
+	this.passphrase = nil
+	if err := this.Save(); err != nil {
+		this.passphrase = passphrase
+		return err
+	}
+	if err := os.Remove(this.encryptedPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove the encrypted environment file: %w", err)
+	}
+	if err := this.convertHistory(passphrase); err != nil {
+		return fmt.Errorf("could not decrypt the configuration history: %w", err)
+	}
+	return nil
+}
+
+// ComposeEnv gets the variables to pass to `docker compose` as `KEY=value` strings.
+// Compose reads a plaintext environment file itself, so only the values of the mode profile and
+// overrides are needed, unless the file is encrypted or has values read from external sources.
+func (this *GWEnvironment) ComposeEnv() []string {
+	var vars []string
+	if this.passphrase == nil && len(this.refs) == 0 {
+		for _, key := range this.layeredKeys() {
+			vars = append(vars, strings.ToUpper(key)+"="+this.Get(key))
+		}
+		return vars
+	}
+	for _, entry := range this.GetAll() {
+		vars = append(vars, strings.ToUpper(entry.Key)+"="+entry.Val)
+	}
+	return vars
 }
 
 func (this *GWEnvironment) Get(key string) string {
+	if value, found := this.overrides[canonicalKey(key)]; found {
+		return value
+	}
 	return this.env.GetString(key)
 }
 
+// IsSet reports whether the environment file has a value for the key, instead of using the default.
+func (this *GWEnvironment) IsSet(key string) bool {
+	return this.env.InConfig(strings.ToLower(key))
+}
+
 func (this *GWEnvironment) GetBool(key string) bool {
+	if value, found := this.overrides[canonicalKey(key)]; found {
+		enabled, _ := strconv.ParseBool(value)
+		return enabled
+	}
 	return this.env.GetBool(key)
 }
 
+// GetList gets a space-separated list value, such as `django_allowed_hosts`
+func (this *GWEnvironment) GetList(key string) []string {
+	return strings.Fields(this.Get(key))
+}
+
 func (this *GWEnvironment) Set(key string, val string) {
+	if this.modified == nil {
+		this.modified = map[string]bool{}
+	}
+	this.modified[canonicalKey(key)] = true
 	this.env.Set(key, val)
 }
 
@@ -176,97 +351,35 @@ func (this *GWEnvironment) GetAll() []Configuration {
 
 // Configuration is a custom type for storing configuration values as Key:Val pairs.
 type Configuration struct {
-	Key string
-	Val string
+	Key string `json:"key"`
+	Val string `json:"value"`
 }
 
-// Set sane defaults for a basic Ghostwriter deployment.
-// Defaults are geared towards a development environment.
-func setDefaultConfigValues(env *viper.Viper) {
-	// GW-CLI configuration
-	env.SetDefault("gwcli_auto_check_updates", true)
-
-	// Project configuration
-	env.SetDefault("use_docker", "yes")
-	env.SetDefault("ipythondir", "/app/.ipython")
-
-	// Passive Voice Detection configuration
-	env.SetDefault("spacy_model", "en_core_web_sm")
-
-	// Django configuration
-	env.SetDefault("django_mfa_always_reveal_backup_tokens", false)
-	env.SetDefault("django_account_allow_registration", false)
-	env.SetDefault("django_account_reauthentication_timeout", 32400)
-	env.SetDefault("django_account_email_verification", "none")
-	env.SetDefault("django_admin_url", "admin/")
-	env.SetDefault("django_allowed_hosts", "localhost 127.0.0.1 django nginx host.docker.internal ghostwriter.local")
-	env.SetDefault("django_compress_enabled", true)
-	env.SetDefault("django_csrf_cookie_secure", false)
-	env.SetDefault("django_csrf_trusted_origins", "")
-	env.SetDefault("django_date_format", "d M Y")
-	env.SetDefault("django_host", "django")
-	env.SetDefault("django_jwt_secret_key", GenerateRandomPassword(32, false))
-	env.SetDefault("django_mailgun_api_key", "")
-	env.SetDefault("django_mailgun_domain", "")
-	env.SetDefault("django_port", "8000")
-	env.SetDefault("django_qcluster_name", "soar")
-	env.SetDefault("django_secret_key", GenerateRandomPassword(32, false))
-	env.SetDefault("django_secure_ssl_redirect", false)
-	env.SetDefault("django_session_cookie_age", 32400)
-	env.SetDefault("django_session_cookie_secure", false)
-	env.SetDefault("django_session_expire_at_browser_close", false)
-	env.SetDefault("django_session_save_every_request", true)
-	env.SetDefault("django_settings_module", "config.settings.local")
-	env.SetDefault("django_social_account_allow_registration", false)
-	env.SetDefault("django_social_account_domain_allowlist", "")
-	env.SetDefault("django_social_account_login_on_get", false)
-	env.SetDefault("django_superuser_email", "admin@ghostwriter.local")
-	env.SetDefault("django_superuser_password", GenerateRandomPassword(32, true))
-	env.SetDefault("django_superuser_username", "admin")
-	env.SetDefault("django_web_concurrency", 4)
-
-	// PostgreSQL configuration
-	env.SetDefault("postgres_host", "postgres")
-	env.SetDefault("postgres_port", 5432)
-	env.SetDefault("postgres_db", "ghostwriter")
-	env.SetDefault("postgres_user", "postgres")
-	env.SetDefault("postgres_password", GenerateRandomPassword(32, true))
-	env.SetDefault("POSTGRES_CONN_MAX_AGE", 0)
-
-	// Redis configuration
-	env.SetDefault("redis_host", "redis")
-	env.SetDefault("redis_port", 6379)
-
-	// Nginx configuration
-	env.SetDefault("nginx_host", "nginx")
-	env.SetDefault("nginx_port", 443)
-
-	// Hasura configuration
-	env.SetDefault("hasura_graphql_action_secret", GenerateRandomPassword(32, true))
-	env.SetDefault("hasura_graphql_admin_secret", GenerateRandomPassword(32, true))
-	env.SetDefault("hasura_graphql_dev_mode", true)
-	env.SetDefault("hasura_graphql_enable_console", false)
-	env.SetDefault("hasura_graphql_enabled_log_types", "startup, http-log, webhook-log, websocket-log, query-log")
-	env.SetDefault("hasura_graphql_enable_telemetry", false)
-	env.SetDefault("hasura_graphql_server_host", "graphql_engine")
-	env.SetDefault("hasura_graphql_server_hostname", "graphql_engine")
-	env.SetDefault("hasura_graphql_insecure_skip_tls_verify", true)
-	env.SetDefault("hasura_graphql_log_level", "warn")
-	env.SetDefault("hasura_graphql_metadata_dir", "/metadata")
-	env.SetDefault("hasura_graphql_migrations_dir", "/migrations")
-	env.SetDefault("hasura_graphql_server_port", 8080)
-
-	// Docker & Django health check configuration
-	env.SetDefault("healthcheck_disk_usage_max", 90)
-	env.SetDefault("healthcheck_interval", "300s")
-	env.SetDefault("healthcheck_mem_min", 100)
-	env.SetDefault("healthcheck_retries", 3)
-	env.SetDefault("healthcheck_start", "60s")
-	env.SetDefault("healthcheck_timeout", "30s")
+// Set sane defaults for a basic Ghostwriter deployment from the settings in the schema.
+func setDefaultConfigValues(env *viper.Viper) error {
+	// Generate random passwords, keeping the first error
+	var err error
+	password := func(length int, safe bool) string {
+		if err != nil {
+			return ""
+		}
+		var pw string
+		pw, err = GenerateRandomPassword(length, safe)
+		return pw
+	}
+
+	for _, setting := range settings {
+		if setting.PasswordLength > 0 {
+			env.SetDefault(setting.Key, password(setting.PasswordLength, setting.PasswordSafe))
+		} else {
+			env.SetDefault(setting.Key, setting.Default)
+		}
+	}
 
 	// Set some helpful aliases for common settings
-	env.RegisterAlias("date_format", "django_date_format")
-	env.RegisterAlias("admin_password", "django_superuser_password")
-	env.RegisterAlias("hasura_password", "hasura_graphql_admin_secret")
-	env.RegisterAlias("spacy", "spacy_model")
+	for alias, key := range settingAliases {
+		env.RegisterAlias(alias, key)
+	}
+
+	return err
 }
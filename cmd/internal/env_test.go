@@ -26,27 +26,27 @@ func TestGhostwriterEnvironmentVariables(t *testing.T) {
 	// Test a default value
 	assert.Equal(t, env.Get("django_date_format"), "d M Y", "Value of `django_date_format` should be `d M Y`")
 
-	// Test modifying the .env file for production mode
+	// Test applying the production mode profile, which isn't saved to the .env file
 	env.SetProd()
-	env.Save()
-	env, err = ReadEnv(tempDir)
-	assert.NoError(t, err)
 	assert.Equal(t, env.Get("hasura_graphql_dev_mode"), "false", "Production value of `hasura_graphql_dev_mode` should be false")
 	assert.Equal(t, env.Get("django_secure_ssl_redirect"), "true", "Production value of `django_secure_ssl_redirect` should be true")
 	assert.Equal(t, env.Get("django_settings_module"), "config.settings.production", "Production value of `django_settings_module` should be `config.settings.production`")
+	assert.NoError(t, env.Save())
+	data, err := os.ReadFile(envFile)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "DJANGO_SETTINGS_MODULE", "Expected the mode profile to be left out of the .env file")
 
-	// Test modifying the .env file for dev mode
-	env.SetDev()
-	env.Save()
+	// Test applying the dev mode profile
 	env, err = ReadEnv(tempDir)
 	assert.NoError(t, err)
+	env.SetDev()
 	assert.Equal(t, env.Get("hasura_graphql_dev_mode"), "true", "Development value of `hasura_graphql_dev_mode` should be true")
 	assert.Equal(t, env.Get("django_secure_ssl_redirect"), "false", "Development value of `django_secure_ssl_redirect` should be false")
 	assert.Equal(t, env.Get("django_settings_module"), "config.settings.local", "Development value of `django_settings_module` should be `config.settings.local`")
 
 	// Test ``GetAll()``
 	config := env.GetAll()
-	assert.Equal(t, len(config), 67, "`GetConfigAll()` should return all values")
+	assert.Equal(t, len(config), 70, "`GetConfigAll()` should return all values")
 
 	// Test ``Set()``
 	env.Set("django_date_format", "Y M d")
@@ -0,0 +1,27 @@
+package internal
+
+// Sentinel errors returned by the internal package
+// Wrapped errors add details, so compare them with `errors.Is`
+
+import "errors"
+
+var (
+	// Ghostwriter has not been installed, or the CLI is not in the same directory as the compose file
+	ErrNotInstalled = errors.New("Ghostwriter is not installed")
+	// Neither Docker nor Podman is available in the PATH
+	ErrRuntimeMissing = errors.New("no container engine is installed")
+	// The container engine is installed, but its daemon is not running or reachable
+	ErrDaemonUnavailable = errors.New("the container engine daemon is unavailable")
+	// The Docker Compose v2 plugin is not installed
+	ErrComposeMissing = errors.New("Docker Compose is not installed")
+	// The current user is not allowed to talk to the container engine daemon
+	ErrPermissionDenied = errors.New("permission denied")
+	// The Django container stopped or never finished starting
+	ErrDjangoNotStarted = errors.New("Django did not start")
+	// PostgreSQL rejected the password in the environment file
+	ErrPostgresPasswordMismatch = errors.New("PostgreSQL password mismatch")
+	// The environment file is encrypted, but no passphrase or key file was provided
+	ErrPassphraseMissing = errors.New("no passphrase for the encrypted environment file")
+	// The passphrase or key file can't decrypt the environment file, or the file was modified
+	ErrDecryptionFailed = errors.New("could not decrypt the environment file")
+)
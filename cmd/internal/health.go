@@ -0,0 +1,125 @@
+package internal
+
+// Types for reporting the health of Ghostwriter's containers and services
+// Severities follow the Nagios plugin conventions so results can be used by monitoring systems
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity of a health issue, with values matching Nagios plugin exit codes
+type Severity int
+
+const (
+	SeverityOK       Severity = 0
+	SeverityWarning  Severity = 1
+	SeverityCritical Severity = 2
+	SeverityUnknown  Severity = 3
+)
+
+var severityNames = map[Severity]string{
+	SeverityOK:       "OK",
+	SeverityWarning:  "WARNING",
+	SeverityCritical: "CRITICAL",
+	SeverityUnknown:  "UNKNOWN",
+}
+
+// Order used to pick the worst severity: an unknown state is worse than OK but better than a known problem
+var severityRanks = map[Severity]int{
+	SeverityOK:       0,
+	SeverityUnknown:  1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+func (this Severity) String() string {
+	if name, ok := severityNames[this]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(this))
+}
+
+// ExitCode returns the Nagios plugin exit code for the severity.
+func (this Severity) ExitCode() int {
+	return int(this)
+}
+
+// Worse returns the more severe of the two severities.
+func (this Severity) Worse(other Severity) Severity {
+	if severityRanks[other] > severityRanks[this] {
+		return other
+	}
+	return this
+}
+
+func (this Severity) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(this.String())), nil
+}
+
+func (this *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if strings.EqualFold(name, string(text)) {
+			*this = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity: %s", text)
+}
+
+// HealthIssue is a problem found with a container or service, or the result of a service probe.
+type HealthIssue struct {
+	Type     string   `json:"type"`
+	Service  string   `json:"service"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+	// Time taken by the check in seconds, if it was measured
+	Latency float64 `json:"latency,omitempty"`
+}
+
+// HealthIssues is a collection of HealthIssue structs
+type HealthIssues []HealthIssue
+
+func (c HealthIssues) Len() int {
+	return len(c)
+}
+
+func (c HealthIssues) Less(i, j int) bool {
+	return c[i].Service < c[j].Service
+}
+
+func (c HealthIssues) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+// Severity returns the worst severity of the issues, or `SeverityOK` if there are none.
+func (c HealthIssues) Severity() Severity {
+	severity := SeverityOK
+	for _, issue := range c {
+		severity = severity.Worse(issue.Severity)
+	}
+	return severity
+}
+
+// KnownSeverity returns the worst severity of the issues, ignoring checks that couldn't be run
+// (`SeverityUnknown`), or `SeverityOK` if there are no others.
+func (c HealthIssues) KnownSeverity() Severity {
+	severity := SeverityOK
+	for _, issue := range c {
+		if issue.Severity != SeverityUnknown {
+			severity = severity.Worse(issue.Severity)
+		}
+	}
+	return severity
+}
+
+// Count returns the number of issues with the specified severity.
+func (c HealthIssues) Count(severity Severity) int {
+	count := 0
+	for _, issue := range c {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverityWorse(t *testing.T) {
+	assert.Equal(t, SeverityWarning, SeverityOK.Worse(SeverityWarning))
+	assert.Equal(t, SeverityCritical, SeverityCritical.Worse(SeverityWarning))
+	assert.Equal(t, SeverityWarning, SeverityUnknown.Worse(SeverityWarning), "Expected a known warning to outrank an unknown state")
+	assert.Equal(t, SeverityUnknown, SeverityOK.Worse(SeverityUnknown))
+	assert.Equal(t, 2, SeverityCritical.ExitCode(), "Expected Nagios exit codes")
+}
+
+func TestHealthIssuesSeverity(t *testing.T) {
+	var issues HealthIssues
+	assert.Equal(t, SeverityOK, issues.Severity(), "Expected no issues to be OK")
+
+	issues = HealthIssues{
+		{Type: "Container", Service: "QUEUE", Message: "Container is not running", Severity: SeverityWarning},
+		{Type: "Container", Service: "POSTGRES", Message: "Container is not running", Severity: SeverityCritical},
+	}
+	assert.Equal(t, SeverityCritical, issues.Severity())
+	assert.Equal(t, 1, issues.Count(SeverityWarning))
+
+	unknown := HealthIssues{{Type: "Resource", Service: "memory", Message: "Could not get available memory", Severity: SeverityUnknown}}
+	assert.Equal(t, SeverityUnknown, unknown.Severity())
+	assert.Equal(t, SeverityOK, unknown.KnownSeverity(), "Expected checks that couldn't run to be ignored")
+	assert.Equal(t, SeverityWarning, append(unknown, issues[0]).KnownSeverity())
+
+	out, err := json.Marshal(issues[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"severity":"warning"`)
+
+	var decoded HealthIssue
+	assert.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, issues[0], decoded)
+}
@@ -0,0 +1,301 @@
+package internal
+
+// Keeping previous versions of the environment file, so a broken configuration can be rolled back
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Directory in the data directory with the previous versions of the environment file
+	ConfigHistoryDir = "config_history"
+
+	defaultConfigHistoryLimit = 20
+	historyTimeFormat         = "2006_01_02T15_04_05"
+)
+
+// Names of the versions in the history, e.g., `env_2026_10_16T12_30_00.env` or
+// `env_2026_10_16T12_30_00_1.env.enc` for a second version saved within the same second
+var historyFilePattern = regexp.MustCompile(`^env_(\d{4}_\d{2}_\d{2}T\d{2}_\d{2}_\d{2})(?:_(\d+))?\.env(\.enc)?$`)
+
+// ConfigVersion is a previous version of the environment file.
+type ConfigVersion struct {
+	// Position in the history, starting from 1 for the most recent version
+	Number   int       `json:"number"`
+	Replaced time.Time `json:"replaced"`
+	Path     string    `json:"path"`
+	// Whether the version is encrypted with the passphrase of the environment file
+	Encrypted bool `json:"encrypted"`
+	// Settings changed when the version was replaced, or nil if it couldn't be read
+	Changed []string `json:"changed"`
+
+	// Orders versions saved within the same second
+	sequence int
+}
+
+// Gets the directory with the previous versions of the environment file
+func (this *GWEnvironment) historyDir() string {
+	return filepath.Join(filepath.Dir(this.filepath), ConfigHistoryDir)
+}
+
+// Gets the number of versions to keep from `gwcli_config_history_limit`
+func (this *GWEnvironment) historyLimit() int {
+	limit, err := strconv.Atoi(this.Get("gwcli_config_history_limit"))
+	if err != nil || limit < 0 {
+		return defaultConfigHistoryLimit
+	}
+	return limit
+}
+
+// Copies the environment file into the history before it's replaced with `content`, keeping its
+// permissions. Nothing is copied if the file is empty or its contents aren't changing. The oldest
+// versions are removed once there are more than `gwcli_config_history_limit`.
+func (this *GWEnvironment) archive(content []byte) error {
+	limit := this.historyLimit()
+	if limit == 0 {
+		return nil
+	}
+
+	path, ext := this.filepath, ".env"
+	if this.passphrase != nil {
+		path, ext = this.encryptedPath(), EncryptedEnvFile
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	previous := data
+	if this.passphrase != nil {
+		if previous, err = decryptEnv(data, this.passphrase); err != nil {
+			return err
+		}
+	}
+	if len(bytes.TrimSpace(previous)) == 0 || bytes.Equal(previous, content) {
+		return nil
+	}
+
+	dir := this.historyDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to make the history directory: %w", err)
+	}
+	timestamp := time.Now().Format(historyTimeFormat)
+	archived := filepath.Join(dir, fmt.Sprintf("env_%s%s", timestamp, ext))
+	// Don't overwrite a version saved within the same second
+	for i := 1; FileExists(archived); i++ {
+		archived = filepath.Join(dir, fmt.Sprintf("env_%s_%d%s", timestamp, i, ext))
+	}
+	if err := writeFileAtomic(archived, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	versions, err := this.listVersions()
+	if err != nil {
+		return err
+	}
+	for _, version := range versions[min(limit, len(versions)):] {
+		if err := os.Remove(version.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", version.Path, err)
+		}
+	}
+	return nil
+}
+
+// Lists the versions in the history, most recent first
+func (this *GWEnvironment) listVersions() ([]ConfigVersion, error) {
+	entries, err := os.ReadDir(this.historyDir())
+	if os.IsNotExist(err) {
+		return []ConfigVersion{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the history directory: %w", err)
+	}
+
+	versions := []ConfigVersion{}
+	for _, entry := range entries {
+		match := historyFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil || !entry.Type().IsRegular() {
+			continue
+		}
+		saved, err := time.ParseInLocation(historyTimeFormat, match[1], time.Local)
+		if err != nil {
+			continue
+		}
+		sequence, _ := strconv.Atoi(match[2])
+		versions = append(versions, ConfigVersion{
+			Replaced:  saved,
+			Path:      filepath.Join(this.historyDir(), entry.Name()),
+			Encrypted: match[3] != "",
+			sequence:  sequence,
+		})
+	}
+	slices.SortFunc(versions, func(a, b ConfigVersion) int {
+		if compared := b.Replaced.Compare(a.Replaced); compared != 0 {
+			return compared
+		}
+		return b.sequence - a.sequence
+	})
+	for i := range versions {
+		versions[i].Number = i + 1
+	}
+	return versions, nil
+}
+
+// Reads the contents of a version, decrypting it with `passphrase` or the configured passphrase
+func readVersion(version ConfigVersion, passphrase []byte) ([]byte, error) {
+	data, err := os.ReadFile(version.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version %d: %w", version.Number, err)
+	}
+	if !version.Encrypted {
+		return data, nil
+	}
+	if passphrase == nil {
+		if passphrase, err = GetPassphrase(); err != nil {
+			return nil, err
+		}
+	}
+	return decryptEnv(data, passphrase)
+}
+
+// Gets the values in an environment file, with the values of the mode profile and the defaults for
+// the missing ones
+func fileValues(doc *dotenvFile, profile map[string]string) map[string]string {
+	values := DefaultValues()
+	for key, value := range profile {
+		values[key] = value
+	}
+	for key, value := range doc.Values() {
+		values[canonicalKey(key)] = value
+	}
+	return values
+}
+
+// Gets the values in the contents of an environment file, with the values of the mode profile and
+// the defaults for the missing ones. Mode values written by earlier versions are dropped, the same
+// as when the file is read.
+func parseEnvValues(data []byte, profile map[string]string) (map[string]string, error) {
+	doc, err := parseDotenv(data)
+	if err != nil {
+		return nil, err
+	}
+	dropLegacyModeValues(doc)
+	return fileValues(doc, profile), nil
+}
+
+// History lists the previous versions of the environment file, most recent first, with the
+// settings that changed when each one was replaced. Only the values in the files are compared, with
+// the same mode profile applied to every version.
+func (this *GWEnvironment) History() ([]ConfigVersion, error) {
+	versions, err := this.listVersions()
+	if err != nil {
+		return nil, err
+	}
+	newer := fileValues(this.doc, this.profile)
+	for i := range versions {
+		data, err := readVersion(versions[i], this.passphrase)
+		if err != nil {
+			newer = nil
+			continue
+		}
+		values, err := parseEnvValues(data, this.profile)
+		if err != nil {
+			newer = nil
+			continue
+		}
+		// The changes can't be listed if the newer version couldn't be read
+		if newer != nil {
+			versions[i].Changed = []string{}
+			for _, change := range DiffConfig(newer, values) {
+				versions[i].Changed = append(versions[i].Changed, change.Key)
+			}
+		}
+		newer = values
+	}
+	return versions, nil
+}
+
+// Rollback replaces the environment file with version `number` of the history and returns the
+// settings that changed. The current file is added to the history first, so the rollback can be
+// undone by rolling back to version 1.
+func (this *GWEnvironment) Rollback(number int) ([]ConfigChange, error) {
+	versions, err := this.listVersions()
+	if err != nil {
+		return nil, err
+	}
+	if number < 1 || number > len(versions) {
+		return nil, fmt.Errorf("there is no version %d in the configuration history; use `config history` to list the versions", number)
+	}
+	content, err := readVersion(versions[number-1], this.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDotenv(content)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse version %d: %w", number, err)
+	}
+	dropLegacyModeValues(doc)
+	env, err := newEnvConfig(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := this.Values()
+	if err := this.write(content); err != nil {
+		return nil, err
+	}
+	this.doc = doc
+	this.env = env
+	this.refs = nil
+	this.modified = nil
+	if this.profile != nil {
+		this.setProfile(this.profileName, this.profile)
+	}
+	return DiffConfig(this.Values(), previous), nil
+}
+
+// Rewrites the versions in the history that aren't in the same format as the environment file,
+// so encrypting the file doesn't leave plaintext copies of its secrets behind. `passphrase`
+// decrypts the versions when the file was just decrypted.
+func (this *GWEnvironment) convertHistory(passphrase []byte) error {
+	versions, err := this.listVersions()
+	if err != nil {
+		return err
+	}
+	encrypt := this.passphrase != nil
+	for _, version := range versions {
+		if version.Encrypted == encrypt {
+			continue
+		}
+		data, err := readVersion(version, passphrase)
+		if err != nil {
+			return err
+		}
+		path := strings.TrimSuffix(version.Path, ".enc")
+		if encrypt {
+			if data, err = encryptEnv(data, this.passphrase); err != nil {
+				return err
+			}
+			path += ".enc"
+		}
+		if err := writeFileAtomic(path, data, 0600); err != nil {
+			return err
+		}
+		if err := os.Remove(version.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", version.Path, err)
+		}
+	}
+	return nil
+}
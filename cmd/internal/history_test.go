@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigHistory(t *testing.T) {
+	dir := t.TempDir()
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, env.Save())
+	assert.NoError(t, os.Chmod(filepath.Join(dir, ".env"), 0640))
+
+	versions, err := env.History()
+	assert.NoError(t, err)
+	assert.Empty(t, versions, "Expected the empty file to be left out of the history")
+
+	env.Set("django_port", "8001")
+	assert.NoError(t, env.Save())
+	assert.NoError(t, env.Save())
+	env.Set("django_port", "8002")
+	env.Set("django_date_format", "Y M d")
+	assert.NoError(t, env.Save())
+
+	versions, err = env.History()
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 2, "Expected saving without changes to be left out of the history") {
+		assert.Equal(t, 1, versions[0].Number)
+		assert.Equal(t, []string{"django_date_format", "django_port"}, versions[0].Changed)
+		assert.Equal(t, []string{"django_port"}, versions[1].Changed)
+		info, err := os.Stat(versions[1].Path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm(), "Expected the permissions to be kept")
+	}
+
+	changes, err := env.Rollback(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []ConfigChange{
+		{Key: "django_date_format", Change: ChangeChanged, Current: "d M Y", Other: "Y M d"},
+		{Key: "django_port", Change: ChangeChanged, Current: "8001", Other: "8002"},
+	}, changes)
+	assert.Equal(t, "8001", env.Get("django_port"))
+	reread, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "8001", reread.Get("django_port"))
+
+	versions, err = env.History()
+	assert.NoError(t, err)
+	assert.Len(t, versions, 3, "Expected the replaced file to be added to the history")
+
+	_, err = env.Rollback(4)
+	assert.ErrorContains(t, err, "there is no version 4")
+	_, err = env.Rollback(0)
+	assert.Error(t, err)
+}
+
+func TestConfigHistoryLimit(t *testing.T) {
+	dir := t.TempDir()
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	env.Set("gwcli_config_history_limit", "2")
+	for _, port := range []string{"8001", "8002", "8003", "8004"} {
+		env.Set("django_port", port)
+		assert.NoError(t, env.Save())
+	}
+
+	versions, err := env.History()
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 2, "Expected the oldest versions to be removed") {
+		values, err := ReadEnvValues(versions[1].Path)
+		assert.NoError(t, err)
+		assert.Equal(t, "8002", values["django_port"])
+	}
+
+	env.Set("gwcli_config_history_limit", "0")
+	env.Set("django_port", "8005")
+	assert.NoError(t, env.Save())
+	versions, err = env.History()
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2, "Expected nothing to be added to the history with a limit of 0")
+}
+
+func TestEncryptedConfigHistory(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(KeyFileEnvVar, "")
+	t.Setenv(PassphraseEnvVar, "passphrase")
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, env.Save())
+	env.Set("django_port", "8001")
+	assert.NoError(t, env.Save())
+
+	assert.NoError(t, env.Encrypt([]byte("passphrase")))
+	versions, err := env.History()
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 1) {
+		assert.True(t, versions[0].Encrypted)
+		assert.True(t, strings.HasSuffix(versions[0].Path, EncryptedEnvFile))
+		data, err := os.ReadFile(versions[0].Path)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "DJANGO_PORT", "Expected the plaintext version to be encrypted")
+	}
+
+	env.Set("django_port", "8002")
+	assert.NoError(t, env.Save())
+	changes, err := env.Rollback(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []ConfigChange{{Key: "django_port", Change: ChangeChanged, Current: "8001", Other: "8002"}}, changes)
+
+	assert.NoError(t, env.Decrypt())
+	versions, err = env.History()
+	assert.NoError(t, err)
+	assert.Len(t, versions, 3)
+	for _, version := range versions {
+		assert.False(t, version.Encrypted)
+		assert.NotNil(t, version.Changed)
+	}
+}
+
+func TestConfigHistoryWithModeProfile(t *testing.T) {
+	dir := t.TempDir()
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	env.SetProd()
+	env.Set("django_port", "8001")
+	assert.NoError(t, env.Save())
+	env.Set("django_port", "8002")
+	assert.NoError(t, env.Save())
+
+	versions, err := env.History()
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 1) {
+		assert.Equal(t, []string{"django_port"}, versions[0].Changed, "Expected the mode profile to be applied to every version")
+	}
+}
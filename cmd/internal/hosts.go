@@ -0,0 +1,187 @@
+package internal
+
+// Checking the entries of `django_allowed_hosts` and `django_csrf_trusted_origins` against the rules
+// Django applies to `ALLOWED_HOSTS` and `CSRF_TRUSTED_ORIGINS`
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Labels of hostnames, which Django only accepts with letters, digits, and hyphens
+var hostLabelPattern = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Addresses that look like IPv4 addresses or patterns of them, e.g., `192.168.1.300` or `192.168.1.*`
+var ipv4LikePattern = regexp.MustCompile(`^[0-9.*]+$`)
+
+// Checks a hostname without a wildcard or port
+func validateHostname(host string) error {
+	if len(host) > 253 {
+		return fmt.Errorf("%q is longer than 253 characters", host)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !hostLabelPattern.MatchString(label) {
+			return fmt.Errorf("%q is not a valid hostname; hostnames can only have letters, digits, hyphens, and dots", host)
+		}
+	}
+	return nil
+}
+
+// ValidateAllowedHost checks an entry of `django_allowed_hosts`. Django accepts hostnames, IP
+// addresses with IPv6 in brackets, a leading dot to match a domain and its subdomains, and a lone
+// "*" to match any host. Wildcards anywhere else, CIDR ranges, schemes, and ports never match.
+func ValidateAllowedHost(host string) error {
+	switch {
+	case host == "*":
+		return nil
+	case strings.Contains(host, "://"):
+		suggestion := host
+		if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+			suggestion = u.Hostname()
+		}
+		return fmt.Errorf("%q includes a scheme; allowed hosts are only the hostname, e.g., %s", host, suggestion)
+	case strings.Contains(host, "/"):
+		if _, _, err := net.ParseCIDR(host); err == nil {
+			return fmt.Errorf("%q is a CIDR range, which Django doesn't support; add each IP address instead", host)
+		}
+		return fmt.Errorf("%q includes a path; allowed hosts are only the hostname", host)
+	case strings.HasPrefix(host, "*."):
+		return fmt.Errorf("%q uses a wildcard, which Django doesn't support in hostnames; use %q to allow the domain and its subdomains", host, host[1:])
+	case strings.Contains(host, "*"):
+		return fmt.Errorf("%q uses a wildcard, which Django only supports as a single \"*\" to allow every host", host)
+	case strings.HasPrefix(host, "["):
+		address, port, found := strings.Cut(strings.TrimPrefix(host, "["), "]")
+		if !found || net.ParseIP(address) == nil || strings.Contains(address, ".") && !strings.Contains(address, ":") {
+			return fmt.Errorf("%q is not a valid IPv6 address", host)
+		}
+		if port != "" {
+			return fmt.Errorf("%q includes a port, which Django ignores; use %q", host, "["+address+"]")
+		}
+		return nil
+	case net.ParseIP(host) != nil && strings.Contains(host, ":"):
+		return fmt.Errorf("IPv6 addresses must be in brackets, e.g., %q", "["+host+"]")
+	case strings.Contains(host, ":"):
+		name, _, _ := strings.Cut(host, ":")
+		return fmt.Errorf("%q includes a port, which Django ignores; use %q", host, name)
+	case net.ParseIP(host) != nil:
+		return nil
+	case ipv4LikePattern.MatchString(host):
+		return fmt.Errorf("%q is not a valid IP address", host)
+	}
+	return validateHostname(strings.TrimPrefix(host, "."))
+}
+
+// ValidateTrustedOrigin checks an entry of `django_csrf_trusted_origins`. Django compares origins
+// with the `Origin` header, so they need a scheme and can't have a path. The host can start with
+// "*." to trust every subdomain.
+func ValidateTrustedOrigin(origin string) error {
+	if origin == "*" {
+		return fmt.Errorf("Django can't trust every origin; add each origin, e.g., https://ghostwriter.local")
+	}
+	if !strings.Contains(origin, "://") {
+		return fmt.Errorf("%q is missing the scheme, which Django requires, e.g., %q", origin, "https://"+strings.TrimSuffix(origin, "/"))
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid origin", origin)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q must use http or https", origin)
+	}
+	base := u.Scheme + "://" + u.Host
+	switch {
+	case u.User != nil:
+		return fmt.Errorf("%q can't include a username or password", origin)
+	case u.Path != "" || u.RawQuery != "" || u.Fragment != "" || strings.HasSuffix(origin, "?") || strings.HasSuffix(origin, "#"):
+		return fmt.Errorf("%q includes a path, which never matches the Origin header; use %q", origin, base)
+	case u.Host == "":
+		return fmt.Errorf("%q is missing the hostname", origin)
+	}
+	if port := u.Port(); port != "" || strings.HasSuffix(u.Host, ":") {
+		if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+			return fmt.Errorf("%q does not have a valid port", origin)
+		}
+	}
+
+	host := u.Hostname()
+	switch {
+	case strings.HasPrefix(host, "*."):
+		return validateHostname(host[2:])
+	case strings.Contains(host, "*"):
+		return fmt.Errorf("%q uses a wildcard, which Django only supports at the start of the hostname, e.g., https://*.example.com", origin)
+	case net.ParseIP(host) != nil:
+		return nil
+	case ipv4LikePattern.MatchString(host):
+		return fmt.Errorf("%q does not have a valid IP address", origin)
+	}
+	return validateHostname(host)
+}
+
+// Checks an entry of a host or origin list, depending on the setting
+func validateListEntry(key, entry string) error {
+	switch canonicalKey(key) {
+	case "django_allowed_hosts":
+		return ValidateAllowedHost(entry)
+	case "django_csrf_trusted_origins":
+		return ValidateTrustedOrigin(entry)
+	}
+	return nil
+}
+
+// Warns about entries of a host or origin list that work but are risky, or returns an empty string
+func listEntryWarning(key, entry string) string {
+	if canonicalKey(key) == "django_allowed_hosts" && entry == "*" {
+		return "Allows requests for any hostname, which should only be used for testing"
+	}
+	return ""
+}
+
+// HostEntry is an entry of a host or origin list with any problems found with it.
+type HostEntry struct {
+	Host string `json:"host"`
+	// Why Django won't match the entry, if it's invalid
+	Error string `json:"error,omitempty"`
+	// Why the entry is risky, if it's valid
+	Warning string `json:"warning,omitempty"`
+}
+
+// CheckHost checks an entry for a host or origin list, such as `django_allowed_hosts`.
+func CheckHost(key, host string) HostEntry {
+	entry := HostEntry{Host: host}
+	if err := validateListEntry(key, host); err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Warning = listEntryWarning(key, host)
+	}
+	return entry
+}
+
+// ListHosts checks every entry of a host or origin list, in order.
+func (this *GWEnvironment) ListHosts(key string) []HostEntry {
+	entries := []HostEntry{}
+	for _, host := range this.GetList(key) {
+		entries = append(entries, CheckHost(key, host))
+	}
+	return entries
+}
+
+// ReadHostList reads the entries for a host or origin list from a file with one or more entries
+// on each line, separated by spaces. Blank lines and comments starting with "#" are skipped.
+func ReadHostList(reader io.Reader) ([]string, error) {
+	hosts := []string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		hosts = append(hosts, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAllowedHost(t *testing.T) {
+	for _, host := range []string{"*", "localhost", "ghostwriter.local", ".example.com", "host.docker.internal", "192.168.1.100", "[::1]", "[2001:db8::1]", "xn--bcher-kva.example"} {
+		assert.NoError(t, ValidateAllowedHost(host), host)
+	}
+
+	for host, message := range map[string]string{
+		"*.example.com":        `use ".example.com"`,
+		"192.168.1.*":          `single "*"`,
+		"192.168.1.0/24":       "CIDR range",
+		"example.com/path":     "includes a path",
+		"https://example.com":  "e.g., example.com",
+		"example.com:8000":     `use "example.com"`,
+		"::1":                  `"[::1]"`,
+		"[::1]:8000":           "includes a port",
+		"[1.2.3.4]":            "not a valid IPv6 address",
+		"192.168.1.300":        "not a valid IP address",
+		"under_score.local":    "not a valid hostname",
+		"-leading.example.com": "not a valid hostname",
+		"example..com":         "not a valid hostname",
+	} {
+		err := ValidateAllowedHost(host)
+		if assert.Error(t, err, host) {
+			assert.Contains(t, err.Error(), message, host)
+		}
+	}
+}
+
+func TestValidateTrustedOrigin(t *testing.T) {
+	for _, origin := range []string{"https://ghostwriter.local", "http://localhost:8000", "https://*.example.com", "https://192.168.1.100:8443", "https://[::1]"} {
+		assert.NoError(t, ValidateTrustedOrigin(origin), origin)
+	}
+
+	for origin, message := range map[string]string{
+		"*":                          "can't trust every origin",
+		"ghostwriter.local":          `"https://ghostwriter.local"`,
+		"https://ghostwriter.local/": `use "https://ghostwriter.local"`,
+		"https://example.com/login":  "includes a path",
+		"https://example.com?a=b":    "includes a path",
+		"ftp://example.com":          "http or https",
+		"https://user@example.com":   "username or password",
+		"https://":                   "missing the hostname",
+		"https://example.com:0":      "valid port",
+		"https://example.com:":       "valid port",
+		"https://api.*.example.com":  "start of the hostname",
+		"https://under_score.local":  "not a valid hostname",
+		"https://192.168.1.300":      "valid IP address",
+	} {
+		err := ValidateTrustedOrigin(origin)
+		if assert.Error(t, err, origin) {
+			assert.Contains(t, err.Error(), message, origin)
+		}
+	}
+}
+
+func TestReadHostList(t *testing.T) {
+	hosts, err := ReadHostList(strings.NewReader("# Hosts\nghostwriter.local  .example.com\n\n192.168.1.100 # office\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ghostwriter.local", ".example.com", "192.168.1.100"}, hosts)
+}
+
+func TestHostLists(t *testing.T) {
+	env, err := ReadEnv(t.TempDir())
+	assert.NoError(t, err)
+	env.Set("django_allowed_hosts", "localhost * *.example.com")
+	assert.Equal(t, []HostEntry{
+		{Host: "localhost"},
+		{Host: "*", Warning: "Allows requests for any hostname, which should only be used for testing"},
+		{Host: "*.example.com", Error: `"*.example.com" uses a wildcard, which Django doesn't support in hostnames; use ".example.com" to allow the domain and its subdomains`},
+	}, env.ListHosts("DJANGO_ALLOWED_HOSTS"))
+
+	assert.NoError(t, ValidateSetting("django_allowed_hosts", ""))
+	assert.NoError(t, ValidateSetting("django_allowed_hosts", "localhost 127.0.0.1 django nginx host.docker.internal ghostwriter.local"))
+	assert.ErrorContains(t, ValidateSetting("django_allowed_hosts", "localhost 10.0.0.0/8"), "CIDR range")
+	assert.NoError(t, ValidateSetting("django_csrf_trusted_origins", "https://ghostwriter.local https://*.example.com"))
+	assert.ErrorContains(t, ValidateSetting("django_csrf_trusted_origins", "ghostwriter.local"), "missing the scheme")
+
+	issues := env.Validate()
+	assert.Contains(t, issues, ConfigIssue{Key: "DJANGO_ALLOWED_HOSTS", Message: `"*.example.com" uses a wildcard, which Django doesn't support in hostnames; use ".example.com" to allow the domain and its subdomains`, Severity: SeverityCritical})
+}
@@ -0,0 +1,237 @@
+package internal
+
+// Layers of the configuration, from lowest to highest precedence: the defaults in the schema, the
+// profile of the mode Ghostwriter runs in, the environment file, environment variables, and overrides
+// made by the running command. Only the environment file is saved; the other layers are passed to
+// Compose when it runs.
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Layers a value can come from, in order of precedence
+const (
+	LayerDefault     = "default"
+	LayerMode        = "mode"
+	LayerFile        = "file"
+	LayerEnvironment = "environment"
+	LayerOverride    = "override"
+)
+
+// Values that depend on whether Ghostwriter runs in development or production mode
+var (
+	devProfile = map[string]string{
+		"hasura_graphql_dev_mode":      "true",
+		"django_secure_ssl_redirect":   "false",
+		"django_settings_module":       "config.settings.local",
+		"django_csrf_cookie_secure":    "false",
+		"django_session_cookie_secure": "false",
+	}
+	prodProfile = map[string]string{
+		"hasura_graphql_dev_mode":      "false",
+		"django_secure_ssl_redirect":   "true",
+		"django_settings_module":       "config.settings.production",
+		"django_csrf_cookie_secure":    "true",
+		"django_session_cookie_secure": "true",
+	}
+)
+
+// Gets the name of a setting in lower case, replacing aliases with the settings they stand for
+func canonicalKey(key string) string {
+	key = strings.ToLower(key)
+	if target, alias := settingAliases[key]; alias {
+		return target
+	}
+	return key
+}
+
+// Reports whether the values match every value of a mode profile
+func matchesProfile(values map[string]string, profile map[string]string) bool {
+	for key, expected := range profile {
+		value, found := values[key]
+		if !found {
+			return false
+		}
+		expectedBool, err := strconv.ParseBool(expected)
+		if err != nil {
+			if value != expected {
+				return false
+			}
+			continue
+		}
+		if valueBool, err := strconv.ParseBool(value); err != nil || valueBool != expectedBool {
+			return false
+		}
+	}
+	return true
+}
+
+// Removes the values of a mode profile that earlier versions wrote to the environment file.
+// Those versions replaced the values on every command, so a file with all of them matching a
+// profile can't have deliberate overrides, and keeping them would stop the other mode's profile
+// from applying.
+func dropLegacyModeValues(doc *dotenvFile) {
+	values := doc.Values()
+	for _, profile := range []map[string]string{devProfile, prodProfile} {
+		if matchesProfile(values, profile) {
+			for key := range profile {
+				doc.Delete(key)
+			}
+			return
+		}
+	}
+}
+
+// Applies the profile of a mode, which takes precedence over the defaults but not over the
+// environment file
+func (this *GWEnvironment) setProfile(name string, profile map[string]string) {
+	this.profileName = name
+	this.profile = profile
+	for key, value := range profile {
+		this.env.SetDefault(key, value)
+	}
+}
+
+// SetDev applies the development mode profile.
+func (this *GWEnvironment) SetDev() {
+	this.setProfile("development", devProfile)
+}
+
+// SetProd applies the production mode profile.
+func (this *GWEnvironment) SetProd() {
+	this.setProfile("production", prodProfile)
+}
+
+// ApplyMode applies the profile of the mode Ghostwriter runs in.
+func (this *GWEnvironment) ApplyMode(mode DockerMode) {
+	if mode == ModeLocalDev {
+		this.SetDev()
+	} else {
+		this.SetProd()
+	}
+}
+
+// Override sets a value for the running command only. Overrides take precedence over every other
+// layer, are passed to Compose, and are never saved to the environment file.
+func (this *GWEnvironment) Override(key string, val string) {
+	if this.overrides == nil {
+		this.overrides = map[string]string{}
+	}
+	this.overrides[canonicalKey(key)] = val
+}
+
+// Source gets the layer the current value of a setting comes from.
+func (this *GWEnvironment) Source(key string) string {
+	key = canonicalKey(key)
+	_, override := this.overrides[key]
+	_, ref := this.refs[key]
+	_, profile := this.profile[key]
+	switch {
+	case override:
+		return LayerOverride
+	// Values changed by the command and resolved references take precedence over environment variables
+	case this.modified[key] || ref:
+		return LayerFile
+	case os.Getenv(strings.ToUpper(key)) != "":
+		return LayerEnvironment
+	case this.IsSet(key):
+		return LayerFile
+	case profile:
+		return LayerMode
+	}
+	return LayerDefault
+}
+
+// Gets the value of a setting in the environment file, including changes that weren't saved yet
+func (this *GWEnvironment) fileValue(key string) (string, bool) {
+	if this.modified[key] {
+		return this.env.GetString(key), true
+	}
+	keys := []string{key}
+	for alias, target := range settingAliases {
+		if target == key {
+			keys = append(keys, alias)
+		}
+	}
+	return this.doc.Lookup(keys...)
+}
+
+// SettingLayer is the value of a setting in one of the layers of the configuration.
+type SettingLayer struct {
+	// One of the `Layer*` constants
+	Layer string `json:"layer"`
+	Value string `json:"value"`
+	// Whether the layer has a value for the setting
+	Set bool `json:"set"`
+}
+
+// SettingExplanation shows where the value of a setting comes from.
+type SettingExplanation struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Layer the value comes from
+	Source string `json:"source"`
+	// Mode whose profile is applied, if any
+	Mode string `json:"mode,omitempty"`
+	// Every layer, from lowest to highest precedence
+	Layers []SettingLayer `json:"layers"`
+}
+
+// Explain shows the value of a setting in every layer of the configuration and which one is used.
+// Unknown settings return an error wrapping `ErrUnknownSetting`, unless the environment file has them.
+func (this *GWEnvironment) Explain(key string) (*SettingExplanation, error) {
+	key = canonicalKey(key)
+	setting, known := LookupSetting(key)
+	if !known && !this.IsSet(key) {
+		return nil, fmt.Errorf("%w: %s%s", ErrUnknownSetting, strings.ToUpper(key), didYouMean(key))
+	}
+
+	explanation := &SettingExplanation{
+		Key:    strings.ToUpper(key),
+		Value:  this.Get(key),
+		Source: this.Source(key),
+		Mode:   this.profileName,
+	}
+	defaultLayer := SettingLayer{Layer: LayerDefault}
+	if known {
+		defaultLayer.Set = true
+		defaultLayer.Value = fmt.Sprint(setting.Default)
+		if setting.PasswordLength > 0 {
+			defaultLayer.Value = fmt.Sprintf("random %d-character password", setting.PasswordLength)
+		}
+	}
+	modeValue, modeSet := this.profile[key]
+	fileValue, fileSet := this.fileValue(key)
+	environmentValue := os.Getenv(strings.ToUpper(key))
+	overrideValue, overrideSet := this.overrides[key]
+	explanation.Layers = []SettingLayer{
+		defaultLayer,
+		{Layer: LayerMode, Value: modeValue, Set: modeSet},
+		{Layer: LayerFile, Value: fileValue, Set: fileSet},
+		{Layer: LayerEnvironment, Value: environmentValue, Set: environmentValue != ""},
+		{Layer: LayerOverride, Value: overrideValue, Set: overrideSet},
+	}
+	return explanation, nil
+}
+
+// Gets the settings that Compose doesn't read from the environment file itself: those from the
+// mode profile and overrides, in order
+func (this *GWEnvironment) layeredKeys() []string {
+	keys := []string{}
+	for _, key := range slices.Sorted(maps.Keys(this.profile)) {
+		if this.Source(key) == LayerMode {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(this.overrides)) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModeProfile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DJANGO_SECURE_SSL_REDIRECT=false\n"), 0600))
+
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	env.SetProd()
+	assert.Equal(t, "false", env.Get("django_secure_ssl_redirect"), "Expected the environment file to take precedence over the mode")
+	assert.Equal(t, "true", env.Get("django_session_cookie_secure"))
+	assert.Equal(t, LayerFile, env.Source("django_secure_ssl_redirect"))
+	assert.Equal(t, LayerMode, env.Source("django_session_cookie_secure"))
+	assert.Equal(t, LayerDefault, env.Source("django_port"))
+
+	env.Set("django_csrf_cookie_secure", "true")
+	assert.NoError(t, env.Save())
+	data, err := os.ReadFile(filepath.Join(dir, ".env"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "DJANGO_SECURE_SSL_REDIRECT=false\n", "Expected the deliberate override to be kept")
+	assert.Contains(t, string(data), "DJANGO_CSRF_COOKIE_SECURE='true'", "Expected values set to the mode's value to be saved")
+	assert.NotContains(t, string(data), "DJANGO_SESSION_COOKIE_SECURE")
+	assert.NotContains(t, string(data), "HASURA_GRAPHQL_DEV_MODE")
+
+	env, err = ReadEnv(dir)
+	assert.NoError(t, err)
+	env.SetDev()
+	assert.Equal(t, "false", env.Get("django_session_cookie_secure"))
+	assert.Equal(t, "true", env.Get("django_csrf_cookie_secure"))
+	assert.Equal(t, []string{
+		"DJANGO_SESSION_COOKIE_SECURE=false",
+		"DJANGO_SETTINGS_MODULE=config.settings.local",
+		"HASURA_GRAPHQL_DEV_MODE=true",
+	}, env.ComposeEnv(), "Expected only the mode profile to be passed to Compose")
+}
+
+func TestSaveIgnoresEnvironmentVariables(t *testing.T) {
+	dir := t.TempDir()
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	env.Set("django_port", "8001")
+	assert.NoError(t, env.Save())
+	saved, err := os.ReadFile(filepath.Join(dir, ".env"))
+	assert.NoError(t, err)
+
+	t.Setenv("DJANGO_PORT", "9000")
+	t.Setenv("SMTP_HOST", "mail.example.com")
+	env, err = ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "9000", env.Get("django_port"))
+	assert.NoError(t, env.Save())
+	data, err := os.ReadFile(filepath.Join(dir, ".env"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(saved), string(data), "Expected environment variables to be left out of the file")
+	assert.Equal(t, LayerEnvironment, env.Source("django_port"))
+
+	env.Set("smtp_host", "mail.example.com")
+	assert.NoError(t, env.Save())
+	data, err = os.ReadFile(filepath.Join(dir, ".env"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "SMTP_HOST='mail.example.com'", "Expected values that were set to be saved")
+	assert.Contains(t, string(data), "DJANGO_PORT='8001'")
+}
+
+func TestLegacyModeValues(t *testing.T) {
+	dir := t.TempDir()
+	legacy := "DJANGO_CSRF_COOKIE_SECURE='true'\nDJANGO_PORT='8001'\nDJANGO_SECURE_SSL_REDIRECT='True'\n" +
+		"DJANGO_SESSION_COOKIE_SECURE='true'\nDJANGO_SETTINGS_MODULE='config.settings.production'\nHASURA_GRAPHQL_DEV_MODE='false'\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(legacy), 0600))
+
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	env.SetDev()
+	assert.Equal(t, "config.settings.local", env.Get("django_settings_module"), "Expected values written by earlier versions to be ignored")
+	assert.Equal(t, LayerMode, env.Source("django_settings_module"))
+	assert.NoError(t, env.Save())
+	data, err := os.ReadFile(filepath.Join(dir, ".env"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "DJANGO_SETTINGS_MODULE")
+	assert.Contains(t, string(data), "DJANGO_PORT='8001'")
+
+	// A profile with a value changed by hand is kept
+	changed := "DJANGO_CSRF_COOKIE_SECURE='true'\nDJANGO_SECURE_SSL_REDIRECT='false'\n" +
+		"DJANGO_SESSION_COOKIE_SECURE='true'\nDJANGO_SETTINGS_MODULE='config.settings.production'\nHASURA_GRAPHQL_DEV_MODE='false'\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(changed), 0600))
+	env, err = ReadEnv(dir)
+	assert.NoError(t, err)
+	env.SetDev()
+	assert.Equal(t, "config.settings.production", env.Get("django_settings_module"))
+}
+
+func TestOverride(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newFakeDockerInterface(t)
+	assert.NoError(t, dockerInterface.Env.Save())
+
+	dockerInterface.Env.Override("HASURA_GRAPHQL_ACTION_SECRET", "changeme")
+	dockerInterface.Env.Override("django_settings_module", "config.settings.test")
+	assert.Equal(t, "changeme", dockerInterface.Env.Get("hasura_graphql_action_secret"))
+	assert.Equal(t, LayerOverride, dockerInterface.Env.Source("django_settings_module"))
+	assert.NoError(t, dockerInterface.Up())
+	assert.Contains(t, runtime.ComposeEnv, "HASURA_GRAPHQL_ACTION_SECRET=changeme")
+	assert.Contains(t, runtime.ComposeEnv, "DJANGO_SETTINGS_MODULE=config.settings.test")
+	assert.NotContains(t, runtime.ComposeEnv, "DJANGO_SETTINGS_MODULE=config.settings.local")
+
+	assert.NoError(t, dockerInterface.Env.Save())
+	data, err := os.ReadFile(filepath.Join(dockerInterface.Dir, ".env"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "changeme", "Expected overrides not to be saved")
+	assert.NotContains(t, string(data), "config.settings.test")
+}
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DJANGO_PORT=8001\nADMIN_PASSWORD=secret\nCUSTOM_VALUE=1\n"), 0600))
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	env.SetProd()
+
+	explanation, err := env.Explain("django_secure_ssl_redirect")
+	assert.NoError(t, err)
+	assert.Equal(t, &SettingExplanation{
+		Key:    "DJANGO_SECURE_SSL_REDIRECT",
+		Value:  "true",
+		Source: LayerMode,
+		Mode:   "production",
+		Layers: []SettingLayer{
+			{Layer: LayerDefault, Value: "false", Set: true},
+			{Layer: LayerMode, Value: "true", Set: true},
+			{Layer: LayerFile},
+			{Layer: LayerEnvironment},
+			{Layer: LayerOverride},
+		},
+	}, explanation)
+
+	t.Setenv("DJANGO_PORT", "9000")
+	explanation, err = env.Explain("DJANGO_PORT")
+	assert.NoError(t, err)
+	assert.Equal(t, LayerEnvironment, explanation.Source)
+	assert.Equal(t, SettingLayer{Layer: LayerFile, Value: "8001", Set: true}, explanation.Layers[2])
+
+	explanation, err = env.Explain("django_superuser_password")
+	assert.NoError(t, err)
+	assert.Equal(t, LayerFile, explanation.Source)
+	assert.Equal(t, SettingLayer{Layer: LayerFile, Value: "secret", Set: true}, explanation.Layers[2], "Expected aliases in the file to be found")
+
+	env.Override("custom_value", "2")
+	explanation, err = env.Explain("CUSTOM_VALUE")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", explanation.Value)
+	assert.Equal(t, LayerOverride, explanation.Source)
+	assert.False(t, explanation.Layers[0].Set)
+
+	_, err = env.Explain("django_prot")
+	assert.ErrorIs(t, err, ErrUnknownSetting)
+}
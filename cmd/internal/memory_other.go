@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+
+package internal
+
+// Gets the memory available for new processes in bytes
+func availableMemory() (uint64, error) {
+	return 0, errUnsupported
+}
@@ -0,0 +1,252 @@
+package internal
+
+// Tracking of health check results over time and notifications for changes
+// Used by `healthcheck --watch` to report when containers or services go down or recover
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of health events
+const (
+	// A new issue was found
+	EventDown = "down"
+	// An existing issue changed severity or message
+	EventChanged = "changed"
+	// An issue is no longer present
+	EventRecovered = "recovered"
+)
+
+// HealthEvent is a change in the health of a container or service between two checks.
+type HealthEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Type     string    `json:"type"`
+	Service  string    `json:"service"`
+	Message  string    `json:"message"`
+	Severity Severity  `json:"severity"`
+	// Severity before the change, `SeverityOK` for new issues
+	Previous Severity `json:"previous_severity"`
+}
+
+func (this HealthEvent) String() string {
+	timestamp := this.Time.Format(time.RFC3339)
+	switch this.Event {
+	case EventRecovered:
+		return fmt.Sprintf("[+] %s %s %s recovered (was %s)", timestamp, this.Type, this.Service, this.Previous)
+	case EventChanged:
+		return fmt.Sprintf("[*] %s %s %s changed from %s to %s: %s", timestamp, this.Type, this.Service, this.Previous, this.Severity, this.Message)
+	default:
+		return fmt.Sprintf("[!] %s %s %s is %s: %s", timestamp, this.Type, this.Service, this.Severity, this.Message)
+	}
+}
+
+// HealthTracker remembers the issues from the previous check to find what changed.
+type HealthTracker struct {
+	previous map[string]HealthIssue
+}
+
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{previous: map[string]HealthIssue{}}
+}
+
+// Update records the issues from the latest check and returns the events since the previous check.
+// Everything is assumed to be healthy before the first check. Only changes of severity are events,
+// since many messages include measurements that change on every check, such as response times.
+func (this *HealthTracker) Update(issues HealthIssues, now time.Time) []HealthEvent {
+	var events []HealthEvent
+	current := map[string]HealthIssue{}
+	for _, issue := range issues {
+		key := issue.Type + "/" + issue.Service
+		current[key] = issue
+
+		previous, found := this.previous[key]
+		if !found {
+			events = append(events, HealthEvent{
+				Time: now, Event: EventDown, Type: issue.Type, Service: issue.Service,
+				Message: issue.Message, Severity: issue.Severity, Previous: SeverityOK,
+			})
+		} else if previous.Severity != issue.Severity {
+			events = append(events, HealthEvent{
+				Time: now, Event: EventChanged, Type: issue.Type, Service: issue.Service,
+				Message: issue.Message, Severity: issue.Severity, Previous: previous.Severity,
+			})
+		}
+	}
+
+	for key, previous := range this.previous {
+		if _, found := current[key]; !found {
+			events = append(events, HealthEvent{
+				Time: now, Event: EventRecovered, Type: previous.Type, Service: previous.Service,
+				Message: previous.Message, Severity: SeverityOK, Previous: previous.Severity,
+			})
+		}
+	}
+	this.previous = current
+
+	// Map iteration order is random, so keep the output stable
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			return events[i].Type < events[j].Type
+		}
+		return events[i].Service < events[j].Service
+	})
+	return events
+}
+
+// Notifier delivers health events somewhere outside of the CLI.
+type Notifier interface {
+	Notify(event HealthEvent) error
+}
+
+// StdoutNotifier prints events as messages or, if `JSON` is set, as JSON lines.
+type StdoutNotifier struct {
+	Writer io.Writer
+	JSON   bool
+}
+
+func (this *StdoutNotifier) Notify(event HealthEvent) error {
+	if this.JSON {
+		return json.NewEncoder(this.Writer).Encode(event)
+	}
+	_, err := fmt.Fprintln(this.Writer, event)
+	return err
+}
+
+// FileNotifier appends events to a file as JSON lines.
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (this *FileNotifier) Notify(event HealthEvent) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(this.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", this.Path, err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write to %s: %w", this.Path, err)
+	}
+	return nil
+}
+
+// WebhookNotifier sends each event as a JSON document in a POST request.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (this *WebhookNotifier) Notify(event HealthEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, this.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Ghostwriter-CLI")
+
+	res, err := this.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send webhook: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// ExecNotifier runs a local script for each event. The event is passed as JSON on stdin and
+// the main fields are also available as `GHOSTWRITER_*` environment variables.
+type ExecNotifier struct {
+	// Program and its arguments, separated by whitespace like `exec:` references
+	Command string
+	Timeout time.Duration
+}
+
+// NewExecNotifier checks that the program of the command can be found before any events are sent.
+func NewExecNotifier(command string) (*ExecNotifier, error) {
+	args := splitCommand(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command to run for notifications")
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("could not find the notification script %s: %w", args[0], err)
+	}
+	return &ExecNotifier{Command: command}, nil
+}
+
+func (this *ExecNotifier) Notify(event HealthEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	args := splitCommand(this.Command)
+	if len(args) == 0 {
+		return fmt.Errorf("no command to run for notifications")
+	}
+
+	command := exec.Command(args[0], args[1:]...)
+	command.Stdin = bytes.NewReader(body)
+	command.Stdout = os.Stderr
+	command.Stderr = os.Stderr
+	command.Env = append(os.Environ(),
+		"GHOSTWRITER_EVENT="+event.Event,
+		"GHOSTWRITER_TYPE="+event.Type,
+		"GHOSTWRITER_SERVICE="+event.Service,
+		"GHOSTWRITER_SEVERITY="+strings.ToLower(event.Severity.String()),
+		"GHOSTWRITER_PREVIOUS_SEVERITY="+strings.ToLower(event.Previous.String()),
+		"GHOSTWRITER_MESSAGE="+event.Message,
+	)
+
+	if err := command.Start(); err != nil {
+		return fmt.Errorf("could not run %s: %w", this.Command, err)
+	}
+	timeout := this.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	done := make(chan error, 1)
+	go func() { done <- command.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", this.Command, err)
+		}
+		return nil
+	case <-time.After(timeout):
+		command.Process.Kill()
+		<-done
+		return fmt.Errorf("%s did not finish within %s", this.Command, timeout)
+	}
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthTrackerTransitions(t *testing.T) {
+	tracker := NewHealthTracker()
+	now := time.Now()
+	postgresDown := HealthIssue{Type: "Container", Service: "POSTGRES", Message: "Container is not running", Severity: SeverityCritical}
+	queueDown := HealthIssue{Type: "Container", Service: "QUEUE", Message: "Container is not running", Severity: SeverityWarning}
+
+	events := tracker.Update(HealthIssues{}, now)
+	assert.Equal(t, 0, len(events), "Expected no events while healthy")
+
+	events = tracker.Update(HealthIssues{postgresDown, queueDown}, now)
+	assert.Equal(t, 2, len(events), "Expected an event for each new issue")
+	assert.Equal(t, EventDown, events[0].Event)
+	assert.Equal(t, "POSTGRES", events[0].Service)
+	assert.Equal(t, SeverityCritical, events[0].Severity)
+
+	events = tracker.Update(HealthIssues{postgresDown, queueDown}, now)
+	assert.Equal(t, 0, len(events), "Expected no events when nothing changed")
+
+	queueDown.Severity = SeverityCritical
+	events = tracker.Update(HealthIssues{queueDown}, now)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, EventRecovered, events[0].Event)
+	assert.Equal(t, "POSTGRES", events[0].Service)
+	assert.Equal(t, SeverityCritical, events[0].Previous)
+	assert.Equal(t, EventChanged, events[1].Event)
+	assert.Equal(t, SeverityWarning, events[1].Previous)
+}
+
+func TestHealthTrackerIgnoresMessageChanges(t *testing.T) {
+	tracker := NewHealthTracker()
+	now := time.Now()
+	slow := HealthIssue{Type: "Service", Service: "DJANGO", Message: "Slow response (2.1s)", Severity: SeverityWarning}
+
+	events := tracker.Update(HealthIssues{slow}, now)
+	assert.Equal(t, 1, len(events))
+
+	slow.Message = "Slow response (2.4s)"
+	events = tracker.Update(HealthIssues{slow}, now)
+	assert.Equal(t, 0, len(events), "Expected no events while the warning persists")
+
+	slow.Message = "Slow response (6.2s)"
+	slow.Severity = SeverityCritical
+	events = tracker.Update(HealthIssues{slow}, now)
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, EventChanged, events[0].Event)
+		assert.Equal(t, "Slow response (6.2s)", events[0].Message, "Expected the latest message in the event")
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	notifier := &FileNotifier{Path: path}
+	assert.NoError(t, notifier.Notify(HealthEvent{Event: EventDown, Service: "POSTGRES", Severity: SeverityCritical}))
+	assert.NoError(t, notifier.Notify(HealthEvent{Event: EventRecovered, Service: "POSTGRES", Previous: SeverityCritical}))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, 2, len(lines), "Expected one JSON line per event")
+
+	var event HealthEvent
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, EventRecovered, event.Event)
+	assert.Equal(t, SeverityCritical, event.Previous)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received HealthEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		if received.Service == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL)
+	assert.NoError(t, notifier.Notify(HealthEvent{Event: EventDown, Service: "REDIS", Severity: SeverityCritical}))
+	assert.Equal(t, "REDIS", received.Service)
+	assert.Error(t, notifier.Notify(HealthEvent{Service: "fail"}), "Expected an error for a non-2xx response")
+}
+
+func TestExecNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Test script requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+	script := filepath.Join(dir, "notify.sh")
+	content := "#!/bin/sh\necho \"$1 $GHOSTWRITER_EVENT $GHOSTWRITER_SERVICE $GHOSTWRITER_SEVERITY\" > " + output + "\ncat >> " + output + "\n"
+	assert.NoError(t, os.WriteFile(script, []byte(content), 0700))
+
+	notifier, err := NewExecNotifier(script + "  --team")
+	assert.NoError(t, err)
+	assert.NoError(t, notifier.Notify(HealthEvent{Event: EventDown, Service: "NGINX", Severity: SeverityWarning}))
+
+	result, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(result), "--team down NGINX warning\n{"), "Expected arguments, environment variables, and JSON on stdin, got %q", result)
+
+	_, err = NewExecNotifier(filepath.Join(dir, "missing.sh") + " --team ops")
+	assert.ErrorContains(t, err, "missing.sh", "Expected an error for a missing script")
+	_, err = NewExecNotifier(" ")
+	assert.Error(t, err)
+	notifier = &ExecNotifier{Command: filepath.Join(dir, "missing.sh")}
+	assert.Error(t, notifier.Notify(HealthEvent{}), "Expected an error for a missing script")
+}
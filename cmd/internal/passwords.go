@@ -4,7 +4,7 @@ package internal
 
 import (
 	"crypto/rand"
-	"log"
+	"fmt"
 	"math/big"
 	"strings"
 )
@@ -13,7 +13,7 @@ import (
 // The password will be comprised of a-zA-Z0-9 and !@#$%^&*()_-+=/?<>.,
 // Special characters exclude the following: '";:`~\/|
 // Exclusions are to help avoid issues with escaping and breaking quotes in env files
-func GenerateRandomPassword(pwLength int, safe bool) string {
+func GenerateRandomPassword(pwLength int, safe bool) (string, error) {
 	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!@#$%^&*()_-+=/?<>.,")
 	if safe {
 		chars = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789")
@@ -22,9 +22,9 @@ func GenerateRandomPassword(pwLength int, safe bool) string {
 	for i := 0; i < pwLength; i++ {
 		nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
 		if err != nil {
-			log.Fatalf("Failed to generate random number for password generation\n")
+			return "", fmt.Errorf("failed to generate random number for password generation: %w", err)
 		}
 		b.WriteRune(chars[nBig.Int64()])
 	}
-	return b.String()
+	return b.String(), nil
 }
@@ -0,0 +1,184 @@
+package internal
+
+// Probes that check each Ghostwriter service directly, instead of relying on Django's /status/ endpoint
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Timeout for the HTTP and WebSocket probes
+const probeTimeout = 5 * time.Second
+
+// Path of the collaboration server's WebSocket endpoint behind Nginx
+const collabPath = "/collab/"
+
+// Commands run inside the containers, using the containers' own environment for credentials
+const (
+	pgIsReadyScript = `pg_isready -h localhost -U "$POSTGRES_USER" -d "$POSTGRES_DB"`
+	psqlScript      = `PGPASSWORD="$POSTGRES_PASSWORD" psql -h localhost -U "$POSTGRES_USER" -d "$POSTGRES_DB" -tAc "SELECT 1"`
+)
+
+// GUID from RFC 6455 used to compute the `Sec-WebSocket-Accept` header
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+type serviceProbe struct {
+	service string
+	// Severity when the probe fails
+	severity Severity
+	// Returns a short description of the result
+	run func() (string, error)
+}
+
+// ProbeServices checks each service independently and returns one result per probe with its latency.
+// Services that are working are included with `SeverityOK`.
+func (this *DockerInterface) ProbeServices() HealthIssues {
+	probes := []serviceProbe{
+		{"postgres", SeverityCritical, this.probePostgres},
+		{"redis", SeverityCritical, this.probeRedis},
+		{"hasura", SeverityCritical, this.probeHasura},
+		{"queue", SeverityWarning, this.probeQueue},
+	}
+	// The collaboration server is only reachable through Nginx, which the development environment doesn't run
+	if !this.UseDevInfra {
+		probes = append(probes, serviceProbe{"collab", SeverityWarning, this.probeCollab})
+	}
+
+	var results HealthIssues
+	for _, probe := range probes {
+		start := time.Now()
+		message, err := probe.run()
+		result := HealthIssue{
+			Type:     "Probe",
+			Service:  probe.service,
+			Message:  message,
+			Severity: SeverityOK,
+			Latency:  time.Since(start).Seconds(),
+		}
+		if err != nil {
+			result.Message = err.Error()
+			result.Severity = probe.severity
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Checks that PostgreSQL accepts connections and can run a query
+func (this *DockerInterface) probePostgres() (string, error) {
+	out, err := this.RunComposeCmdWithOutput("exec", "-T", "postgres", "sh", "-c", pgIsReadyScript)
+	if err != nil {
+		return "", fmt.Errorf("pg_isready failed: %s", commandFailure(out, err))
+	}
+	out, err = this.RunComposeCmdWithOutput("exec", "-T", "postgres", "sh", "-c", psqlScript)
+	if err != nil {
+		return "", fmt.Errorf("could not query the database: %s", commandFailure(out, err))
+	}
+	if strings.TrimSpace(out) != "1" {
+		return "", fmt.Errorf("unexpected query result: %s", strings.TrimSpace(out))
+	}
+	return "Accepting connections and queries", nil
+}
+
+// Checks that Redis answers a PING
+func (this *DockerInterface) probeRedis() (string, error) {
+	out, err := this.RunComposeCmdWithOutput("exec", "-T", "redis", "redis-cli", "ping")
+	if err != nil {
+		return "", fmt.Errorf("redis-cli ping failed: %s", commandFailure(out, err))
+	}
+	if strings.TrimSpace(out) != "PONG" {
+		return "", fmt.Errorf("unexpected reply to PING: %s", strings.TrimSpace(out))
+	}
+	return "Replied to PING", nil
+}
+
+// Checks for the django-q cluster's heartbeat, which the cluster refreshes in Redis every few seconds
+func (this *DockerInterface) probeQueue() (string, error) {
+	cluster := this.Env.Get("django_qcluster_name")
+	pattern := fmt.Sprintf("django_q:%s:cluster:*", cluster)
+	out, err := this.RunComposeCmdWithOutput("exec", "-T", "redis", "redis-cli", "--scan", "--pattern", pattern)
+	if err != nil {
+		return "", fmt.Errorf("could not read the %s cluster's heartbeat: %s", cluster, commandFailure(out, err))
+	}
+	if strings.TrimSpace(out) == "" {
+		return "", fmt.Errorf("no heartbeat from the %s cluster", cluster)
+	}
+	return fmt.Sprintf("Heartbeat from the %s cluster", cluster), nil
+}
+
+// Checks Hasura's health endpoint on the configured port
+func (this *DockerInterface) probeHasura() (string, error) {
+	url := fmt.Sprintf("http://localhost:%s/healthz", this.Env.Get("hasura_graphql_server_port"))
+	client := http.Client{Timeout: probeTimeout}
+	res, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("could not reach %s: %w", url, err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned status code %d: %s", url, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return "Health endpoint returned OK", nil
+}
+
+// Checks that the collaboration server completes a WebSocket handshake through Nginx
+func (this *DockerInterface) probeCollab() (string, error) {
+	url := fmt.Sprintf("https://localhost:%s%s", this.Env.Get("nginx_port"), collabPath)
+	if err := websocketHandshake(url); err != nil {
+		return "", err
+	}
+	return "Completed WebSocket handshake", nil
+}
+
+// Performs a WebSocket opening handshake (RFC 6455) and closes the connection
+func websocketHandshake(url string) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	client := http.Client{Timeout: probeTimeout, Transport: transport}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach %s: %w", url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("%s did not switch protocols (status code %d)", url, res.StatusCode)
+	}
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	if res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(hash[:]) {
+		return errors.New("invalid Sec-WebSocket-Accept header in the handshake response")
+	}
+	return nil
+}
+
+// Describes a failed command using its output, if there was any
+func commandFailure(out string, err error) string {
+	out = strings.TrimSpace(out)
+	if out != "" {
+		return out
+	}
+	return err.Error()
+}
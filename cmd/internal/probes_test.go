@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeServices(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newFakeDockerInterface(t)
+
+	hasura := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/healthz", r.URL.Path)
+		w.Write([]byte("OK"))
+	}))
+	defer hasura.Close()
+	serverUrl, _ := url.Parse(hasura.URL)
+	dockerInterface.Env.Set("hasura_graphql_server_port", serverUrl.Port())
+
+	runtime.Outputs["compose -f local.yml exec -T postgres sh -c "+pgIsReadyScript] = "localhost:5432 - accepting connections\n"
+	runtime.Outputs["compose -f local.yml exec -T postgres sh -c "+psqlScript] = "1\n"
+	runtime.Outputs["compose -f local.yml exec -T redis redis-cli ping"] = "PONG\n"
+	runtime.Errors["compose -f local.yml exec -T redis redis-cli --scan"] = errors.New("exit status 1")
+
+	results := dockerInterface.ProbeServices()
+	assert.Equal(t, 4, len(results), "Expected one result per probe without the collab server in development")
+	for _, result := range results {
+		if result.Service == "queue" {
+			assert.Equal(t, SeverityWarning, result.Severity, "Expected a missing queue heartbeat to be a warning")
+		} else {
+			assert.Equal(t, SeverityOK, result.Severity, "Expected %s probe to pass: %s", result.Service, result.Message)
+		}
+	}
+
+	delete(runtime.Errors, "compose -f local.yml exec -T redis redis-cli --scan")
+	runtime.Outputs["compose -f local.yml exec -T redis redis-cli ping"] = "LOADING\n"
+	runtime.Outputs["compose -f local.yml exec -T redis redis-cli --scan --pattern django_q:soar:cluster:*"] = "django_q:soar:cluster:abc\n"
+	results = dockerInterface.ProbeServices()
+	assert.Equal(t, SeverityCritical, results[1].Severity, "Expected an unexpected PING reply to be critical")
+	assert.Equal(t, SeverityOK, results[3].Severity, "Expected the queue heartbeat to be found")
+}
+
+func TestWebsocketHandshake(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		hash := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+		w.Header().Set("Connection", "Upgrade")
+		w.Header().Set("Upgrade", "websocket")
+		w.Header().Set("Sec-WebSocket-Accept", base64.StdEncoding.EncodeToString(hash[:]))
+		w.WriteHeader(http.StatusSwitchingProtocols)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, websocketHandshake(server.URL+collabPath))
+
+	plain := httptest.NewTLSServer(http.NotFoundHandler())
+	defer plain.Close()
+	assert.Error(t, websocketHandshake(plain.URL+collabPath), "Expected an error when the server doesn't switch protocols")
+}
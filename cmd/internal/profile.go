@@ -0,0 +1,175 @@
+package internal
+
+// Exporting the configuration as a profile and importing it into another deployment
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const configProfileVersion = 1
+
+// ConfigProfile is a portable copy of the configuration, written as YAML or JSON.
+type ConfigProfile struct {
+	Version int `json:"version" yaml:"version"`
+	// Values keyed by setting
+	Settings map[string]string `json:"settings" yaml:"settings"`
+	// Settings left out of the profile, which keep or generate their own values when imported
+	Excluded []string `json:"excluded,omitempty" yaml:"excluded,omitempty"`
+}
+
+// Options for `ExportProfile`
+type ExportOptions struct {
+	// Leave out passwords, keys, and other secrets
+	ExcludeSecrets bool
+	// Leave out settings that depend on the server, such as its hostnames
+	ExcludeHostSpecific bool
+}
+
+// ExportProfile copies the configuration into a profile. Only the values of the environment file and
+// the defaults are exported; the mode profile, environment variables, and overrides are left out,
+// since they belong to this deployment.
+func (this *GWEnvironment) ExportProfile(opts *ExportOptions) *ConfigProfile {
+	profile := &ConfigProfile{Version: configProfileVersion, Settings: this.Values()}
+	for _, key := range slices.Sorted(maps.Keys(profile.Settings)) {
+		switch this.Source(key) {
+		case LayerMode:
+			delete(profile.Settings, key)
+			continue
+		case LayerEnvironment, LayerOverride:
+			value, found := this.fileValue(key)
+			if !found {
+				delete(profile.Settings, key)
+				continue
+			}
+			profile.Settings[key] = value
+		}
+		setting, known := LookupSetting(key)
+		// Empty secrets are kept, so importing them doesn't report them as missing
+		secret := IsSecret(key) && profile.Settings[key] != ""
+		if (opts.ExcludeSecrets && secret) || (opts.ExcludeHostSpecific && known && setting.HostSpecific) {
+			delete(profile.Settings, key)
+			profile.Excluded = append(profile.Excluded, key)
+		}
+	}
+	return profile
+}
+
+// ParseProfile reads a profile written as YAML or JSON.
+func ParseProfile(data []byte) (*ConfigProfile, error) {
+	var profile ConfigProfile
+	// JSON is also valid YAML
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("could not parse the profile: %w", err)
+	}
+	if profile.Version != configProfileVersion {
+		return nil, fmt.Errorf("unsupported profile version %d", profile.Version)
+	}
+	normalized := map[string]string{}
+	for key, value := range profile.Settings {
+		key = strings.ToLower(key)
+		if target, alias := settingAliases[key]; alias {
+			key = target
+		}
+		normalized[key] = value
+	}
+	profile.Settings = normalized
+	return &profile, nil
+}
+
+// Results of importing a setting
+const (
+	ImportSet       = "set"
+	ImportUnchanged = "unchanged"
+	ImportConflict  = "conflict"
+	ImportInvalid   = "invalid"
+	ImportKept      = "kept"
+	ImportGenerated = "generated"
+	ImportMissing   = "missing"
+	ImportReference = "reference"
+)
+
+// ProfileChange is the result of importing a setting from a profile.
+type ProfileChange struct {
+	Key string `json:"key"`
+	// One of the `Import*` results
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+// Options for `ImportProfile`
+type ProfileImportOptions struct {
+	// Replace values that were changed from their defaults in this deployment
+	Overwrite bool
+	// Import `file:`, `env:`, and `exec:` references, which are read or run on this host
+	AllowRefs bool
+}
+
+// ImportProfile merges a profile into the configuration, without saving it.
+//
+// Settings that still have their default value here are set from the profile. Settings changed from
+// their defaults here are conflicts and keep their values, unless `Overwrite` is set. Generated
+// passwords that were excluded from the profile keep the values in the environment file, or get new
+// ones if the file doesn't have them yet. References to external sources are skipped unless
+// `AllowRefs` is set, since an `exec:` reference runs a command whenever the containers are managed.
+func (this *GWEnvironment) ImportProfile(profile *ConfigProfile, opts *ProfileImportOptions) []ProfileChange {
+	defaults := DefaultValues()
+	changes := []ProfileChange{}
+	for _, key := range slices.Sorted(maps.Keys(profile.Settings)) {
+		value := profile.Settings[key]
+		current := this.Get(key)
+		change := ProfileChange{Key: key, Result: ImportSet}
+
+		if IsSecretRef(value) && current != value {
+			if !opts.AllowRefs {
+				changes = append(changes, ProfileChange{Key: key, Result: ImportReference, Message: fmt.Sprintf("Skipped %q; use --allow-refs to import references", value)})
+				continue
+			}
+			change.Message = fmt.Sprintf("Reads the value from %q", value)
+		}
+
+		if err := ValidateSetting(key, value); err != nil {
+			if _, known := LookupSetting(key); known {
+				changes = append(changes, ProfileChange{Key: key, Result: ImportInvalid, Message: err.Error()})
+				continue
+			}
+			change.Message = "Not a known setting"
+		}
+
+		switch {
+		case current == value:
+			change.Result = ImportUnchanged
+		case this.IsSet(key) && current != defaults[key] && !opts.Overwrite:
+			change.Result = ImportConflict
+			change.Message = "Kept the value set here; use --overwrite to replace it"
+		default:
+			this.Set(key, value)
+			if key == "postgres_password" {
+				change.Message = "Only works for a new database; use `config rotate` for an existing one"
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	for _, key := range profile.Excluded {
+		setting, found := LookupSetting(key)
+		switch {
+		case !found || !setting.Secret:
+			continue
+		case setting.PasswordLength == 0:
+			if this.Get(setting.Key) == "" {
+				changes = append(changes, ProfileChange{Key: setting.Key, Result: ImportMissing, Message: "Excluded from the profile; set it with `config set`"})
+			}
+		case this.IsSet(setting.Key):
+			changes = append(changes, ProfileChange{Key: setting.Key, Result: ImportKept, Message: "Excluded from the profile"})
+		default:
+			// The default is a new random password, which is written when the file is saved
+			changes = append(changes, ProfileChange{Key: setting.Key, Result: ImportGenerated, Message: "Excluded from the profile"})
+		}
+	}
+	return changes
+}
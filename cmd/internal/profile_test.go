@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportProfile(t *testing.T) {
+	env, err := ReadEnv(t.TempDir())
+	assert.NoError(t, err)
+	env.Set("django_date_format", "Y M d")
+
+	profile := env.ExportProfile(&ExportOptions{})
+	assert.Equal(t, "Y M d", profile.Settings["django_date_format"])
+	assert.Equal(t, env.Get("postgres_password"), profile.Settings["postgres_password"])
+	assert.NotContains(t, profile.Settings, "hasura_password", "Expected aliases to be left out")
+	assert.Empty(t, profile.Excluded)
+
+	profile = env.ExportProfile(&ExportOptions{ExcludeSecrets: true, ExcludeHostSpecific: true})
+	assert.NotContains(t, profile.Settings, "postgres_password")
+	assert.NotContains(t, profile.Settings, "django_allowed_hosts")
+	assert.Contains(t, profile.Settings, "django_mailgun_api_key", "Expected empty secrets to be kept")
+	assert.Equal(t, []string{
+		"django_allowed_hosts", "django_csrf_trusted_origins", "django_jwt_secret_key", "django_secret_key",
+		"django_superuser_password", "hasura_graphql_action_secret", "hasura_graphql_admin_secret", "nginx_port", "postgres_password",
+	}, profile.Excluded)
+
+	// Profiles can be read back from YAML and JSON
+	for _, marshal := range []func(any) ([]byte, error){yaml.Marshal, json.Marshal} {
+		data, err := marshal(profile)
+		assert.NoError(t, err)
+		parsed, err := ParseProfile(data)
+		assert.NoError(t, err)
+		assert.Equal(t, profile, parsed)
+	}
+	_, err = ParseProfile([]byte("version: 2\n"))
+	assert.ErrorContains(t, err, "unsupported profile version")
+}
+
+func TestExportProfileBetweenModes(t *testing.T) {
+	t.Setenv("DJANGO_PORT", "9000")
+	prodDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(prodDir, ".env"), []byte("DJANGO_DATE_FORMAT='Y M d'\nDJANGO_CSRF_COOKIE_SECURE='false'\n"), 0600))
+	prod, err := ReadEnv(prodDir)
+	assert.NoError(t, err)
+	prod.SetProd()
+	prod.Override("django_qcluster_name", "override")
+
+	profile := prod.ExportProfile(&ExportOptions{})
+	for key := range prodProfile {
+		if key != "django_csrf_cookie_secure" {
+			assert.NotContains(t, profile.Settings, key, "Expected the mode profile to be left out")
+		}
+	}
+	assert.Equal(t, "false", profile.Settings["django_csrf_cookie_secure"], "Expected values in the file to be kept")
+	assert.NotContains(t, profile.Settings, "django_port", "Expected environment variables to be left out")
+	assert.NotEqual(t, "override", profile.Settings["django_qcluster_name"], "Expected overrides to be left out")
+
+	devDir := t.TempDir()
+	dev, err := ReadEnv(devDir)
+	assert.NoError(t, err)
+	dev.SetDev()
+	dev.ImportProfile(profile, &ProfileImportOptions{})
+	assert.NoError(t, dev.Save())
+	data, err := os.ReadFile(filepath.Join(devDir, ".env"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "DJANGO_DATE_FORMAT='Y M d'")
+	assert.NotContains(t, string(data), "DJANGO_SETTINGS_MODULE")
+	assert.NotContains(t, string(data), "HASURA_GRAPHQL_DEV_MODE")
+	assert.NotContains(t, string(data), "9000")
+
+	dev, err = ReadEnv(devDir)
+	assert.NoError(t, err)
+	dev.SetDev()
+	assert.Equal(t, "config.settings.local", dev.Get("django_settings_module"))
+	assert.Equal(t, "true", dev.Get("hasura_graphql_dev_mode"))
+}
+
+func TestImportProfile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DJANGO_PORT='9000'\nDJANGO_DATE_FORMAT='d M Y'\nPOSTGRES_PASSWORD='existing'\n"), 0600))
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	secretKey := env.Get("django_secret_key")
+
+	profile := &ConfigProfile{
+		Version: configProfileVersion,
+		Settings: map[string]string{
+			"django_date_format":   "Y M d",
+			"django_port":          "8001",
+			"django_qcluster_name": "soar",
+			"nginx_port":           "banana",
+			"smtp_host":            "mail",
+		},
+		Excluded: []string{"postgres_password", "django_secret_key", "django_mailgun_api_key", "django_allowed_hosts"},
+	}
+	changes := env.ImportProfile(profile, &ProfileImportOptions{})
+	results := map[string]string{}
+	for _, change := range changes {
+		results[change.Key] = change.Result
+	}
+	assert.Equal(t, map[string]string{
+		"django_date_format":     ImportSet,
+		"django_port":            ImportConflict,
+		"django_qcluster_name":   ImportUnchanged,
+		"nginx_port":             ImportInvalid,
+		"smtp_host":              ImportSet,
+		"postgres_password":      ImportKept,
+		"django_secret_key":      ImportGenerated,
+		"django_mailgun_api_key": ImportMissing,
+	}, results)
+	assert.Equal(t, "Y M d", env.Get("django_date_format"))
+	assert.Equal(t, "9000", env.Get("django_port"), "Expected conflicts to keep their values")
+	assert.Equal(t, "443", env.Get("nginx_port"), "Expected invalid values to be skipped")
+	assert.Equal(t, "existing", env.Get("postgres_password"))
+	assert.Equal(t, secretKey, env.Get("django_secret_key"))
+
+	env.ImportProfile(profile, &ProfileImportOptions{Overwrite: true})
+	assert.Equal(t, "8001", env.Get("django_port"), "Expected conflicts to be replaced with --overwrite")
+}
+
+func TestImportProfileReferences(t *testing.T) {
+	dir := t.TempDir()
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	profile := &ConfigProfile{
+		Version: configProfileVersion,
+		Settings: map[string]string{
+			"django_mailgun_api_key": "exec:sh -c whoami",
+			"django_date_format":     "Y M d",
+		},
+	}
+
+	changes := env.ImportProfile(profile, &ProfileImportOptions{})
+	assert.Equal(t, []ProfileChange{
+		{Key: "django_date_format", Result: ImportSet},
+		{Key: "django_mailgun_api_key", Result: ImportReference, Message: `Skipped "exec:sh -c whoami"; use --allow-refs to import references`},
+	}, changes)
+	assert.Equal(t, "", env.Get("django_mailgun_api_key"), "Expected the reference to be skipped")
+
+	changes = env.ImportProfile(profile, &ProfileImportOptions{AllowRefs: true})
+	assert.Equal(t, ProfileChange{Key: "django_mailgun_api_key", Result: ImportSet, Message: `Reads the value from "exec:sh -c whoami"`}, changes[1])
+	assert.Equal(t, "exec:sh -c whoami", env.Get("django_mailgun_api_key"))
+}
@@ -0,0 +1,190 @@
+package internal
+
+// Checks of the host's disk space and memory and of the containers' resource usage
+// Thresholds come from the `healthcheck_disk_usage_max`, `healthcheck_mem_min`, and
+// `healthcheck_container_mem_max` settings
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/adrg/xdg"
+)
+
+// Returned by host checks that are not implemented for the current operating system
+var errUnsupported = errors.New("not supported on this platform")
+
+// CheckResources checks disk space, memory, volume sizes, and the resource usage of the running
+// Ghostwriter containers. Every check is returned, including the ones that passed with `SeverityOK`.
+// Checks that can't be run are returned with `SeverityUnknown`.
+func (this *DockerInterface) CheckResources() HealthIssues {
+	diskUsageMax := this.envInt("healthcheck_disk_usage_max", 90)
+	memMin := uint64(this.envInt("healthcheck_mem_min", 100)) * 1024 * 1024
+	containerMemMax := this.envInt("healthcheck_container_mem_max", 90)
+
+	var results HealthIssues
+	add := func(service string, severity Severity, format string, args ...any) {
+		results = append(results, HealthIssue{
+			Type:     "Resource",
+			Service:  service,
+			Message:  fmt.Sprintf(format, args...),
+			Severity: severity,
+		})
+	}
+
+	// Disk space where Docker stores images and volumes, if the engine runs on this host
+	var rootFree uint64
+	rootChecked := false
+	info, err := this.Runtime.Info()
+	if err != nil {
+		add("docker_disk", SeverityUnknown, "Could not get Docker's data directory: %s", err)
+	} else if _, statErr := os.Stat(info.RootDir); info.RootDir != "" && statErr == nil {
+		free, severity, message := diskCheck(info.RootDir, diskUsageMax)
+		add("docker_disk", severity, "%s", message)
+		rootFree, rootChecked = free, severity != SeverityUnknown
+	}
+
+	// Disk space where Ghostwriter CLI keeps its data, which is the same in every mode
+	_, severity, message := diskCheck(dataDir(), diskUsageMax)
+	add("data_disk", severity, "%s", message)
+
+	available, err := availableMemory()
+	if errors.Is(err, errUnsupported) {
+		// Skip the check rather than reporting a problem on every run
+	} else if err != nil {
+		add("memory", SeverityUnknown, "Could not get available memory: %s", err)
+	} else if available < memMin {
+		add("memory", SeverityWarning, "%s of memory available, below the minimum of %s", formatBytes(available), formatBytes(memMin))
+	} else {
+		add("memory", SeverityOK, "%s of memory available", formatBytes(available))
+	}
+
+	// Volumes that grow with use, compared to the free space so there's room to back them up
+	sizes, err := this.Runtime.VolumeSizes()
+	if err != nil {
+		add("volumes", SeverityUnknown, "Could not get volume sizes: %s", err)
+	} else {
+		for _, volume := range this.dataVolumeKeys() {
+			name, err := this.GetVolumeNameFromConfig(volume.key)
+			if err != nil {
+				add(volume.service, SeverityUnknown, "Could not get the volume name: %s", err)
+				continue
+			}
+			size, found := sizes[name]
+			if !found {
+				continue
+			}
+			size64 := uint64(max(size, 0))
+			if rootChecked && size64 > rootFree {
+				add(volume.service, SeverityWarning, "Volume %s uses %s, more than the %s of free disk space needed to back it up", name, formatBytes(size64), formatBytes(rootFree))
+			} else {
+				add(volume.service, SeverityOK, "Volume %s uses %s", name, formatBytes(size64))
+			}
+		}
+	}
+
+	results = append(results, this.checkContainerResources(containerMemMax)...)
+	return results
+}
+
+// Gets Ghostwriter CLI's XDG data directory, or the XDG data home if it wasn't created yet
+func dataDir() string {
+	dir := filepath.Join(xdg.DataHome, "ghostwriter")
+	if !DirExists(dir) {
+		return xdg.DataHome
+	}
+	return dir
+}
+
+type dataVolume struct {
+	service string
+	key     string
+}
+
+// Gets the keys of the database and media volumes in the compose file for the current mode
+func (this *DockerInterface) dataVolumeKeys() []dataVolume {
+	if this.UseDevInfra {
+		return []dataVolume{{"postgres_volume", "local_postgres_data"}, {"media_volume", "local_data"}}
+	}
+	return []dataVolume{{"postgres_volume", "production_postgres_data"}, {"media_volume", "production_data"}}
+}
+
+// Gets the CPU and memory usage of each running Ghostwriter container
+// Containers using more than `memUsageMax` percent of their memory limit (or the host's memory, if they
+// have no limit) are a warning.
+func (this *DockerInterface) checkContainerResources(memUsageMax int) HealthIssues {
+	containers, err := this.GetRunning()
+	if err != nil {
+		return HealthIssues{{Type: "Resource", Service: "containers", Message: fmt.Sprintf("Could not list containers: %s", err), Severity: SeverityUnknown}}
+	}
+
+	// Each stats request waits for a second sample, so fetch them all at once
+	results := make(HealthIssues, len(containers))
+	var wg sync.WaitGroup
+	for i, container := range containers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := HealthIssue{Type: "Resource", Service: container.Name, Severity: SeverityOK}
+			stats, err := this.Runtime.ContainerStats(container.ID)
+			if err != nil {
+				result.Message = fmt.Sprintf("Could not get resource usage: %s", err)
+				result.Severity = SeverityUnknown
+			} else {
+				result.Message = fmt.Sprintf("CPU %.1f%%, memory %s of %s", stats.CPUPercent, formatBytes(stats.MemoryUsage), formatBytes(stats.MemoryLimit))
+				if stats.MemoryLimit > 0 && float64(stats.MemoryUsage)*100/float64(stats.MemoryLimit) > float64(memUsageMax) {
+					result.Message += fmt.Sprintf(", more than %d%% of the limit", memUsageMax)
+					result.Severity = SeverityWarning
+				}
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Checks the disk usage of the filesystem containing `path` against the maximum percentage
+// Returns the free space in bytes along with the result.
+func diskCheck(path string, usageMax int) (uint64, Severity, string) {
+	total, free, err := diskSpace(path)
+	if err != nil {
+		return 0, SeverityUnknown, fmt.Sprintf("Could not get disk usage of %s: %s", path, err)
+	}
+	if total == 0 {
+		return free, SeverityUnknown, fmt.Sprintf("Could not get disk usage of %s: the filesystem reports no size", path)
+	}
+	used := float64(total-free) / float64(total) * 100
+	message := fmt.Sprintf("%.0f%% used with %s free on the disk containing %s", used, formatBytes(free), path)
+	if used > float64(usageMax) {
+		return free, SeverityWarning, fmt.Sprintf("%s, above the maximum of %d%%", message, usageMax)
+	}
+	return free, SeverityOK, message
+}
+
+// Reads an integer setting, falling back to `fallback` if it's not a valid number
+func (this *DockerInterface) envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(this.Env.Get(key))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+// Formats a number of bytes using binary units, e.g., "1.5 GiB"
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
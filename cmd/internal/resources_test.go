@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/moby/moby/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResources(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newFakeDockerInterface(t)
+	assert.NoError(t, dockerInterface.Up())
+
+	runtime.Engine = EngineInfo{RootDir: t.TempDir()}
+	runtime.Outputs["compose -f local.yml config --format json"] = `{"name": "ghostwriter"}`
+	runtime.VolumeUsage["ghostwriter_local_postgres_data"] = 1024 * 1024
+	runtime.Stats["1"] = ContainerStats{CPUPercent: 12.5, MemoryUsage: 240 * 1024 * 1024, MemoryLimit: 250 * 1024 * 1024}
+	runtime.Stats["2"] = ContainerStats{CPUPercent: 1, MemoryUsage: 100 * 1024 * 1024, MemoryLimit: 4 * 1024 * 1024 * 1024}
+	// A small limit on the host's memory doesn't change the containers' thresholds
+	dockerInterface.Env.Set("healthcheck_mem_min", "1000000")
+
+	results := dockerInterface.CheckResources()
+	byService := map[string]HealthIssue{}
+	for _, result := range results {
+		assert.Equal(t, "Resource", result.Type)
+		byService[result.Service] = result
+	}
+
+	assert.Contains(t, byService, "docker_disk", "Expected Docker's data directory to be checked")
+	assert.Contains(t, byService, "data_disk", "Expected the data directory to be checked")
+	assert.Equal(t, SeverityOK, byService["postgres_volume"].Severity)
+	assert.Contains(t, byService["postgres_volume"].Message, "1.0 MiB")
+	assert.NotContains(t, byService, "media_volume", "Expected volumes without usage data to be skipped")
+
+	assert.Equal(t, SeverityWarning, byService["ghostwriter_django"].Severity, "Expected a container using more than 90% of its limit to be a warning")
+	assert.Equal(t, SeverityOK, byService["ghostwriter_postgres"].Severity, "Expected the host's memory threshold to be left out of container checks")
+	assert.Contains(t, byService["ghostwriter_postgres"].Message, "CPU 1.0%")
+	assert.True(t, runtime.Called("stats", "--no-stream", "1"), "Expected container stats to be fetched")
+
+	// No disk is more than 100% used
+	dockerInterface.Env.Set("healthcheck_disk_usage_max", "100")
+	for _, result := range dockerInterface.CheckResources() {
+		if result.Service == "data_disk" {
+			assert.Equal(t, SeverityOK, result.Severity)
+		}
+	}
+}
+
+func TestDataDir(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	xdg.Reload()
+	defer xdg.Reload()
+
+	assert.Equal(t, dataHome, dataDir(), "Expected the data home before the data directory is created")
+	assert.NoError(t, os.Mkdir(filepath.Join(dataHome, "ghostwriter"), 0700))
+	assert.Equal(t, filepath.Join(dataHome, "ghostwriter"), dataDir(), "Expected the data directory in every mode")
+}
+
+func TestContainerStatsFromResponse(t *testing.T) {
+	stats := &container.StatsResponse{}
+	stats.CPUStats.CPUUsage.TotalUsage = 300
+	stats.CPUStats.SystemUsage = 2000
+	stats.CPUStats.OnlineCPUs = 2
+	stats.PreCPUStats.CPUUsage.TotalUsage = 100
+	stats.PreCPUStats.SystemUsage = 1000
+	stats.MemoryStats.Usage = 1000
+	stats.MemoryStats.Limit = 4000
+	stats.MemoryStats.Stats = map[string]uint64{"inactive_file": 200}
+
+	result := containerStatsFromResponse(stats)
+	assert.InDelta(t, 40.0, result.CPUPercent, 0.001)
+	assert.Equal(t, uint64(800), result.MemoryUsage, "Expected the page cache to be excluded")
+	assert.Equal(t, uint64(4000), result.MemoryLimit)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 GiB", formatBytes(2*1024*1024*1024))
+}
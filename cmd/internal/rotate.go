@@ -0,0 +1,165 @@
+package internal
+
+// Rotating secrets in the environment file, the database, and the running services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Secrets that `RotateSecrets` can replace
+var RotatableSecrets = []string{
+	"postgres_password", "hasura_graphql_admin_secret", "hasura_graphql_action_secret",
+	"django_secret_key", "django_jwt_secret_key",
+}
+
+// Quotes a PostgreSQL identifier, such as a user name
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Quotes a PostgreSQL string literal
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// Changes the password of the PostgreSQL user in the running database, logging in with the current password.
+// Neither password is passed as an argument, where other users could see them: the current one is passed
+// to Compose in its environment and the query is sent on stdin. Statement logging is turned off for the
+// session so the new password isn't written to PostgreSQL's logs.
+func (this *DockerInterface) alterPostgresPassword(current, password string) error {
+	user := this.Env.Get("postgres_user")
+	query := fmt.Sprintf("SET log_statement = 'none';\nALTER USER %s WITH PASSWORD %s;\n", quoteIdentifier(user), quoteLiteral(password))
+	out, err := this.RunComposeCmdWithInput(
+		[]string{"PGPASSWORD=" + current}, query,
+		"exec", "-T", "-e", "PGPASSWORD", "postgres",
+		"psql", "-h", "localhost", "-U", user, "-d", this.Env.Get("postgres_db"), "-v", "ON_ERROR_STOP=1",
+	)
+	if err != nil {
+		return fmt.Errorf("could not change the password of PostgreSQL user %s: %s", user, commandFailure(out, err))
+	}
+	return nil
+}
+
+// Recreates a service so it reads the current environment file
+func (this *DockerInterface) recreateService(service string) error {
+	fmt.Printf("[+] Recreating the %s service...\n", service)
+	if err := this.RunComposeCmd("up", "-d", "--no-deps", "--force-recreate", service); err != nil {
+		return fmt.Errorf("failed to recreate the %s service: %w", service, err)
+	}
+	return nil
+}
+
+// Gets the running services that read any of the settings, in the order they start in
+func (this *DockerInterface) runningServicesFor(keys []string) []string {
+	var services []string
+	for _, service := range allServices {
+		for _, key := range keys {
+			setting, found := LookupSetting(key)
+			if !found || !slices.Contains(setting.Services, service) {
+				continue
+			}
+			// Containers that don't exist are treated as stopped, the same as when restarting Nginx
+			if running, err := this.IsServiceRunning(service); err == nil && running {
+				services = append(services, service)
+			}
+			break
+		}
+	}
+	return services
+}
+
+// RotateSecrets replaces the secrets in `keys`, which must be listed in `RotatableSecrets`, with
+// new random values.
+//
+// The PostgreSQL user's password is changed in the running database first, since PostgreSQL only reads
+// it from the environment when the database is created. The environment file is saved afterwards, and
+// the running services that read the secrets are recreated in the order they start in. If any step fails,
+// the previous secrets are restored in the database and the environment file, and the services are
+// recreated again to use them.
+func (this *DockerInterface) RotateSecrets(keys []string) error {
+	var rotated []string
+	for _, key := range keys {
+		setting, found := LookupSetting(key)
+		if !found || !slices.Contains(RotatableSecrets, setting.Key) {
+			return fmt.Errorf("%s can't be rotated; must be one of: %s", key, strings.Join(RotatableSecrets, ", "))
+		}
+		if ref := this.Env.Ref(setting.Key); ref != "" {
+			return fmt.Errorf("%s is read from %s, so rotate it there instead", setting.Key, ref)
+		}
+		if !slices.Contains(rotated, setting.Key) {
+			rotated = append(rotated, setting.Key)
+		}
+	}
+
+	rotatePostgres := slices.Contains(rotated, "postgres_password")
+	if rotatePostgres {
+		running, err := this.IsServiceRunning("postgres")
+		if err != nil || !running {
+			return fmt.Errorf("PostgreSQL must be running to change its password; start the containers with `ghostwriter-cli up` first")
+		}
+	}
+	services := this.runningServicesFor(rotated)
+
+	previous := map[string]string{}
+	for _, key := range rotated {
+		setting, _ := LookupSetting(key)
+		value, err := GenerateRandomPassword(setting.PasswordLength, setting.PasswordSafe)
+		if err != nil {
+			return err
+		}
+		previous[key] = this.Env.Get(key)
+		this.Env.Set(key, value)
+	}
+
+	altered := false
+	var recreated []string
+	rollback := func(cause error) error {
+		fmt.Println("[!] Rolling back to the previous secrets...")
+		errs := []error{cause}
+		if altered {
+			if err := this.alterPostgresPassword(this.Env.Get("postgres_password"), previous["postgres_password"]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for key, value := range previous {
+			this.Env.Set(key, value)
+		}
+		if err := this.Env.Save(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore the environment file: %w", err))
+		}
+		for _, service := range recreated {
+			if err := this.recreateService(service); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	if rotatePostgres {
+		fmt.Println("[+] Changing the password of the PostgreSQL user...")
+		if err := this.alterPostgresPassword(previous["postgres_password"], this.Env.Get("postgres_password")); err != nil {
+			return rollback(err)
+		}
+		altered = true
+	}
+	if err := this.Env.Save(); err != nil {
+		return rollback(fmt.Errorf("failed to save the environment file: %w", err))
+	}
+	fmt.Printf("[+] Saved the new values of %s\n", strings.Join(rotated, ", "))
+
+	for _, service := range services {
+		recreated = append(recreated, service)
+		if err := this.recreateService(service); err != nil {
+			return rollback(err)
+		}
+	}
+	if slices.Contains(recreated, "django") {
+		if err := this.WaitForDjango(); err != nil {
+			return rollback(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns a fake docker interface with every Ghostwriter service running
+func newRotateDockerInterface(t *testing.T) (*DockerInterface, *FakeRuntime) {
+	dockerInterface, runtime := newFakeDockerInterface(t)
+	runtime.Outputs["compose -f local.yml config --format json"] = `{"name": "ghostwriter"}`
+	for _, service := range allServices {
+		runtime.Services = append(runtime.Services, Container{ID: service, Image: "ghostwriter_local_" + service, Name: "ghostwriter-" + service + "-1"})
+	}
+	runtime.Logs["ghostwriter_django"] = []string{"Application startup complete\n"}
+	runtime.IsUp = true
+	return dockerInterface, runtime
+}
+
+// Gets the services recreated through the fake runtime, in order
+func recreatedServices(runtime *FakeRuntime) []string {
+	var services []string
+	for _, call := range runtime.Calls {
+		if strings.HasPrefix(strings.Join(call, " "), "compose -f local.yml up -d --no-deps --force-recreate") {
+			services = append(services, call[len(call)-1])
+		}
+	}
+	return services
+}
+
+func TestRotateSecrets(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newRotateDockerInterface(t)
+	previous := dockerInterface.Env.Get("postgres_password")
+	secretKey := dockerInterface.Env.Get("django_secret_key")
+
+	assert.NoError(t, dockerInterface.RotateSecrets([]string{"POSTGRES_PASSWORD"}))
+	password := dockerInterface.Env.Get("postgres_password")
+	assert.NotEqual(t, previous, password)
+	assert.Len(t, password, 32)
+	assert.Equal(t, secretKey, dockerInterface.Env.Get("django_secret_key"), "Expected other secrets to be unchanged")
+	assert.True(t, runtime.Called("compose", "-f", "local.yml", "exec", "-T", "-e", "PGPASSWORD", "postgres", "psql", "-h", "localhost", "-U", "postgres", "-d", "ghostwriter", "-v", "ON_ERROR_STOP=1"))
+	assert.Equal(t, []string{"SET log_statement = 'none';\nALTER USER \"postgres\" WITH PASSWORD '" + password + "';\n"}, runtime.Inputs)
+	assert.Equal(t, []string{"postgres", "graphql_engine", "django", "queue"}, recreatedServices(runtime))
+
+	saved, err := ReadEnv(dockerInterface.Dir)
+	assert.NoError(t, err)
+	assert.Equal(t, password, saved.Get("postgres_password"), "Expected the new password to be saved")
+
+	runtime.Calls = nil
+	assert.NoError(t, dockerInterface.RotateSecrets([]string{"django_secret_key", "hasura_password"}))
+	assert.NotEqual(t, secretKey, dockerInterface.Env.Get("django_secret_key"))
+	assert.False(t, runtime.Called("compose", "-f", "local.yml", "exec"), "Expected PostgreSQL to be left alone")
+	assert.Equal(t, []string{"graphql_engine", "django", "queue", "collab-server"}, recreatedServices(runtime))
+
+	assert.ErrorContains(t, dockerInterface.RotateSecrets([]string{"django_superuser_password"}), "can't be rotated")
+}
+
+func TestRotateSecretsRollback(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newRotateDockerInterface(t)
+	previous := map[string]string{}
+	for _, key := range RotatableSecrets {
+		previous[key] = dockerInterface.Env.Get(key)
+	}
+
+	runtime.Errors["compose -f local.yml up -d --no-deps --force-recreate django"] = errors.New("boom")
+	err := dockerInterface.RotateSecrets(RotatableSecrets)
+	assert.ErrorContains(t, err, "boom")
+	for key, value := range previous {
+		assert.Equal(t, value, dockerInterface.Env.Get(key), "Expected %s to be restored", key)
+	}
+	saved, err := ReadEnv(dockerInterface.Dir)
+	assert.NoError(t, err)
+	assert.Equal(t, previous["postgres_password"], saved.Get("postgres_password"), "Expected the environment file to be restored")
+	assert.True(t, runtime.Called("compose", "-f", "local.yml", "exec", "-T", "-e", "PGPASSWORD", "postgres"), "Expected the password to be changed")
+	restore := `ALTER USER "postgres" WITH PASSWORD '` + previous["postgres_password"] + "';"
+	if assert.Len(t, runtime.Inputs, 2) {
+		assert.Contains(t, runtime.Inputs[1], restore, "Expected the password to be changed back")
+	}
+	assert.Equal(t, []string{"postgres", "graphql_engine", "django", "postgres", "graphql_engine", "django"}, recreatedServices(runtime))
+
+	runtime.IsUp = false
+	assert.ErrorContains(t, dockerInterface.RotateSecrets([]string{"postgres_password"}), "PostgreSQL must be running")
+	assert.Equal(t, previous["postgres_password"], dockerInterface.Env.Get("postgres_password"))
+}
+
+func TestRotateSecretsHidesPasswords(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newRotateDockerInterface(t)
+	previous := dockerInterface.Env.Get("postgres_password")
+
+	assert.NoError(t, dockerInterface.RotateSecrets([]string{"postgres_password"}))
+	password := dockerInterface.Env.Get("postgres_password")
+	for _, call := range runtime.Calls {
+		joined := strings.Join(call, " ")
+		assert.NotContains(t, joined, previous, "Expected the current password to be left out of the arguments")
+		assert.NotContains(t, joined, password, "Expected the new password to be left out of the arguments")
+	}
+	if assert.Len(t, runtime.Inputs, 1) {
+		assert.True(t, strings.HasPrefix(runtime.Inputs[0], "SET log_statement = 'none';"), "Expected statement logging to be turned off first")
+	}
+}
@@ -0,0 +1,408 @@
+package internal
+
+// Container runtime abstraction used by `DockerInterface`
+// The default implementation shells out to `docker` or `podman` and talks to the daemon API,
+// while `FakeRuntime` (see runtime_fake.go) keeps everything in memory for tests
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+// ContainerRuntime is the set of container engine operations used by Ghostwriter CLI.
+type ContainerRuntime interface {
+	// Name of the engine's command line tool, either `docker` or `podman`
+	Name() string
+	// Runs the engine's command line tool with the given arguments in the directory `dir`,
+	// attached to the current terminal
+	Run(dir string, args ...string) error
+	// Similar to `Run` but returns stdout
+	RunWithOutput(dir string, args ...string) (string, error)
+	// Runs a `compose` subcommand (e.g., `up`, `down`, `run`) against `composeFile` in the directory `dir`,
+	// adding the `KEY=value` variables in `env` to its environment
+	Compose(dir string, composeFile string, env []string, args ...string) error
+	// Similar to `Compose` but returns stdout
+	ComposeWithOutput(dir string, composeFile string, env []string, args ...string) (string, error)
+	// Similar to `ComposeWithOutput` but writes `input` to stdin, for data that shouldn't be in the
+	// arguments where other users can see it
+	ComposeWithInput(dir string, composeFile string, env []string, input string, args ...string) (string, error)
+	// Gets the state of the container with the specified name
+	Inspect(name string) (*ContainerDetails, error)
+	// Determines if a volume with the specified name exists
+	VolumeExists(name string) bool
+	// Creates a volume with the specified name
+	VolumeCreate(name string) error
+	// Deletes the volume with the specified name
+	VolumeRemove(name string) error
+	// Lists the names of all volumes
+	VolumeList() ([]string, error)
+	// Lists all running containers (including outside of this project)
+	ContainerList() (Containers, error)
+	// Gets up to `lines` lines of logs for the container with the specified ID
+	ContainerLogs(id string, lines string) ([]string, error)
+	// Gets a sample of the CPU and memory usage of the container with the specified ID
+	ContainerStats(id string) (*ContainerStats, error)
+	// Gets the size of each volume in bytes, keyed by volume name
+	VolumeSizes() (map[string]int64, error)
+	// Gets details about the container engine
+	Info() (*EngineInfo, error)
+}
+
+// ContainerDetails is the subset of `docker inspect` output used by Ghostwriter CLI.
+type ContainerDetails struct {
+	Name    string
+	Image   string
+	Status  string
+	Running bool
+	Env     []string
+}
+
+// ContainerStats is the resource usage of a container.
+type ContainerStats struct {
+	// CPU usage as a percentage of one CPU, so it can exceed 100 on hosts with multiple CPUs
+	CPUPercent float64
+	// Memory used in bytes, excluding the page cache
+	MemoryUsage uint64
+	// Memory limit in bytes, which is the host's memory if the container has no limit
+	MemoryLimit uint64
+}
+
+// EngineInfo is the subset of `docker info` output used by Ghostwriter CLI.
+type EngineInfo struct {
+	// Directory where the engine stores images, containers, and volumes (on the engine's host)
+	RootDir string
+	// Total memory available to the engine in bytes
+	MemTotal int64
+}
+
+// ExecRuntime implements `ContainerRuntime` by executing `docker` or `podman` and using the Docker daemon API.
+type ExecRuntime struct {
+	// Command to use, either docker or podman
+	command string
+	// Daemon client, lazily initialized
+	client *client.Client
+}
+
+// NewExecRuntime returns a runtime that runs the specified command (`docker` or `podman`).
+func NewExecRuntime(command string) *ExecRuntime {
+	return &ExecRuntime{command: command}
+}
+
+// DetectRuntime checks how to run docker/podman and that the engine and the Compose plugin are usable.
+func DetectRuntime() (*ExecRuntime, error) {
+	// Check for ``docker`` first because it's required for everything to come
+	dockerExists := CheckPath("docker")
+	dockerCmd := "docker"
+	if !dockerExists {
+		podmanExists := CheckPath("podman")
+		if podmanExists {
+			fmt.Println("[+] Docker is not installed, but Podman is installed. Using Podman as a Docker alternative.")
+			dockerCmd = "podman"
+		} else {
+			return nil, fmt.Errorf("%w: neither Docker nor Podman is installed on this system, so please install Docker or Podman (in Docker compatibility mode) and try again", ErrRuntimeMissing)
+		}
+	}
+
+	// Check if the Docker Engine is running
+	_, engineErr := exec.Command(dockerCmd, "info").Output()
+	if engineErr != nil {
+		if strings.Contains(strings.ToLower(engineErr.Error()), "permission denied") {
+			return nil, fmt.Errorf("%w: %s is installed, but you don't have permission to talk to the daemon (Try running with sudo or adjusting your group membership)", ErrPermissionDenied, dockerCmd)
+		}
+		return nil, fmt.Errorf("%w: %s is installed on this system, but the daemon may not be running", ErrDaemonUnavailable, dockerCmd)
+	}
+
+	// Check for the ``compose`` plugin as our first choice
+	_, composeErr := exec.Command(dockerCmd, "compose", "version").Output()
+	if composeErr != nil {
+		// Check if the deprecated v1 script is installed
+		composeScriptExists := CheckPath("docker-compose")
+		if composeScriptExists {
+			fmt.Println("[!] The deprecated `docker-compose` v1 script was detected on your system")
+			fmt.Println("[!] Docker has deprecated v1 and this CLI tool no longer supports it")
+			return nil, fmt.Errorf("%w: please upgrade to Docker Compose v2 and try again: https://docs.docker.com/compose/install/", ErrComposeMissing)
+		}
+		return nil, fmt.Errorf("%w: please install it and try again: https://docs.docker.com/compose/install/", ErrComposeMissing)
+	}
+
+	return NewExecRuntime(dockerCmd), nil
+}
+
+func (this *ExecRuntime) Name() string {
+	return this.command
+}
+
+func (this *ExecRuntime) Run(dir string, args ...string) error {
+	return this.run(dir, nil, args...)
+}
+
+// Runs the command with the `KEY=value` variables in `env` added to its environment
+func (this *ExecRuntime) run(dir string, env []string, args ...string) error {
+	path, err := exec.LookPath(this.command)
+	if err != nil {
+		return fmt.Errorf("%w: `%s` is not installed or not available in the current PATH variable", ErrRuntimeMissing, this.command)
+	}
+	command := exec.Command(path, args...)
+	command.Dir = dir
+	if env != nil {
+		command.Env = append(os.Environ(), env...)
+	}
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	err = command.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start `%s`: %w", this.command, err)
+	}
+	err = command.Wait()
+	if err != nil {
+		fmt.Printf("[-] Error from `%s`: %v\n", this.command, err)
+		return err
+	}
+	return nil
+}
+
+func (this *ExecRuntime) RunWithOutput(dir string, args ...string) (string, error) {
+	return this.runWithOutput(dir, nil, os.Stdin, args...)
+}
+
+// Similar to `run` but returns stdout and reads stdin from `stdin`
+func (this *ExecRuntime) runWithOutput(dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	path, err := exec.LookPath(this.command)
+	if err != nil {
+		return "", fmt.Errorf("%w: `%s` is not installed or not available in the current PATH variable", ErrRuntimeMissing, this.command)
+	}
+	command := exec.Command(path, args...)
+	command.Dir = dir
+	if env != nil {
+		command.Env = append(os.Environ(), env...)
+	}
+	command.Stdin = stdin
+	command.Stderr = os.Stderr
+	out, err := command.Output()
+	output := string(out[:])
+	return output, err
+}
+
+func (this *ExecRuntime) Compose(dir string, composeFile string, env []string, args ...string) error {
+	return this.run(dir, env, append([]string{"compose", "-f", composeFile}, args...)...)
+}
+
+func (this *ExecRuntime) ComposeWithOutput(dir string, composeFile string, env []string, args ...string) (string, error) {
+	return this.runWithOutput(dir, env, os.Stdin, append([]string{"compose", "-f", composeFile}, args...)...)
+}
+
+func (this *ExecRuntime) ComposeWithInput(dir string, composeFile string, env []string, input string, args ...string) (string, error) {
+	return this.runWithOutput(dir, env, strings.NewReader(input), append([]string{"compose", "-f", composeFile}, args...)...)
+}
+
+func (this *ExecRuntime) Inspect(name string) (*ContainerDetails, error) {
+	out, err := this.RunWithOutput("", "inspect", "-f", "json", name)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		Name  string
+		State struct {
+			Status  string
+			Running bool
+		}
+		Config struct {
+			Image string
+			Env   []string
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no such container: %s", name)
+	}
+
+	result := results[0]
+	return &ContainerDetails{
+		Name:    strings.TrimPrefix(result.Name, "/"),
+		Image:   result.Config.Image,
+		Status:  result.State.Status,
+		Running: result.State.Running,
+		Env:     result.Config.Env,
+	}, nil
+}
+
+func (this *ExecRuntime) VolumeExists(name string) bool {
+	err := this.Run("", "volume", "inspect", name)
+	return err == nil
+}
+
+func (this *ExecRuntime) VolumeCreate(name string) error {
+	return this.Run("", "volume", "create", name)
+}
+
+func (this *ExecRuntime) VolumeRemove(name string) error {
+	return this.Run("", "volume", "rm", name)
+}
+
+func (this *ExecRuntime) VolumeList() ([]string, error) {
+	out, err := this.RunWithOutput("", "volume", "ls", "--format", "{{.Name}}")
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			volumes = append(volumes, line)
+		}
+	}
+	return volumes, nil
+}
+
+// Connects to the docker daemon
+func (this *ExecRuntime) GetDaemonClient() (*client.Client, error) {
+	if this.client != nil {
+		return this.client, nil
+	}
+
+	client, err := client.New(client.FromEnv, client.WithAPIVersionNegotiation())
+	this.client = client
+	return this.client, err
+}
+
+func (this *ExecRuntime) ContainerList() (Containers, error) {
+	var running Containers
+
+	cli, err := this.GetDaemonClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get client connection to Docker: %w", ErrDaemonUnavailable, err)
+	}
+	containers, err := cli.ContainerList(context.Background(), client.ContainerListOptions{
+		All: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get container list from Docker: %w", ErrDaemonUnavailable, err)
+	}
+
+	for _, container := range containers.Items {
+		running = append(running, Container{
+			container.ID, container.Image, container.Status, container.Ports, container.Labels["name"],
+		})
+	}
+	return running, nil
+}
+
+func (this *ExecRuntime) ContainerLogs(id string, lines string) ([]string, error) {
+	var logs []string
+
+	cli, err := this.GetDaemonClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get client connection to Docker: %w", ErrDaemonUnavailable, err)
+	}
+	reader, err := cli.ContainerLogs(context.Background(), id, client.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       lines,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container logs: %w", err)
+	}
+	defer reader.Close()
+
+	// Reference: https://medium.com/@dhanushgopinath/reading-docker-container-logs-with-golang-docker-engine-api-702233fac044
+	p := make([]byte, 8)
+	_, err = reader.Read(p)
+	for err == nil {
+		content := make([]byte, binary.BigEndian.Uint32(p[4:]))
+		reader.Read(content)
+		logs = append(logs, string(content))
+		_, err = reader.Read(p)
+	}
+	return logs, nil
+}
+
+func (this *ExecRuntime) ContainerStats(id string) (*ContainerStats, error) {
+	cli, err := this.GetDaemonClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get client connection to Docker: %w", ErrDaemonUnavailable, err)
+	}
+	result, err := cli.ContainerStats(context.Background(), id, client.ContainerStatsOptions{
+		IncludePreviousSample: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	defer result.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(result.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to read container stats: %w", err)
+	}
+	return containerStatsFromResponse(&stats), nil
+}
+
+// Calculates CPU and memory usage the same way as `docker stats`
+func containerStatsFromResponse(stats *container.StatsResponse) *ContainerStats {
+	result := &ContainerStats{MemoryLimit: stats.MemoryStats.Limit}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		result.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	// The page cache can be reclaimed, so don't count it as used (cgroup v2 and v1 report it differently)
+	cache := stats.MemoryStats.Stats["inactive_file"]
+	if cache == 0 {
+		cache = stats.MemoryStats.Stats["total_inactive_file"]
+	}
+	if cache < stats.MemoryStats.Usage {
+		result.MemoryUsage = stats.MemoryStats.Usage - cache
+	}
+	return result
+}
+
+func (this *ExecRuntime) VolumeSizes() (map[string]int64, error) {
+	cli, err := this.GetDaemonClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get client connection to Docker: %w", ErrDaemonUnavailable, err)
+	}
+	usage, err := cli.DiskUsage(context.Background(), client.DiskUsageOptions{Volumes: true, Verbose: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume disk usage: %w", err)
+	}
+
+	sizes := map[string]int64{}
+	for _, volume := range usage.Volumes.Items {
+		if volume.UsageData != nil {
+			sizes[volume.Name] = volume.UsageData.Size
+		}
+	}
+	return sizes, nil
+}
+
+func (this *ExecRuntime) Info() (*EngineInfo, error) {
+	cli, err := this.GetDaemonClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get client connection to Docker: %w", ErrDaemonUnavailable, err)
+	}
+	result, err := cli.Info(context.Background(), client.InfoOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get engine information: %w", ErrDaemonUnavailable, err)
+	}
+	return &EngineInfo{RootDir: result.Info.DockerRootDir, MemTotal: result.Info.MemTotal}, nil
+}
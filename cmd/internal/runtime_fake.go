@@ -0,0 +1,249 @@
+package internal
+
+// In-memory `ContainerRuntime` for testing commands without Docker or Podman
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FakeRuntime implements `ContainerRuntime` without talking to a container engine.
+// Every command is recorded in `Calls`, `compose up` and `compose down` toggle whether the
+// containers in `Services` are running, and volume operations act on `Volumes`.
+type FakeRuntime struct {
+	// Arguments of every command run through the runtime, in order
+	Calls [][]string
+	// Containers started by `compose up`
+	Services Containers
+	// Whether the compose project is up
+	IsUp bool
+	// Names of existing volumes
+	Volumes []string
+	// Log lines keyed by container name
+	Logs map[string][]string
+	// Environment variables returned by `Inspect`, keyed by container name
+	Environments map[string][]string
+	// Stdout returned for commands, keyed by the space-separated arguments
+	Outputs map[string]string
+	// Errors returned for commands, keyed by a prefix of the space-separated arguments
+	Errors map[string]error
+	// Resource usage keyed by container ID
+	Stats map[string]ContainerStats
+	// Volume sizes in bytes keyed by volume name
+	VolumeUsage map[string]int64
+	// Returned by `Info`
+	Engine EngineInfo
+	// Variables passed to the last compose command
+	ComposeEnv []string
+	// Stdin written to the compose commands run with `ComposeWithInput`, in order
+	Inputs []string
+
+	mu sync.Mutex
+}
+
+// NewFakeRuntime returns an empty `FakeRuntime` with the compose project down.
+func NewFakeRuntime() *FakeRuntime {
+	return &FakeRuntime{
+		Logs:         map[string][]string{},
+		Environments: map[string][]string{},
+		Outputs:      map[string]string{},
+		Errors:       map[string]error{},
+		Stats:        map[string]ContainerStats{},
+		VolumeUsage:  map[string]int64{},
+	}
+}
+
+// Records a call and returns its configured output and error
+func (this *FakeRuntime) record(args ...string) (string, error) {
+	this.Calls = append(this.Calls, args)
+	joined := strings.Join(args, " ")
+	for prefix, err := range this.Errors {
+		if strings.HasPrefix(joined, prefix) {
+			return "", err
+		}
+	}
+	return this.Outputs[joined], nil
+}
+
+// Called reports whether a command starting with the specified arguments was run.
+func (this *FakeRuntime) Called(args ...string) bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	prefix := strings.Join(args, " ")
+	for _, call := range this.Calls {
+		if strings.HasPrefix(strings.Join(call, " "), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *FakeRuntime) Name() string {
+	return "docker"
+}
+
+func (this *FakeRuntime) Run(dir string, args ...string) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	_, err := this.record(args...)
+	return err
+}
+
+func (this *FakeRuntime) RunWithOutput(dir string, args ...string) (string, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.record(args...)
+}
+
+func (this *FakeRuntime) Compose(dir string, composeFile string, env []string, args ...string) error {
+	_, err := this.ComposeWithOutput(dir, composeFile, env, args...)
+	return err
+}
+
+func (this *FakeRuntime) ComposeWithInput(dir string, composeFile string, env []string, input string, args ...string) (string, error) {
+	this.mu.Lock()
+	this.Inputs = append(this.Inputs, input)
+	this.mu.Unlock()
+	return this.ComposeWithOutput(dir, composeFile, env, args...)
+}
+
+func (this *FakeRuntime) ComposeWithOutput(dir string, composeFile string, env []string, args ...string) (string, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.ComposeEnv = env
+	out, err := this.record(append([]string{"compose", "-f", composeFile}, args...)...)
+	if err != nil || len(args) == 0 {
+		return out, err
+	}
+	switch args[0] {
+	case "up", "start", "restart":
+		this.IsUp = true
+	case "down", "stop":
+		this.IsUp = false
+		if args[0] == "down" && (slices.Contains(args, "--volumes") || slices.Contains(args, "-v")) {
+			this.Volumes = nil
+		}
+	}
+	return out, nil
+}
+
+func (this *FakeRuntime) Inspect(name string) (*ContainerDetails, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("inspect", "-f", "json", name); err != nil {
+		return nil, err
+	}
+	for _, container := range this.Services {
+		if container.Name == name || container.ID == name {
+			status := "exited"
+			if this.IsUp {
+				status = "running"
+			}
+			return &ContainerDetails{Name: container.Name, Image: container.Image, Status: status, Running: this.IsUp, Env: slices.Clone(this.Environments[container.Name])}, nil
+		}
+	}
+	return nil, fmt.Errorf("no such container: %s", name)
+}
+
+func (this *FakeRuntime) VolumeExists(name string) bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.record("volume", "inspect", name)
+	return slices.Contains(this.Volumes, name)
+}
+
+func (this *FakeRuntime) VolumeCreate(name string) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("volume", "create", name); err != nil {
+		return err
+	}
+	if !slices.Contains(this.Volumes, name) {
+		this.Volumes = append(this.Volumes, name)
+	}
+	return nil
+}
+
+func (this *FakeRuntime) VolumeRemove(name string) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("volume", "rm", name); err != nil {
+		return err
+	}
+	index := slices.Index(this.Volumes, name)
+	if index < 0 {
+		return fmt.Errorf("no such volume: %s", name)
+	}
+	this.Volumes = slices.Delete(this.Volumes, index, index+1)
+	return nil
+}
+
+func (this *FakeRuntime) VolumeList() ([]string, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("volume", "ls"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(this.Volumes), nil
+}
+
+func (this *FakeRuntime) ContainerList() (Containers, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("ps"); err != nil {
+		return nil, err
+	}
+	if !this.IsUp {
+		return Containers{}, nil
+	}
+	return slices.Clone(this.Services), nil
+}
+
+func (this *FakeRuntime) ContainerLogs(id string, lines string) ([]string, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("logs", "--tail", lines, id); err != nil {
+		return nil, err
+	}
+	for _, container := range this.Services {
+		if container.ID == id {
+			return slices.Clone(this.Logs[container.Name]), nil
+		}
+	}
+	return nil, fmt.Errorf("no such container: %s", id)
+}
+
+func (this *FakeRuntime) ContainerStats(id string) (*ContainerStats, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("stats", "--no-stream", id); err != nil {
+		return nil, err
+	}
+	stats, ok := this.Stats[id]
+	if !ok {
+		return nil, fmt.Errorf("no such container: %s", id)
+	}
+	return &stats, nil
+}
+
+func (this *FakeRuntime) VolumeSizes() (map[string]int64, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("system", "df", "-v"); err != nil {
+		return nil, err
+	}
+	return maps.Clone(this.VolumeUsage), nil
+}
+
+func (this *FakeRuntime) Info() (*EngineInfo, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, err := this.record("info"); err != nil {
+		return nil, err
+	}
+	info := this.Engine
+	return &info, nil
+}
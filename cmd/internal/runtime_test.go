@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Creates a docker interface backed by a `FakeRuntime` in a temporary directory
+func newFakeDockerInterface(t *testing.T) (*DockerInterface, *FakeRuntime) {
+	tempDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(tempDir, "local.yml"), []byte{}, 0644)
+	assert.NoError(t, err, "Expected to create mock compose file")
+
+	runtime := NewFakeRuntime()
+	runtime.Services = Containers{
+		{ID: "1", Image: "ghostwriter_local_django", Name: "ghostwriter_django"},
+		{ID: "2", Image: "ghostwriter_local_postgres", Name: "ghostwriter_postgres"},
+		{ID: "3", Image: "someone/else", Name: "unrelated"},
+	}
+	dockerInterface, err := NewDockerInterface(tempDir, ModeLocalDev, runtime)
+	assert.NoError(t, err, "Expected `NewDockerInterface()` to return no error")
+	return dockerInterface, runtime
+}
+
+func TestFakeRuntimeUpAndDown(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newFakeDockerInterface(t)
+
+	running, err := dockerInterface.GetRunning()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(running), "Expected no containers before `Up()`")
+
+	assert.NoError(t, dockerInterface.Up())
+	assert.True(t, runtime.Called("compose", "-f", "local.yml", "up", "-d"), "Expected `compose up -d` to be run")
+	running, err = dockerInterface.GetRunning()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(running), "Expected only Ghostwriter containers to be listed")
+	assert.NoError(t, dockerInterface.ValidateContainersRunning())
+
+	runtime.Volumes = []string{"ghostwriter_local_postgres_data"}
+	assert.NoError(t, dockerInterface.Down(&DownOptions{Volumes: true}))
+	assert.True(t, runtime.Called("compose", "-f", "local.yml", "down", "--volumes"), "Expected `compose down --volumes` to be run")
+	running, err = dockerInterface.GetRunning()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(running), "Expected no containers after `Down()`")
+	assert.False(t, dockerInterface.VerifyVolumeExists("ghostwriter_local_postgres_data"), "Expected volumes to be removed")
+}
+
+func TestFakeRuntimeServiceStatusAndLogs(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newFakeDockerInterface(t)
+	runtime.Outputs["compose -f local.yml config --format json"] = `{"name": "ghostwriter"}`
+	runtime.Services = append(runtime.Services, Container{ID: "4", Image: "ghostwriter_local_django", Name: "ghostwriter-django-1"})
+	runtime.Logs["ghostwriter_django"] = []string{"Application startup complete\n"}
+	runtime.IsUp = true
+
+	running, err := dockerInterface.IsServiceRunning("django")
+	assert.NoError(t, err)
+	assert.True(t, running, "Expected `django` to be running")
+	started, err := dockerInterface.IsDjangoStarted()
+	assert.NoError(t, err)
+	assert.True(t, started, "Expected Django startup message in the logs")
+	mismatch, err := dockerInterface.IsPostgresStarted()
+	assert.NoError(t, err)
+	assert.False(t, mismatch, "Expected no password mismatch in the logs")
+	assert.NoError(t, dockerInterface.WaitForDjango())
+
+	runtime.IsUp = false
+	assert.ErrorIs(t, dockerInterface.WaitForDjango(), ErrDjangoNotStarted, "Expected an error when Django has exited")
+}
+
+func TestFakeRuntimeErrors(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newFakeDockerInterface(t)
+	runtime.Errors["compose -f local.yml up"] = errors.New("boom")
+
+	assert.Error(t, dockerInterface.Up(), "Expected configured error to be returned")
+	assert.False(t, runtime.IsUp, "Expected project to stay down after a failed `up`")
+}
+
+func TestNewDockerInterfaceNotInstalled(t *testing.T) {
+	_, err := NewDockerInterface(t.TempDir(), ModeProd, NewFakeRuntime())
+	assert.ErrorIs(t, err, ErrNotInstalled, "Expected `ErrNotInstalled` without a compose file")
+}
+
+func TestFakeRuntimeVolumes(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, runtime := newFakeDockerInterface(t)
+	runtime.Volumes = []string{"ghostwriter_production_data", "other_data"}
+
+	volumes, err := dockerInterface.ListVolumes("ghostwriter_")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ghostwriter_production_data"}, volumes)
+
+	assert.NoError(t, dockerInterface.CopyVolume("ghostwriter_production_data", "ghostwriter_data"))
+	assert.True(t, dockerInterface.VerifyVolumeExists("ghostwriter_data"), "Expected destination volume to be created")
+	assert.True(t, runtime.Called("run", "--rm"), "Expected a copy container to be run")
+
+	assert.NoError(t, dockerInterface.RemoveVolume("other_data"))
+	assert.Error(t, dockerInterface.RemoveVolume("other_data"), "Expected removing a missing volume to fail")
+}
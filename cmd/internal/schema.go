@@ -0,0 +1,426 @@
+package internal
+
+// Schema of the settings in the environment file, used to set defaults and validate values
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type of a setting's value
+type SettingType string
+
+const (
+	SettingString   SettingType = "string"
+	SettingBool     SettingType = "bool"
+	SettingInt      SettingType = "int"
+	SettingPort     SettingType = "port"
+	SettingDuration SettingType = "duration"
+	SettingEmail    SettingType = "email"
+	// Space-separated list, such as `django_social_account_domain_allowlist`
+	SettingList SettingType = "list"
+	// Space-separated list of hostnames and IP addresses, following Django's rules for `ALLOWED_HOSTS`
+	SettingHosts SettingType = "hosts"
+	// Space-separated list of origins, following Django's rules for `CSRF_TRUSTED_ORIGINS`
+	SettingOrigins SettingType = "origins"
+)
+
+// Setting describes a value in the environment file.
+type Setting struct {
+	Key  string      `json:"key"`
+	Type SettingType `json:"type"`
+	// Default value, unless the setting is a password generated on first use
+	Default any `json:"default,omitempty"`
+	// Length of the random password generated as the default, if the setting is a password
+	PasswordLength int `json:"-"`
+	// Only use URL-safe characters in the generated password
+	PasswordSafe bool `json:"-"`
+	// Allowed values, if the setting can't be any value of its type
+	Allowed []string `json:"allowed,omitempty"`
+	// Range of integer values, with no upper bound if `Max` is zero
+	Min int `json:"min,omitempty"`
+	Max int `json:"max,omitempty"`
+	// Whether the value is a password, key, or other secret
+	Secret bool `json:"secret"`
+	// Whether the value depends on the server, such as its hostnames, so it isn't copied between deployments
+	HostSpecific bool   `json:"host_specific"`
+	Description  string `json:"description"`
+	// Compose services that read the value, which must be recreated for a change to take effect
+	Services []string `json:"services"`
+}
+
+// Compose services grouped by the settings they read
+var (
+	djangoServices   = []string{"django", "queue"}
+	databaseServices = []string{"postgres", "django", "queue", "graphql_engine"}
+	hasuraServices   = []string{"graphql_engine", "django", "queue", "collab-server"}
+	allServices      = []string{"postgres", "redis", "graphql_engine", "django", "queue", "collab-server", "nginx"}
+)
+
+// Shorter names for common settings
+var settingAliases = map[string]string{
+	"date_format":     "django_date_format",
+	"admin_password":  "django_superuser_password",
+	"hasura_password": "hasura_graphql_admin_secret",
+	"spacy":           "spacy_model",
+}
+
+// Every setting in the environment file
+// Defaults are geared towards a development environment.
+var settings = []Setting{
+	// GW-CLI configuration
+	{Key: "gwcli_auto_check_updates", Type: SettingBool, Default: true, Description: "Check for a new release of Ghostwriter CLI when running commands"},
+	{Key: "gwcli_config_history_limit", Type: SettingInt, Default: 20, Description: "Previous versions of the environment file to keep for `config rollback`, or 0 to keep none"},
+	{Key: "gwcli_cert_expiry_warning_days", Type: SettingInt, Default: 30, Description: "Warn when the TLS certificate expires within this many days"},
+
+	// Project configuration
+	{Key: "use_docker", Type: SettingString, Default: "yes", Allowed: []string{"yes", "no"}, Description: "Tells Django it's running in a container", Services: djangoServices},
+	{Key: "ipythondir", Type: SettingString, Default: "/app/.ipython", Description: "Directory for the IPython shell's profile inside the Django container", Services: djangoServices},
+
+	// Passive Voice Detection configuration
+	{Key: "spacy_model", Type: SettingString, Default: "en_core_web_sm", Allowed: []string{"en_core_web_sm", "en_core_web_md", "en_core_web_lg", "en_core_web_trf"}, Description: "spaCy language model used for passive voice detection", Services: djangoServices},
+
+	// Django configuration
+	{Key: "django_mfa_always_reveal_backup_tokens", Type: SettingBool, Default: false, Description: "Let users view their MFA backup tokens again after they're first shown", Services: djangoServices},
+	{Key: "django_account_allow_registration", Type: SettingBool, Default: false, Description: "Allow anyone to register an account", Services: djangoServices},
+	{Key: "django_account_reauthentication_timeout", Type: SettingInt, Default: 32400, Description: "Seconds before users must enter their password again for sensitive actions", Services: djangoServices},
+	{Key: "django_account_email_verification", Type: SettingString, Default: "none", Allowed: []string{"none", "optional", "mandatory"}, Description: "Whether new accounts must verify their email address", Services: djangoServices},
+	{Key: "django_admin_url", Type: SettingString, Default: "admin/", Description: "Path of the Django admin site", Services: djangoServices},
+	{Key: "django_allowed_hosts", Type: SettingHosts, Default: "localhost 127.0.0.1 django nginx host.docker.internal ghostwriter.local", HostSpecific: true, Description: "Hostnames and IP addresses that Ghostwriter can be reached at", Services: djangoServices},
+	{Key: "django_compress_enabled", Type: SettingBool, Default: true, Description: "Compress and combine static CSS and JavaScript files", Services: djangoServices},
+	{Key: "django_csrf_cookie_secure", Type: SettingBool, Default: false, Description: "Only send the CSRF cookie over HTTPS", Services: djangoServices},
+	{Key: "django_csrf_trusted_origins", Type: SettingOrigins, Default: "", HostSpecific: true, Description: "Origins, including the scheme, trusted for unsafe requests such as POST", Services: djangoServices},
+	{Key: "django_date_format", Type: SettingString, Default: "d M Y", Description: "Format of dates shown in the interface and reports, using Django's date format characters", Services: djangoServices},
+	{Key: "django_host", Type: SettingString, Default: "django", Description: "Hostname of the Django container", Services: []string{"django", "nginx", "graphql_engine"}},
+	{Key: "django_jwt_secret_key", Type: SettingString, PasswordLength: 32, Secret: true, Description: "Key used to sign the JSON Web Tokens for the GraphQL API", Services: hasuraServices},
+	{Key: "django_mailgun_api_key", Type: SettingString, Default: "", Secret: true, Description: "API key for sending email with Mailgun", Services: djangoServices},
+	{Key: "django_mailgun_domain", Type: SettingString, Default: "", Description: "Domain for sending email with Mailgun", Services: djangoServices},
+	{Key: "django_port", Type: SettingPort, Default: "8000", Description: "Port Django listens on inside its container", Services: []string{"django", "nginx", "graphql_engine"}},
+	{Key: "django_qcluster_name", Type: SettingString, Default: "soar", Description: "Name of the django-q task cluster", Services: djangoServices},
+	{Key: "django_secret_key", Type: SettingString, PasswordLength: 32, Secret: true, Description: "Django's secret key for signing sessions and tokens", Services: djangoServices},
+	{Key: "django_secure_ssl_redirect", Type: SettingBool, Default: false, Description: "Redirect HTTP requests to HTTPS", Services: djangoServices},
+	{Key: "django_session_cookie_age", Type: SettingInt, Default: 32400, Description: "Seconds before a login session expires", Services: djangoServices},
+	{Key: "django_session_cookie_secure", Type: SettingBool, Default: false, Description: "Only send the session cookie over HTTPS", Services: djangoServices},
+	{Key: "django_session_expire_at_browser_close", Type: SettingBool, Default: false, Description: "End login sessions when the browser closes", Services: djangoServices},
+	{Key: "django_session_save_every_request", Type: SettingBool, Default: true, Description: "Extend login sessions on every request", Services: djangoServices},
+	{Key: "django_settings_module", Type: SettingString, Default: "config.settings.local", Allowed: []string{"config.settings.local", "config.settings.production"}, Description: "Django settings module, set by the development or production mode", Services: djangoServices},
+	{Key: "django_social_account_allow_registration", Type: SettingBool, Default: false, Description: "Allow new accounts to be created by signing in with SSO", Services: djangoServices},
+	{Key: "django_social_account_domain_allowlist", Type: SettingList, Default: "", Description: "Email domains allowed to register with SSO", Services: djangoServices},
+	{Key: "django_social_account_login_on_get", Type: SettingBool, Default: false, Description: "Skip the confirmation page when signing in with SSO", Services: djangoServices},
+	{Key: "django_superuser_email", Type: SettingEmail, Default: "admin@ghostwriter.local", Description: "Email address of the initial admin account", Services: djangoServices},
+	{Key: "django_superuser_password", Type: SettingString, PasswordLength: 32, PasswordSafe: true, Secret: true, Description: "Password of the initial admin account", Services: djangoServices},
+	{Key: "django_superuser_username", Type: SettingString, Default: "admin", Description: "Username of the initial admin account", Services: djangoServices},
+	{Key: "django_web_concurrency", Type: SettingInt, Default: 4, Min: 1, Description: "Number of worker processes serving Django", Services: []string{"django"}},
+
+	// PostgreSQL configuration
+	{Key: "postgres_host", Type: SettingString, Default: "postgres", Description: "Hostname of the PostgreSQL container", Services: databaseServices},
+	{Key: "postgres_port", Type: SettingPort, Default: 5432, Description: "Port PostgreSQL listens on", Services: databaseServices},
+	{Key: "postgres_db", Type: SettingString, Default: "ghostwriter", Description: "Name of the Ghostwriter database", Services: databaseServices},
+	{Key: "postgres_user", Type: SettingString, Default: "postgres", Description: "PostgreSQL user for the Ghostwriter database", Services: databaseServices},
+	{Key: "postgres_password", Type: SettingString, PasswordLength: 32, PasswordSafe: true, Secret: true, Description: "Password of the PostgreSQL user", Services: databaseServices},
+	{Key: "postgres_conn_max_age", Type: SettingInt, Default: 0, Description: "Seconds Django keeps database connections open, or 0 to close them after each request", Services: djangoServices},
+
+	// Redis configuration
+	{Key: "redis_host", Type: SettingString, Default: "redis", Description: "Hostname of the Redis container", Services: []string{"redis", "django", "queue"}},
+	{Key: "redis_port", Type: SettingPort, Default: 6379, Description: "Port Redis listens on", Services: []string{"redis", "django", "queue"}},
+
+	// Nginx configuration
+	{Key: "nginx_host", Type: SettingString, Default: "nginx", Description: "Hostname of the Nginx container", Services: []string{"nginx"}},
+	{Key: "nginx_port", Type: SettingPort, Default: 443, HostSpecific: true, Description: "HTTPS port Nginx publishes on the host", Services: []string{"nginx"}},
+
+	// Hasura configuration
+	{Key: "hasura_graphql_action_secret", Type: SettingString, PasswordLength: 32, PasswordSafe: true, Secret: true, Description: "Secret Hasura sends with action and event requests to Django", Services: hasuraServices},
+	{Key: "hasura_graphql_admin_secret", Type: SettingString, PasswordLength: 32, PasswordSafe: true, Secret: true, Description: "Admin secret for the Hasura GraphQL engine and console", Services: hasuraServices},
+	{Key: "hasura_graphql_dev_mode", Type: SettingBool, Default: true, Description: "Include debugging details in GraphQL errors", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_enable_console", Type: SettingBool, Default: false, Description: "Serve the Hasura console", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_enabled_log_types", Type: SettingString, Default: "startup, http-log, webhook-log, websocket-log, query-log", Description: "Comma-separated types of logs Hasura writes", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_enable_telemetry", Type: SettingBool, Default: false, Description: "Send anonymous usage statistics to Hasura", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_server_host", Type: SettingString, Default: "graphql_engine", Description: "Hostname of the Hasura container", Services: hasuraServices},
+	{Key: "hasura_graphql_server_hostname", Type: SettingString, Default: "graphql_engine", Description: "Hostname Django uses to reach Hasura", Services: hasuraServices},
+	{Key: "hasura_graphql_insecure_skip_tls_verify", Type: SettingBool, Default: true, Description: "Skip TLS verification for Hasura's requests to Django", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_log_level", Type: SettingString, Default: "warn", Allowed: []string{"debug", "info", "warn", "error"}, Description: "Minimum level of Hasura's logs", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_metadata_dir", Type: SettingString, Default: "/metadata", Description: "Directory of Hasura's metadata inside its container", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_migrations_dir", Type: SettingString, Default: "/migrations", Description: "Directory of Hasura's migrations inside its container", Services: []string{"graphql_engine"}},
+	{Key: "hasura_graphql_server_port", Type: SettingPort, Default: 8080, Description: "Port Hasura listens on", Services: hasuraServices},
+
+	// Docker & Django health check configuration
+	{Key: "healthcheck_container_mem_max", Type: SettingInt, Default: 90, Min: 1, Max: 100, Description: "Percentage of a container's memory limit used before `healthcheck` warns"},
+	{Key: "healthcheck_disk_usage_max", Type: SettingInt, Default: 90, Min: 1, Max: 100, Description: "Percentage of disk space used before `healthcheck` warns"},
+	{Key: "healthcheck_interval", Type: SettingDuration, Default: "300s", Description: "Time between the containers' health checks", Services: allServices},
+	{Key: "healthcheck_mem_min", Type: SettingInt, Default: 100, Description: "Megabytes of free memory below which `healthcheck` warns"},
+	{Key: "healthcheck_retries", Type: SettingInt, Default: 3, Min: 1, Description: "Failed health checks before a container is unhealthy", Services: allServices},
+	{Key: "healthcheck_start", Type: SettingDuration, Default: "60s", Description: "Time containers have to start before failed health checks count", Services: allServices},
+	{Key: "healthcheck_timeout", Type: SettingDuration, Default: "30s", Description: "Time before a container's health check fails", Services: allServices},
+}
+
+// LookupSetting finds a setting by its key or alias, ignoring case.
+func LookupSetting(key string) (*Setting, bool) {
+	key = strings.ToLower(key)
+	if target, found := settingAliases[key]; found {
+		key = target
+	}
+	index := slices.IndexFunc(settings, func(setting Setting) bool { return setting.Key == key })
+	if index < 0 {
+		return nil, false
+	}
+	return &settings[index], true
+}
+
+// Values accepted for boolean settings, as understood by both Django and Hasura
+var boolValues = []string{"true", "false", "1", "0", "yes", "no", "on", "off"}
+
+// Reports whether the setting holds text or a list, which can be empty
+func (this *Setting) isText() bool {
+	return slices.Contains([]SettingType{SettingString, SettingList, SettingHosts, SettingOrigins}, this.Type)
+}
+
+// Validate checks that a value is valid for the setting.
+// References to external sources, such as `file:/run/secrets/postgres_password`, aren't checked.
+func (this *Setting) Validate(value string) error {
+	if IsSecretRef(value) {
+		return nil
+	}
+	if value == "" && !this.isText() {
+		return fmt.Errorf("a %s value is required", this.Type)
+	}
+	switch this.Type {
+	case SettingHosts, SettingOrigins:
+		for _, entry := range strings.Fields(value) {
+			if err := validateListEntry(this.Key, entry); err != nil {
+				return err
+			}
+		}
+	case SettingBool:
+		if !slices.Contains(boolValues, strings.ToLower(value)) {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+	case SettingInt:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		if number < this.Min || (this.Max > 0 && number > this.Max) {
+			if this.Max > 0 {
+				return fmt.Errorf("%d is not between %d and %d", number, this.Min, this.Max)
+			}
+			return fmt.Errorf("%d is less than the minimum of %d", number, this.Min)
+		}
+	case SettingPort:
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%q is not a port number between 1 and 65535", value)
+		}
+	case SettingDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%q is not a duration, such as 30s or 5m", value)
+		}
+	case SettingEmail:
+		if _, err := mail.ParseAddress(value); err != nil {
+			return fmt.Errorf("%q is not an email address", value)
+		}
+	}
+	if len(this.Allowed) > 0 && !slices.Contains(this.Allowed, value) {
+		return fmt.Errorf("%q is not one of: %s", value, strings.Join(this.Allowed, ", "))
+	}
+	return nil
+}
+
+// Counts the single-character edits needed to turn one string into another
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// SuggestSettings returns up to three known keys or aliases that are close to an unknown key,
+// closest first, for "did you mean" messages.
+func SuggestSettings(key string) []string {
+	key = strings.ToLower(key)
+	type candidate struct {
+		key      string
+		distance int
+	}
+	var candidates []candidate
+	consider := func(known string) {
+		distance := editDistance(key, known)
+		// Allow roughly one typo for every four characters, or a key that's part of the name
+		if distance <= max(2, len(key)/4) || (len(key) >= 4 && strings.Contains(known, key)) {
+			candidates = append(candidates, candidate{known, distance})
+		}
+	}
+	for _, setting := range settings {
+		consider(setting.Key)
+	}
+	for alias := range settingAliases {
+		consider(alias)
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	suggestions := []string{}
+	for _, candidate := range candidates[:min(3, len(candidates))] {
+		suggestions = append(suggestions, candidate.key)
+	}
+	return suggestions
+}
+
+// Returned when setting a key that isn't in the schema
+var ErrUnknownSetting = errors.New("unknown setting")
+
+// Formats the suggestions for an unknown key, e.g., " (did you mean DJANGO_PORT?)", or an empty string
+func didYouMean(key string) string {
+	suggestions := SuggestSettings(key)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	for i, suggestion := range suggestions {
+		suggestions[i] = strings.ToUpper(suggestion)
+	}
+	return fmt.Sprintf(" (did you mean %s?)", strings.Join(suggestions, " or "))
+}
+
+// ValidateSetting checks a key and value before they're set. Unknown keys return an error wrapping
+// `ErrUnknownSetting` with "did you mean" suggestions.
+func ValidateSetting(key, value string) error {
+	setting, found := LookupSetting(key)
+	if !found {
+		return fmt.Errorf("%w: %s%s", ErrUnknownSetting, strings.ToUpper(key), didYouMean(key))
+	}
+	if err := setting.Validate(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", strings.ToUpper(setting.Key), err)
+	}
+	return nil
+}
+
+// ConfigIssue is a problem found in the environment file by `Validate`.
+type ConfigIssue struct {
+	Key      string   `json:"key"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+}
+
+// Validate checks every value in the environment file against the schema. Invalid values are critical
+// and unknown keys, which may be typos, are warnings.
+func (this *GWEnvironment) Validate() []ConfigIssue {
+	issues := []ConfigIssue{}
+	for _, config := range this.GetAll() {
+		if _, isAlias := settingAliases[config.Key]; isAlias {
+			continue
+		}
+		key := strings.ToUpper(config.Key)
+		setting, found := LookupSetting(config.Key)
+		if !found {
+			issues = append(issues, ConfigIssue{Key: key, Message: "Unknown setting" + didYouMean(config.Key), Severity: SeverityWarning})
+		} else if err := setting.Validate(config.Val); err != nil {
+			issues = append(issues, ConfigIssue{Key: key, Message: err.Error(), Severity: SeverityCritical})
+		}
+	}
+	return issues
+}
+
+// Shown in place of the values of secrets
+const secretMask = "********"
+
+// Parts of the names of unknown settings that probably hold secrets
+var secretNameParts = []string{"password", "secret", "api_key", "token", "private_key"}
+
+// IsSecret checks if a setting holds a password, key, or other secret. Unknown settings are treated
+// as secrets if their names look like one, e.g., `SMTP_PASSWORD`.
+func IsSecret(key string) bool {
+	if setting, found := LookupSetting(key); found {
+		return setting.Secret
+	}
+	key = strings.ToLower(key)
+	return slices.ContainsFunc(secretNameParts, func(part string) bool { return strings.Contains(key, part) })
+}
+
+// MaskedValue returns the value of a setting for display, masked if it's a secret that isn't empty.
+// References to external sources are shown as they are.
+func MaskedValue(key, value string) string {
+	if value != "" && IsSecret(key) && !IsSecretRef(value) {
+		return secretMask
+	}
+	return value
+}
+
+// SettingInfo describes a setting and its current value, as shown by `config describe`.
+type SettingInfo struct {
+	Key         string      `json:"key"`
+	Aliases     []string    `json:"aliases"`
+	Description string      `json:"description"`
+	Type        SettingType `json:"type"`
+	Allowed     []string    `json:"allowed"`
+	Default     string      `json:"default"`
+	Value       string      `json:"value"`
+	Secret      bool        `json:"secret"`
+	// Containers that must be recreated for a change to take effect
+	Services []string `json:"services"`
+}
+
+// Describes a setting with its current value
+func (this *GWEnvironment) describeSetting(setting *Setting) SettingInfo {
+	info := SettingInfo{
+		Key:         strings.ToUpper(setting.Key),
+		Aliases:     []string{},
+		Description: setting.Description,
+		Type:        setting.Type,
+		Allowed:     append([]string{}, setting.Allowed...),
+		Default:     fmt.Sprint(setting.Default),
+		Value:       this.Get(setting.Key),
+		Secret:      setting.Secret,
+		Services:    append([]string{}, setting.Services...),
+	}
+	if setting.PasswordLength > 0 {
+		info.Default = fmt.Sprintf("random %d-character password", setting.PasswordLength)
+	}
+	for alias, key := range settingAliases {
+		if key == setting.Key {
+			info.Aliases = append(info.Aliases, strings.ToUpper(alias))
+		}
+	}
+	slices.Sort(info.Aliases)
+	return info
+}
+
+// DescribeSettings describes the settings with the given keys or aliases, or every setting if none
+// are given. Unknown keys return an error wrapping `ErrUnknownSetting`.
+func (this *GWEnvironment) DescribeSettings(keys ...string) ([]SettingInfo, error) {
+	result := []SettingInfo{}
+	if len(keys) == 0 {
+		for i := range settings {
+			result = append(result, this.describeSetting(&settings[i]))
+		}
+		return result, nil
+	}
+	for _, key := range keys {
+		setting, found := LookupSetting(key)
+		if !found {
+			return nil, fmt.Errorf("%w: %s%s", ErrUnknownSetting, strings.ToUpper(key), didYouMean(key))
+		}
+		result = append(result, this.describeSetting(setting))
+	}
+	return result, nil
+}
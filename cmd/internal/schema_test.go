@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingDefaultsAreValid(t *testing.T) {
+	for _, setting := range settings {
+		if setting.PasswordLength > 0 {
+			assert.True(t, setting.Secret, "Expected generated password %s to be a secret", setting.Key)
+			continue
+		}
+		assert.NoError(t, setting.Validate(fmt.Sprint(setting.Default)), "Expected the default of %s to be valid", setting.Key)
+	}
+	for alias, key := range settingAliases {
+		setting, found := LookupSetting(alias)
+		assert.True(t, found, "Expected alias %s to be found", alias)
+		assert.Equal(t, key, setting.Key)
+	}
+}
+
+func TestSettingValidate(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"django_port", "8000", true},
+		{"DJANGO_PORT", "banana", false},
+		{"django_port", "70000", false},
+		{"django_port", "", false},
+		{"django_compress_enabled", "False", true},
+		{"django_compress_enabled", "maybe", false},
+		{"django_web_concurrency", "0", false},
+		{"healthcheck_disk_usage_max", "101", false},
+		{"healthcheck_disk_usage_max", "80", true},
+		{"healthcheck_interval", "5m", true},
+		{"healthcheck_interval", "300", false},
+		{"django_superuser_email", "admin@example.com", true},
+		{"django_superuser_email", "admin", false},
+		{"django_account_email_verification", "mandatory", true},
+		{"django_account_email_verification", "always", false},
+		{"django_mailgun_api_key", "", true},
+		{"spacy", "en_core_web_lg", true},
+	}
+	for _, test := range tests {
+		err := ValidateSetting(test.key, test.value)
+		if test.valid {
+			assert.NoError(t, err, "Expected %s=%q to be valid", test.key, test.value)
+		} else {
+			assert.Error(t, err, "Expected %s=%q to be invalid", test.key, test.value)
+		}
+	}
+}
+
+func TestSuggestSettings(t *testing.T) {
+	assert.Equal(t, []string{"django_port"}, SuggestSettings("DJANGO_PROT"))
+	assert.Equal(t, []string{"date_format"}, SuggestSettings("date_fromat"))
+	assert.Contains(t, SuggestSettings("superuser_password"), "django_superuser_password")
+	assert.Empty(t, SuggestSettings("completely_unrelated"))
+
+	err := ValidateSetting("DJANGO_PROT", "8000")
+	assert.True(t, errors.Is(err, ErrUnknownSetting), "Expected an unknown setting error")
+	assert.ErrorContains(t, err, "did you mean DJANGO_PORT?")
+}
+
+func TestEnvironmentValidate(t *testing.T) {
+	defer quietTests()()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DJANGO_PORT='banana'\nDJANGO_PROT='8000'\nADMIN_PASSWORD='secret'\n"), 0600))
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+
+	issues := env.Validate()
+	assert.Equal(t, []ConfigIssue{
+		{Key: "DJANGO_PORT", Message: `"banana" is not a port number between 1 and 65535`, Severity: SeverityCritical},
+		{Key: "DJANGO_PROT", Message: "Unknown setting (did you mean DJANGO_PORT?)", Severity: SeverityWarning},
+	}, issues)
+}
+
+func TestDescribeSettings(t *testing.T) {
+	defer quietTests()()
+	env, err := ReadEnv(t.TempDir())
+	assert.NoError(t, err)
+
+	all, err := env.DescribeSettings()
+	assert.NoError(t, err)
+	assert.Equal(t, len(settings), len(all), "Expected every setting to be described")
+
+	described, err := env.DescribeSettings("admin_password", "DJANGO_PORT")
+	assert.NoError(t, err)
+	assert.Equal(t, "DJANGO_SUPERUSER_PASSWORD", described[0].Key)
+	assert.Equal(t, []string{"ADMIN_PASSWORD"}, described[0].Aliases)
+	assert.Equal(t, env.Get("admin_password"), described[0].Value)
+	assert.True(t, described[0].Secret)
+	assert.Equal(t, "random 32-character password", described[0].Default)
+	assert.Equal(t, SettingInfo{
+		Key:         "DJANGO_PORT",
+		Aliases:     []string{},
+		Description: "Port Django listens on inside its container",
+		Type:        SettingPort,
+		Allowed:     []string{},
+		Default:     "8000",
+		Value:       "8000",
+		Services:    []string{"django", "nginx", "graphql_engine"},
+	}, described[1])
+
+	_, err = env.DescribeSettings("DJANGO_PROT")
+	assert.ErrorIs(t, err, ErrUnknownSetting)
+}
+
+func TestMaskedValue(t *testing.T) {
+	assert.Equal(t, "********", MaskedValue("POSTGRES_PASSWORD", "hunter2"))
+	assert.Equal(t, "********", MaskedValue("admin_password", "hunter2"), "Expected aliases of secrets to be masked")
+	assert.Equal(t, "********", MaskedValue("SMTP_PASSWORD", "hunter2"), "Expected unknown settings named like secrets to be masked")
+	assert.Equal(t, "", MaskedValue("django_mailgun_api_key", ""), "Expected empty secrets to stay empty")
+	assert.Equal(t, "8000", MaskedValue("django_port", "8000"))
+	assert.Equal(t, "file:/run/secrets/postgres_password", MaskedValue("postgres_password", "file:/run/secrets/postgres_password"), "Expected references to be shown")
+}
@@ -0,0 +1,130 @@
+package internal
+
+// References in the environment file to values kept elsewhere, such as a password manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Prefixes of values that reference an external source
+var secretRefPrefixes = []string{"file:", "env:", "exec:"}
+
+// How long an `exec:` command can run, allowing time to unlock a password manager
+const secretRefTimeout = time.Minute
+
+// IsSecretRef reports whether the value references an external source:
+//
+//	file:/run/secrets/postgres_password  reads the contents of the file
+//	env:GW_POSTGRES_PASSWORD             reads the environment variable
+//	exec:pass show ghostwriter/postgres  runs the command and reads its output
+//
+// Trailing line breaks are removed from files and command output.
+func IsSecretRef(value string) bool {
+	for _, prefix := range secretRefPrefixes {
+		if strings.HasPrefix(value, prefix) && len(value) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the value that a reference points to
+func resolveSecretRef(ref string) (string, error) {
+	source, target, _ := strings.Cut(ref, ":")
+	switch source {
+	case "file":
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return "", fmt.Errorf("could not read %s: %w", target, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "env":
+		value, found := os.LookupEnv(target)
+		if !found {
+			return "", fmt.Errorf("environment variable %s is not set", target)
+		}
+		return value, nil
+	case "exec":
+		args := splitCommand(target)
+		if len(args) == 0 {
+			return "", fmt.Errorf("no command in %q", ref)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), secretRefTimeout)
+		defer cancel()
+		command := exec.CommandContext(ctx, args[0], args[1:]...)
+		command.Stdin = os.Stdin
+		command.Stderr = os.Stderr
+		out, err := command.Output()
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%s did not finish within %s", args[0], secretRefTimeout)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s failed: %w", args[0], err)
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+	}
+	return "", fmt.Errorf("unknown reference %q", ref)
+}
+
+// A value read from an external source
+type secretRef struct {
+	// Reference in the environment file, e.g., `file:/run/secrets/postgres_password`
+	ref string
+	// Value the reference resolved to
+	value string
+}
+
+// ResolveRefs replaces values that reference an external source with the values they point to.
+// `Save` writes the references back instead of the values, and fails if a resolved value was changed.
+func (this *GWEnvironment) ResolveRefs() error {
+	for _, entry := range this.GetAll() {
+		// Aliases are resolved with the settings they point to
+		if _, alias := settingAliases[entry.Key]; alias || !IsSecretRef(entry.Val) {
+			continue
+		}
+		value, err := resolveSecretRef(entry.Val)
+		if err != nil {
+			return fmt.Errorf("could not resolve %s: %w", strings.ToUpper(entry.Key), err)
+		}
+		if this.refs == nil {
+			this.refs = map[string]secretRef{}
+		}
+		this.refs[entry.Key] = secretRef{ref: entry.Val, value: value}
+		this.env.Set(entry.Key, value)
+	}
+	return nil
+}
+
+// ResolvedValue gets the value of a setting, reading it from the external source if it's a reference
+// that `ResolveRefs` hasn't resolved.
+func (this *GWEnvironment) ResolvedValue(key string) (string, error) {
+	value := this.Get(key)
+	if !IsSecretRef(value) {
+		return value, nil
+	}
+	resolved, err := resolveSecretRef(value)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %w", strings.ToUpper(key), err)
+	}
+	return resolved, nil
+}
+
+// Gets the resolved value of the setting, which is found by its name or an alias
+func (this *GWEnvironment) lookupRef(key string) (secretRef, bool) {
+	if setting, found := LookupSetting(key); found {
+		key = setting.Key
+	}
+	ref, found := this.refs[strings.ToLower(key)]
+	return ref, found
+}
+
+// Ref gets the external source that a resolved value was read from, or an empty string.
+func (this *GWEnvironment) Ref(key string) string {
+	ref, _ := this.lookupRef(key)
+	return ref.ref
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSecretRef(t *testing.T) {
+	assert.True(t, IsSecretRef("file:/run/secrets/postgres_password"))
+	assert.True(t, IsSecretRef("env:GW_POSTGRES_PASSWORD"))
+	assert.True(t, IsSecretRef("exec:pass show ghostwriter/postgres"))
+	assert.False(t, IsSecretRef("file:"))
+	assert.False(t, IsSecretRef("Ab3file:xyz"))
+	assert.False(t, IsSecretRef("https://ghostwriter.local"))
+}
+
+func TestResolveSecretRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "postgres_password")
+	assert.NoError(t, os.WriteFile(path, []byte("from a file\n"), 0600))
+	value, err := resolveSecretRef("file:" + path)
+	assert.NoError(t, err)
+	assert.Equal(t, "from a file", value)
+	_, err = resolveSecretRef("file:" + path + ".missing")
+	assert.Error(t, err)
+
+	t.Setenv("GW_TEST_SECRET", "from the environment")
+	value, err = resolveSecretRef("env:GW_TEST_SECRET")
+	assert.NoError(t, err)
+	assert.Equal(t, "from the environment", value)
+	_, err = resolveSecretRef("env:GW_TEST_SECRET_MISSING")
+	assert.ErrorContains(t, err, "not set")
+
+	if runtime.GOOS != "windows" {
+		value, err = resolveSecretRef("exec:echo from a command")
+		assert.NoError(t, err)
+		assert.Equal(t, "from a command", value)
+		_, err = resolveSecretRef("exec:false")
+		assert.Error(t, err)
+	}
+}
+
+func TestEnvironmentRefs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "postgres_password")
+	assert.NoError(t, os.WriteFile(path, []byte("from a file\n"), 0600))
+	t.Setenv("GW_TEST_ADMIN_SECRET", "from the environment")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("POSTGRES_PASSWORD='file:"+path+"'\nHASURA_GRAPHQL_ADMIN_SECRET='env:GW_TEST_ADMIN_SECRET'\n"), 0600))
+
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "file:"+path, env.Get("postgres_password"), "Expected references to be left alone until resolved")
+	assert.Nil(t, env.ComposeEnv())
+
+	assert.NoError(t, env.ResolveRefs())
+	assert.Equal(t, "from a file", env.Get("postgres_password"))
+	assert.Equal(t, "from the environment", env.Get("hasura_password"), "Expected aliases to get the resolved value")
+	assert.Equal(t, "env:GW_TEST_ADMIN_SECRET", env.Ref("HASURA_PASSWORD"))
+	assert.Equal(t, "", env.Ref("django_secret_key"))
+	assert.Contains(t, env.ComposeEnv(), "POSTGRES_PASSWORD=from a file")
+
+	// References are saved instead of the values
+	env.Set("django_date_format", "Y M d")
+	assert.NoError(t, env.Save())
+	data, err := os.ReadFile(filepath.Join(dir, ".env"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "POSTGRES_PASSWORD='file:"+path+"'")
+	assert.Contains(t, string(data), "HASURA_PASSWORD='env:GW_TEST_ADMIN_SECRET'")
+	assert.NotContains(t, string(data), "from a file")
+	assert.NotContains(t, string(data), "from the environment")
+
+	env.Set("postgres_password", "changed")
+	assert.ErrorContains(t, env.Save(), "change it there instead")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("POSTGRES_PASSWORD='env:GW_TEST_SECRET_MISSING'\n"), 0600))
+	env, err = ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, env.ResolveRefs(), "POSTGRES_PASSWORD")
+}
+
+func TestRotateSecretsWithRefs(t *testing.T) {
+	defer quietTests()()
+	dockerInterface, _ := newRotateDockerInterface(t)
+	t.Setenv("GW_TEST_SECRET_KEY", "from the environment")
+	dockerInterface.Env.Set("django_secret_key", "env:GW_TEST_SECRET_KEY")
+	assert.NoError(t, dockerInterface.Env.ResolveRefs())
+	assert.ErrorContains(t, dockerInterface.RotateSecrets([]string{"django_secret_key"}), "rotate it there instead")
+	assert.Equal(t, "from the environment", dockerInterface.Env.Get("django_secret_key"))
+}
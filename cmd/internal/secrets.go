@@ -0,0 +1,122 @@
+package internal
+
+// Encrypting the environment file with a passphrase, so a copy of the data directory
+// doesn't expose the database and Hasura credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Name of the encrypted environment file, which replaces `.env`
+	EncryptedEnvFile = ".env.enc"
+	// Environment variable with the passphrase for the encrypted environment file
+	PassphraseEnvVar = "GHOSTWRITER_CLI_PASSPHRASE"
+	// Environment variable with the path to a file holding the passphrase
+	KeyFileEnvVar = "GHOSTWRITER_CLI_KEY_FILE"
+
+	encryptedEnvVersion = 1
+	// scrypt parameters recommended for interactive logins as of 2017
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Format of the encrypted environment file
+// Byte slices are stored as base64 by `encoding/json`.
+type encryptedEnv struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// GetPassphrase gets the passphrase for the encrypted environment file from the key file
+// in `GHOSTWRITER_CLI_KEY_FILE`, or else from `GHOSTWRITER_CLI_PASSPHRASE`.
+func GetPassphrase() ([]byte, error) {
+	if path := os.Getenv(KeyFileEnvVar); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the key file: %w", err)
+		}
+		passphrase := strings.TrimSpace(string(data))
+		if passphrase == "" {
+			return nil, fmt.Errorf("the key file %s is empty", path)
+		}
+		return []byte(passphrase), nil
+	}
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	return nil, fmt.Errorf("%w: set %s to the path of a key file or %s to the passphrase", ErrPassphraseMissing, KeyFileEnvVar, PassphraseEnvVar)
+}
+
+// Derives the AES-256-GCM cipher for the passphrase and salt
+func envCipher(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, fmt.Errorf("could not derive the encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypts the contents of an environment file with the passphrase
+func encryptEnv(plaintext, passphrase []byte) ([]byte, error) {
+	envelope := encryptedEnv{Version: encryptedEnvVersion, KDF: "scrypt", N: scryptN, R: scryptR, P: scryptP, Salt: make([]byte, 16)}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return nil, fmt.Errorf("could not generate a salt: %w", err)
+	}
+	aead, err := envCipher(passphrase, envelope.Salt, envelope.N, envelope.R, envelope.P)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return nil, fmt.Errorf("could not generate a nonce: %w", err)
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, plaintext, nil)
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// Decrypts the contents of an encrypted environment file with the passphrase
+func decryptEnv(data, passphrase []byte) ([]byte, error) {
+	var envelope encryptedEnv
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("could not parse the encrypted environment file: %w", err)
+	}
+	if envelope.Version != encryptedEnvVersion || envelope.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported encrypted environment file: version %d with %q", envelope.Version, envelope.KDF)
+	}
+	// Cap the cost so a modified file can't exhaust the memory
+	if envelope.N > 1<<18 || envelope.R > 16 || envelope.P > 4 {
+		return nil, fmt.Errorf("unsupported encrypted environment file: scrypt parameters are too large")
+	}
+	aead, err := envCipher(passphrase, envelope.Salt, envelope.N, envelope.R, envelope.P)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrDecryptionFailed)
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong passphrase or modified file", ErrDecryptionFailed)
+	}
+	return plaintext, nil
+}
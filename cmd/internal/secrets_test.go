@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptEnv(t *testing.T) {
+	plaintext := []byte("POSTGRES_PASSWORD='secret'\n")
+	data, err := encryptEnv(plaintext, []byte("passphrase"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	decrypted, err := decryptEnv(data, []byte("passphrase"))
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	_, err = decryptEnv(data, []byte("wrong"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	_, err = decryptEnv([]byte("POSTGRES_PASSWORD='secret'"), []byte("passphrase"))
+	assert.Error(t, err)
+}
+
+func TestGetPassphrase(t *testing.T) {
+	t.Setenv(KeyFileEnvVar, "")
+	t.Setenv(PassphraseEnvVar, "")
+	_, err := GetPassphrase()
+	assert.ErrorIs(t, err, ErrPassphraseMissing)
+
+	t.Setenv(PassphraseEnvVar, "from the environment")
+	passphrase, err := GetPassphrase()
+	assert.NoError(t, err)
+	assert.Equal(t, "from the environment", string(passphrase))
+
+	keyFile := filepath.Join(t.TempDir(), "ghostwriter.key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte("from the key file\n"), 0600))
+	t.Setenv(KeyFileEnvVar, keyFile)
+	passphrase, err = GetPassphrase()
+	assert.NoError(t, err)
+	assert.Equal(t, "from the key file", string(passphrase), "Expected the key file to take precedence")
+}
+
+func TestEncryptedEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(KeyFileEnvVar, "")
+	t.Setenv(PassphraseEnvVar, "passphrase")
+
+	env, err := ReadEnv(dir)
+	assert.NoError(t, err)
+	password := env.Get("postgres_password")
+	assert.False(t, env.Encrypted())
+	assert.Nil(t, env.ComposeEnv(), "Expected Compose to read the plaintext file itself")
+
+	assert.NoError(t, env.Encrypt([]byte("passphrase")))
+	assert.False(t, FileExists(filepath.Join(dir, ".env")), "Expected the plaintext file to be removed")
+	data, err := os.ReadFile(filepath.Join(dir, EncryptedEnvFile))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), password)
+	assert.Contains(t, env.ComposeEnv(), "POSTGRES_PASSWORD="+password)
+
+	// Changes are saved to the encrypted file
+	env.Set("django_date_format", "Y M d")
+	assert.NoError(t, env.Save())
+	env, err = ReadEnv(dir)
+	assert.NoError(t, err)
+	assert.True(t, env.Encrypted())
+	assert.Equal(t, password, env.Get("postgres_password"))
+	assert.Equal(t, "Y M d", env.Get("django_date_format"))
+	assert.False(t, FileExists(filepath.Join(dir, ".env")), "Expected no plaintext file to be created")
+
+	t.Setenv(PassphraseEnvVar, "wrong")
+	_, err = ReadEnv(dir)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	t.Setenv(PassphraseEnvVar, "")
+	_, err = ReadEnv(dir)
+	assert.ErrorIs(t, err, ErrPassphraseMissing)
+
+	assert.NoError(t, env.Decrypt())
+	assert.False(t, FileExists(filepath.Join(dir, EncryptedEnvFile)), "Expected the encrypted file to be removed")
+	data, err = os.ReadFile(filepath.Join(dir, ".env"))
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), "POSTGRES_PASSWORD='"+password+"'"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, EncryptedEnvFile), []byte("{}"), 0600))
+	_, err = ReadEnv(dir)
+	assert.ErrorContains(t, err, "both")
+}
+
+func TestEncryptedEnvironmentCompose(t *testing.T) {
+	defer quietTests()()
+	t.Setenv(KeyFileEnvVar, "")
+	t.Setenv(PassphraseEnvVar, "passphrase")
+	dockerInterface, runtime := newFakeDockerInterface(t)
+	assert.NoError(t, dockerInterface.Up())
+	assert.Equal(t, []string{
+		"DJANGO_CSRF_COOKIE_SECURE=false",
+		"DJANGO_SECURE_SSL_REDIRECT=false",
+		"DJANGO_SESSION_COOKIE_SECURE=false",
+		"DJANGO_SETTINGS_MODULE=config.settings.local",
+		"HASURA_GRAPHQL_DEV_MODE=true",
+	}, runtime.ComposeEnv, "Expected only the mode profile to be passed to Compose with a plaintext file")
+
+	assert.NoError(t, dockerInterface.Env.Encrypt([]byte("passphrase")))
+	assert.NoError(t, dockerInterface.Up())
+	assert.Contains(t, runtime.ComposeEnv, "POSTGRES_PASSWORD="+dockerInterface.Env.Get("postgres_password"), "Expected the decrypted values to be passed to Compose")
+}
@@ -18,12 +18,12 @@ import (
 )
 
 // GetCwdFromExe gets the current working directory based on "ghostwriter-cli" location.
-func GetCwdFromExe() string {
+func GetCwdFromExe() (string, error) {
 	exe, err := os.Executable()
 	if err != nil {
-		log.Fatalf("Failed to get path to current executable")
+		return "", fmt.Errorf("failed to get path to current executable: %w", err)
 	}
-	return filepath.Dir(exe)
+	return filepath.Dir(exe), nil
 }
 
 // FileExists determines if a given string is a valid filepath.
@@ -61,7 +61,11 @@ func CheckPath(cmd string) bool {
 func GetLocalGhostwriterVersion() (string, error) {
 	var output string
 
-	versionFile := filepath.Join(GetCwdFromExe(), "VERSION")
+	cwd, err := GetCwdFromExe()
+	if err != nil {
+		return output, err
+	}
+	versionFile := filepath.Join(cwd, "VERSION")
 	if FileExists(versionFile) {
 		file, err := os.Open(versionFile)
 		if err != nil {
@@ -153,6 +157,13 @@ func Contains(slice []string, target string) bool {
 	return false
 }
 
+// Splits a command into the program and its arguments on whitespace. Commands are run without a
+// shell, so quotes, variables, and pipes aren't interpreted. Used for notification scripts and
+// `exec:` references.
+func splitCommand(command string) []string {
+	return strings.Fields(command)
+}
+
 // Silence any output from tests.
 // Place `defer quietTests()()` after test declarations.
 // Ref: https://stackoverflow.com/a/58720235
@@ -174,7 +185,8 @@ func quietTests() func() {
 // AskForConfirmation asks the user for confirmation. A user must type in "yes" or "no" and
 // then press enter. It has fuzzy matching, so "y", "Y", "yes", "YES", and "Yes" all count as
 // confirmations. If the input is not recognized, it will ask again. The function does not return
-// until it gets a valid response from the user.
+// until it gets a valid response from the user. If input cannot be read (e.g., stdin is closed), it
+// returns false.
 // Original source: https://gist.github.com/r0l1/3dcbb0c8f6cfe9c66ab8008f55f8f28b
 func AskForConfirmation(s string) bool {
 	reader := bufio.NewReader(os.Stdin)
@@ -184,7 +196,8 @@ func AskForConfirmation(s string) bool {
 
 		response, err := reader.ReadString('\n')
 		if err != nil {
-			log.Fatal(err)
+			fmt.Println()
+			return false
 		}
 
 		response = strings.ToLower(strings.TrimSpace(response))
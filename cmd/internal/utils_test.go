@@ -10,7 +10,8 @@ import (
 )
 
 func TestGetCwdFromExe(t *testing.T) {
-	cwd := GetCwdFromExe()
+	cwd, err := GetCwdFromExe()
+	assert.NoError(t, err, "Expected `GetCwdFromExe()` to return no error")
 	assert.False(t, cwd == "", "Expected `GetCwdFromExe()` to return a non-empty string")
 }
 
@@ -29,7 +30,9 @@ func TestContains(t *testing.T) {
 
 func TestGetLocalGhostwriterVersion(t *testing.T) {
 	// Mock the Ghostwriter VERSION file
-	versionFile := filepath.Join(GetCwdFromExe(), "VERSION")
+	cwd, err := GetCwdFromExe()
+	assert.NoError(t, err, "Expected `GetCwdFromExe()` to return no error")
+	versionFile := filepath.Join(cwd, "VERSION")
 	f, err := os.Create(versionFile)
 	assert.NoError(t, err, "Expected `os.Create()` to return no error")
 
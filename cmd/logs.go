@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +23,7 @@ Valid names are:
 * queue
 * redis`,
 	Args: cobra.ExactArgs(1),
-	Run:  readLogs,
+	RunE: readLogs,
 }
 
 func init() {
@@ -33,12 +32,19 @@ func init() {
 	logsCmd.Flags().StringP("lines", "l", "500", "Number of lines to display")
 }
 
-func readLogs(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func readLogs(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	lines := cmd.Flag("lines").Value.String()
 	fmt.Printf("[+] Fetching up to %s lines of logs for `%s`...\n", lines, args[0])
-	logs := dockerInterface.FetchLogs(args[0], lines)
+	logs, err := dockerInterface.FetchLogs(args[0], lines)
+	if err != nil {
+		return err
+	}
 	for _, entry := range logs {
 		fmt.Print(entry)
 	}
+	return nil
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -42,20 +41,23 @@ Example:
   # Navigate to your old Ghostwriter directory and run:
   cd /path/to/old/ghostwriter
   ghostwriter-cli migrate`,
-	Run: migrateFiles,
+	RunE: migrateFiles,
 }
 
 func init() {
 	rootCmd.AddCommand(migrateCmd)
 }
 
-func migrateFiles(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func migrateFiles(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 
 	// Get source (CWD) and destination (data directory) paths
 	sourcePath, err := os.Getwd()
 	if err != nil {
-		log.Fatalf("Failed to get current working directory: %v\n", err)
+		return fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
 	destPath := dockerInterface.Dir
@@ -63,26 +65,29 @@ func migrateFiles(cmd *cobra.Command, args []string) {
 	// Resolve absolute paths for comparison
 	sourceAbs, err := filepath.Abs(sourcePath)
 	if err != nil {
-		log.Fatalf("Failed to resolve source path: %v\n", err)
+		return fmt.Errorf("failed to resolve source path: %w", err)
 	}
 
 	destAbs, err := filepath.Abs(destPath)
 	if err != nil {
-		log.Fatalf("Failed to resolve destination path: %v\n", err)
+		return fmt.Errorf("failed to resolve destination path: %w", err)
 	}
 
 	// Check if source and destination are the same
 	if sourceAbs == destAbs {
-		log.Fatalf("Source and destination directories are the same (%s). Nothing to migrate.\n", sourceAbs)
+		return fmt.Errorf("source and destination directories are the same (%s), so there is nothing to migrate", sourceAbs)
 	}
 
 	// Warn if containers are running
-	runningContainers := dockerInterface.GetRunning()
+	runningContainers, err := dockerInterface.GetRunning()
+	if err != nil {
+		return err
+	}
 	if len(runningContainers) > 0 {
 		fmt.Printf("[!] Warning: Found %d running Ghostwriter container(s).\n", len(runningContainers))
 		if !internal.AskForConfirmation("It's recommended to stop containers before migrating. Continue anyway?") {
 			fmt.Println("Migration cancelled. Consider running 'ghostwriter-cli down' first.")
-			return
+			return nil
 		}
 	}
 
@@ -158,6 +163,7 @@ func migrateFiles(cmd *cobra.Command, args []string) {
 	} else if totalFailed == 0 {
 		fmt.Println("[+] No files needed migration.")
 	}
+	return nil
 }
 
 // migrateSSL migrates SSL certificate files from source to destination.
@@ -303,7 +309,12 @@ func migrateVolumes(dockerInterface *internal.DockerInterface, sourceDir string)
 	}
 
 	// Ensure containers are stopped
-	if len(dockerInterface.GetRunning()) > 0 {
+	running, err := dockerInterface.GetRunning()
+	if err != nil {
+		errors = append(errors, fmt.Errorf("failed to list running containers: %w", err))
+		return 0, 0, errors
+	}
+	if len(running) > 0 {
 		fmt.Println("    Stopping containers before volume migration...")
 		if err := dockerInterface.Down(nil); err != nil {
 			errors = append(errors, fmt.Errorf("failed to stop containers: %w", err))
@@ -313,7 +324,7 @@ func migrateVolumes(dockerInterface *internal.DockerInterface, sourceDir string)
 		fmt.Println("    Waiting for containers to stop...")
 		for i := 0; i < 5; i++ {
 			fmt.Print(".")
-			if len(dockerInterface.GetRunning()) == 0 {
+			if running, err := dockerInterface.GetRunning(); err == nil && len(running) == 0 {
 				break
 			}
 			time.Sleep(1 * time.Second)
@@ -422,7 +433,7 @@ func migrateVolumes(dockerInterface *internal.DockerInterface, sourceDir string)
 		fmt.Println()
 		if internal.AskForConfirmation("Delete old volumes to free disk space? (migrated data is preserved)") {
 			for _, oldVolumeName := range volumeSourceMap {
-				if err := dockerInterface.RunCmd("volume", "rm", oldVolumeName); err != nil {
+				if err := dockerInterface.RemoveVolume(oldVolumeName); err != nil {
 					fmt.Printf("    ⊖ Failed to delete %s: %v\n", oldVolumeName, err)
 				} else {
 					fmt.Printf("    ✓ Deleted %s\n", oldVolumeName)
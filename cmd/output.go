@@ -0,0 +1,113 @@
+package cmd
+
+// Helpers for printing command results as tables or as structured JSON/YAML documents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	yaml "github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+type OutputFormat string
+
+const (
+	// Human-readable tables and progress messages on stdout
+	OutputTable OutputFormat = "table"
+	// JSON document on stdout, progress messages on stderr
+	OutputJSON OutputFormat = "json"
+	// YAML document on stdout, progress messages on stderr
+	OutputYAML OutputFormat = "yaml"
+)
+
+var AllOutputFormats = []string{string(OutputJSON), string(OutputYAML), string(OutputTable)}
+
+// cobra pvalue.Value implementation for argument parsing
+func (e *OutputFormat) String() string {
+	return string(*e)
+}
+func (e *OutputFormat) Set(v string) error {
+	if !slices.Contains(AllOutputFormats, v) {
+		return errors.New("must be one of: " + strings.Join(AllOutputFormats, ", "))
+	}
+	*e = OutputFormat(v)
+	return nil
+}
+func (e *OutputFormat) Type() string {
+	return "OutputFormat"
+}
+
+// Where command results are written, set before each command runs
+var resultWriter io.Writer = os.Stdout
+
+// Undoes the stdout redirection done for structured output
+var restoreStdout = func() {}
+
+// Determines if the command should print a JSON or YAML document instead of tables
+func structuredOutput() bool {
+	return output == OutputJSON || output == OutputYAML
+}
+
+// Prepares the result writer for the command about to run. For structured output, anything else
+// printed to stdout (progress messages, output from `docker`) is sent to stderr instead, so the
+// result can be piped into other tools.
+func setupOutput(cmd *cobra.Command) {
+	resultWriter = cmd.OutOrStdout()
+	if structuredOutput() {
+		redirectProgress(cmd)
+	}
+}
+
+// Sends anything printed to stdout to stderr until the command returns, whether or not it fails.
+// Called before the command runs, since the post-run hooks are skipped when it returns an error.
+func redirectProgress(cmd *cobra.Command) {
+	if os.Stdout == os.Stderr {
+		return
+	}
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	restoreStdout = func() {
+		os.Stdout = stdout
+		restoreStdout = func() {}
+	}
+
+	run := cmd.RunE
+	if run == nil {
+		return
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		cmd.RunE = run
+		defer restoreStdout()
+		return run(cmd, args)
+	}
+}
+
+// Prints the result of a command. In table mode `table` is called to print the human-readable version,
+// so it may be nil for commands that only call this for structured output.
+func printResult(result any, table func(writer io.Writer)) error {
+	switch output {
+	case OutputJSON:
+		encoder := json.NewEncoder(resultWriter)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fmt.Errorf("could not encode result as JSON: %w", err)
+		}
+	case OutputYAML:
+		out, err := yaml.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("could not encode result as YAML: %w", err)
+		}
+		if _, err := resultWriter.Write(out); err != nil {
+			return err
+		}
+	default:
+		table(resultWriter)
+	}
+	return nil
+}
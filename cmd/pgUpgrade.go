@@ -2,8 +2,8 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"regexp"
 	"strconv"
@@ -23,16 +23,18 @@ var pgUpgradeCmd = &cobra.Command{
 
 The production environment is targeted by default. Use the "--mode" argument to upgrade a development environment.
 `,
-	Run: pgUpgrade,
+	RunE: pgUpgrade,
 }
 
 func init() {
 	rootCmd.AddCommand(pgUpgradeCmd)
 }
 
-func pgUpgrade(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
-	dockerInterface.Env.Save()
+func pgUpgrade(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	interfix := ""
 	if dockerInterface.UseDevInfra {
 		interfix = "local"
@@ -45,25 +47,34 @@ func pgUpgrade(cmd *cobra.Command, args []string) {
 	fmt.Print("Press enter to continue, or Ctrl+C to cancel\n")
 	reader.ReadString('\n')
 
-	err := dockerInterface.Down(nil)
+	err = dockerInterface.Down(nil)
 	if err != nil {
-		log.Fatalf("Error trying to bring down the containers with %s: %v\n", dockerInterface.ComposeFile, err)
+		return fmt.Errorf("failed to bring down the containers with %s: %w", dockerInterface.ComposeFile, err)
 	}
 
-	volumeName, networkName := getVolumenAndNetworkName(dockerInterface, interfix)
+	volumeName, networkName, err := getVolumenAndNetworkName(dockerInterface, interfix)
+	if err != nil {
+		return err
+	}
 
 	fmt.Println("[+] Building Postgres container")
 	err = dockerInterface.RunComposeCmd("build", "postgres")
 	if err != nil {
-		log.Fatalf("Error building postgres container with %s: %v\n", dockerInterface.ComposeFile, err)
+		return fmt.Errorf("failed to build postgres container with %s: %w", dockerInterface.ComposeFile, err)
 	}
 
 	fmt.Println("[+] Getting versions")
-	serverVersion := postgresVersionInstalled(dockerInterface)
-	dataVersion := postgresVersionForData(dockerInterface)
+	serverVersion, err := postgresVersionInstalled(dockerInterface)
+	if err != nil {
+		return err
+	}
+	dataVersion, err := postgresVersionForData(dockerInterface)
+	if err != nil {
+		return err
+	}
 	if serverVersion == dataVersion {
 		fmt.Println("No PostgreSQL upgrade needed")
-		return
+		return nil
 	}
 	fmt.Printf("Upgrading PostgreSQL data from %d to %d\n", dataVersion, serverVersion)
 
@@ -75,7 +86,7 @@ func pgUpgrade(cmd *cobra.Command, args []string) {
 		fmt.Sprintf("postgres:%d", dataVersion),
 	)
 	if err != nil {
-		log.Fatalf("Could not start old Postgres server: %v\n", err)
+		return fmt.Errorf("could not start old Postgres server: %w", err)
 	}
 
 	// Wait for it to start
@@ -92,30 +103,30 @@ func pgUpgrade(cmd *cobra.Command, args []string) {
 		fmt.Println("[+] Stopping old Postgres server")
 		stopErr := dockerInterface.RunCmd("stop", "ghostwriter_postgres_upgrade")
 		if stopErr != nil {
-			log.Printf("Could not stop old postgres server: %v\n", err)
+			fmt.Printf("[!] Could not stop old postgres server: %v\n", stopErr)
 		}
-		log.Fatalf("Could not run backup: %v\n", err)
+		return fmt.Errorf("could not run backup: %w", err)
 	}
 
 	fmt.Println("[+] Stopping old Postgres server")
 	err = dockerInterface.RunCmd("stop", "ghostwriter_postgres_upgrade")
 	if err != nil {
-		log.Fatalf("Could not stop old postgres server: %v\n", err)
+		return fmt.Errorf("could not stop old postgres server: %w", err)
 	}
 
 	// Wait for volume to release
 	time.Sleep(2 * time.Second)
 
 	fmt.Println("[+] Removing old Postgres volume")
-	err = dockerInterface.RunCmd("volume", "rm", volumeName)
+	err = dockerInterface.RemoveVolume(volumeName)
 	if err != nil {
-		log.Fatalf("Could not delete old postgres db volume: %v\n", err)
+		return fmt.Errorf("could not delete old postgres db volume: %w", err)
 	}
 
 	fmt.Println("[+] Starting new Postgres container")
 	err = dockerInterface.RunComposeCmd("up", "-d", "postgres")
 	if err != nil {
-		log.Fatalf("Could not start new postgresql database: %v\n", err)
+		return fmt.Errorf("could not start new postgresql database: %w", err)
 	}
 	// Wait for it to start
 	time.Sleep(10 * time.Second)
@@ -127,69 +138,70 @@ func pgUpgrade(cmd *cobra.Command, args []string) {
 		"_ghostwriter_postgres_upgrade.sql.gz",
 	)
 	if err != nil {
-		log.Fatalf("Could not start new postgresql database: %v\n", err)
+		return fmt.Errorf("could not start new postgresql database: %w", err)
 	}
 
 	fmt.Println("[+] All done")
+	return nil
 }
 
-func getVolumenAndNetworkName(dockerInterface *internal.DockerInterface, interfix string) (string, string) {
+func getVolumenAndNetworkName(dockerInterface *internal.DockerInterface, interfix string) (string, string, error) {
 	// Docker returns JSON output, but since YAML is a superset, we can use it to get fields.
 	volumePath, err := yaml.PathString(fmt.Sprintf("$.volumes.%s_postgres_data.name", interfix))
 	if err != nil {
-		log.Fatalf("Could not parse volume path. This is a bug.")
+		return "", "", errors.New("could not parse volume path, which is a bug")
 	}
 	networkPath, err := yaml.PathString("$.networks.default.name")
 	if err != nil {
-		log.Fatalf("Could not parse network path. This is a bug.")
+		return "", "", errors.New("could not parse network path, which is a bug")
 	}
 
-	config, err := dockerInterface.RunCmdWithOutput("compose", "-f", dockerInterface.ComposeFile, "config")
+	config, err := dockerInterface.RunComposeCmdWithOutput("config")
 	if err != nil {
-		log.Fatalf("Could not get docker config: %s\n", err)
+		return "", "", fmt.Errorf("could not get docker config: %w", err)
 	}
 
 	var volume string
 	err = volumePath.Read(strings.NewReader(config), &volume)
 	if err != nil {
-		log.Fatalf("Could not get volume path: %s\n", err)
+		return "", "", fmt.Errorf("could not get volume path: %w", err)
 	}
 
 	var network string
 	err = networkPath.Read(strings.NewReader(config), &network)
 	if err != nil {
-		log.Fatalf("Could not get network path: %s\n", err)
+		return "", "", fmt.Errorf("could not get network path: %w", err)
 	}
 
-	return volume, network
+	return volume, network, nil
 }
 
-func postgresVersionInstalled(dockerInterface *internal.DockerInterface) int {
-	out, err := dockerInterface.RunCmdWithOutput("compose", "-f", dockerInterface.ComposeFile, "run", "--rm", "postgres", "psql", "--version")
+func postgresVersionInstalled(dockerInterface *internal.DockerInterface) (int, error) {
+	out, err := dockerInterface.RunComposeCmdWithOutput("run", "--rm", "postgres", "psql", "--version")
 	if err != nil {
-		log.Fatalf("Error trying to get postgresql server version: %v\n", err)
+		return 0, fmt.Errorf("failed to get postgresql server version: %w", err)
 	}
 
 	match := regexp.MustCompile(`(\d+)\.\d+`).FindStringSubmatch(out)
 	if len(match) == 0 {
-		log.Fatalf("Could not find version in string %v", out)
+		return 0, fmt.Errorf("could not find version in string %v", out)
 	}
 
 	majorVersion, err := strconv.Atoi(match[1])
 	if err != nil {
-		log.Fatalf("Could not parse installed Postgres version of %v: %v", match[1], err)
+		return 0, fmt.Errorf("could not parse installed Postgres version of %v: %w", match[1], err)
 	}
-	return majorVersion
+	return majorVersion, nil
 }
 
-func postgresVersionForData(dockerInterface *internal.DockerInterface) int {
-	out, err := dockerInterface.RunCmdWithOutput("compose", "-f", dockerInterface.ComposeFile, "run", "--rm", "postgres", "cat", "/var/lib/postgresql/data/PG_VERSION")
+func postgresVersionForData(dockerInterface *internal.DockerInterface) (int, error) {
+	out, err := dockerInterface.RunComposeCmdWithOutput("run", "--rm", "postgres", "cat", "/var/lib/postgresql/data/PG_VERSION")
 	if err != nil {
-		log.Fatalf("Error trying to get postgresql data version: %v\n", err)
+		return 0, fmt.Errorf("failed to get postgresql data version: %w", err)
 	}
 	majorVersion, err := strconv.Atoi(strings.TrimSpace(out))
 	if err != nil {
-		log.Fatalf("Error trying to parse postgresql data version string %v: %v\n", out, err)
+		return 0, fmt.Errorf("failed to parse postgresql data version string %v: %w", out, err)
 	}
-	return majorVersion
+	return majorVersion, nil
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"strings"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
@@ -32,7 +31,7 @@ Examples:
   # Restore both database and media files
   ghostwriter-cli restore backup_2023_05_23T15_54_19.sql.gz --media media_backup_2023_05_23T15_54_19.tar.gz`,
 	Args: cobra.ExactArgs(1),
-	Run:  restoreDatabase,
+	RunE: restoreDatabase,
 }
 
 func init() {
@@ -40,12 +39,15 @@ func init() {
 	restoreCmd.Flags().StringVar(&mediaBackupFile, "media", "", "Media backup filename to restore (optional)")
 }
 
-func restoreDatabase(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func restoreDatabase(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 
 	// Validate that containers are running and match the current mode
 	if err := dockerInterface.ValidateContainersRunning(); err != nil {
-		log.Fatalf("%v\n", err)
+		return err
 	}
 
 	confirmMsg := "Do you really want to restore this backup file? This cannot be undone!"
@@ -54,33 +56,37 @@ func restoreDatabase(cmd *cobra.Command, args []string) {
 	}
 	c := internal.AskForConfirmation(confirmMsg)
 	if !c {
-		return
+		return nil
 	}
 
-	dockerInterface.Env.Save()
-
 	fmt.Printf("[+] Restoring the `%s` database backup file...\n", args[0])
-	restore(dockerInterface, args[0])
+	if err := restore(dockerInterface, args[0]); err != nil {
+		return err
+	}
 	if mediaBackupFile != "" {
 		if !strings.HasPrefix(mediaBackupFile, "media_backup_") {
 			fmt.Println("[!] Warning: Media backup filename should start with 'media_backup_'")
 		}
 		fmt.Printf("[+] Restoring the `%s` media backup file...\n", mediaBackupFile)
-		mediaRestore(dockerInterface, mediaBackupFile)
+		if err := mediaRestore(dockerInterface, mediaBackupFile); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // RunDockerComposeRestore executes the "docker compose" command to restore a PostgreSQL database backup in the
 // environment from the specified YAML file ("yaml" parameter).
-func restore(dockerInterface *internal.DockerInterface, restore string) {
+func restore(dockerInterface *internal.DockerInterface, restore string) error {
 	fmt.Printf("[+] Restoring the PostgreSQL database backup file %s with %s...\n", restore, dockerInterface.ComposeFile)
 	backupErr := dockerInterface.RunComposeCmd("run", "--rm", "postgres", "restore", restore)
 	if backupErr != nil {
-		log.Fatalf("Error trying to restore %s with %s: %v\n", restore, dockerInterface.ComposeFile, backupErr)
+		return fmt.Errorf("failed to restore %s with %s: %w", restore, dockerInterface.ComposeFile, backupErr)
 	}
+	return nil
 }
 
-func mediaRestore(dockerInterface *internal.DockerInterface, restore string) {
+func mediaRestore(dockerInterface *internal.DockerInterface, restore string) error {
 	// Determine the volume keys based on the environment
 	var dataVolumeKey, backupVolumeKey string
 	if dockerInterface.UseDevInfra {
@@ -95,12 +101,12 @@ func mediaRestore(dockerInterface *internal.DockerInterface, restore string) {
 	// Get actual volume names from Docker Compose configuration
 	dataVolume, err := dockerInterface.GetVolumeNameFromConfig(dataVolumeKey)
 	if err != nil {
-		log.Fatalf("Failed to get data volume name from compose config: %v\n", err)
+		return fmt.Errorf("failed to get data volume name from compose config: %w", err)
 	}
 
 	backupVolume, err := dockerInterface.GetVolumeNameFromConfig(backupVolumeKey)
 	if err != nil {
-		log.Fatalf("Failed to get backup volume name from compose config: %v\n", err)
+		return fmt.Errorf("failed to get backup volume name from compose config: %w", err)
 	}
 
 	fmt.Printf("[+] Restoring media files from backup %s with %s...\n", restore, dockerInterface.ComposeFile)
@@ -115,7 +121,7 @@ func mediaRestore(dockerInterface *internal.DockerInterface, restore string) {
 		"rm -rf /data/* /data/..?* /data/.[!.]*",
 	)
 	if clearErr != nil {
-		log.Fatalf("Error trying to clear existing media files with %s: %v\n", dockerInterface.ComposeFile, clearErr)
+		return fmt.Errorf("failed to clear existing media files with %s: %w", dockerInterface.ComposeFile, clearErr)
 	}
 
 	// Extract the backup archive to the media volume
@@ -129,7 +135,8 @@ func mediaRestore(dockerInterface *internal.DockerInterface, restore string) {
 		fmt.Sprintf("tar xzf /backups/%s -C /data", restore),
 	)
 	if restoreErr != nil {
-		log.Fatalf("Error trying to restore media files from %s with %s: %v\n", restore, dockerInterface.ComposeFile, restoreErr)
+		return fmt.Errorf("failed to restore media files from %s with %s: %w", restore, dockerInterface.ComposeFile, restoreErr)
 	}
 	fmt.Printf("[+] Media files restored from %s\n", restore)
+	return nil
 }
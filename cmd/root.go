@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
@@ -9,6 +11,11 @@ import (
 
 // Vars for global flags
 var mode internal.DockerMode = internal.ModeProd
+var output OutputFormat = OutputTable
+
+// Builds the Docker interface used by commands
+// Tests replace this to run commands against an `internal.FakeRuntime`
+var getDockerInterface = internal.GetDockerInterface
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -16,6 +23,60 @@ var rootCmd = &cobra.Command{
 	Short: "A command line interface for managing Ghostwriter.",
 	Long: `Ghostwriter CLI is a command line interface for managing the Ghostwriter
 application and associated containers and services. Commands are grouped by their use.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Arguments and flags are valid at this point, so don't print usage for errors returned by the command
+		cmd.SilenceUsage = true
+		setupOutput(cmd)
+	},
+}
+
+// Exit codes for errors returned by commands
+const (
+	exitCodeError             = 1
+	exitCodeNotInstalled      = 10
+	exitCodeRuntimeMissing    = 11
+	exitCodeComposeMissing    = 12
+	exitCodeDaemonUnavailable = 13
+	exitCodePermissionDenied  = 14
+)
+
+// ExitCodeError makes the CLI exit with a specific code, such as the Nagios plugin codes used by `healthcheck`
+type ExitCodeError struct {
+	Code int
+	// Underlying error, if any
+	Err error
+}
+
+func (this *ExitCodeError) Error() string {
+	if this.Err == nil {
+		return fmt.Sprintf("exit code %d", this.Code)
+	}
+	return this.Err.Error()
+}
+
+func (this *ExitCodeError) Unwrap() error {
+	return this.Err
+}
+
+// Maps an error returned by a command to the process exit code
+func exitCodeForError(err error) int {
+	var exitErr *ExitCodeError
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.Is(err, internal.ErrNotInstalled):
+		return exitCodeNotInstalled
+	case errors.Is(err, internal.ErrRuntimeMissing):
+		return exitCodeRuntimeMissing
+	case errors.Is(err, internal.ErrComposeMissing):
+		return exitCodeComposeMissing
+	case errors.Is(err, internal.ErrDaemonUnavailable):
+		return exitCodeDaemonUnavailable
+	case errors.Is(err, internal.ErrPermissionDenied):
+		return exitCodePermissionDenied
+	default:
+		return exitCodeError
+	}
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -23,11 +84,12 @@ application and associated containers and services. Commands are grouped by thei
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exitCodeForError(err))
 	}
 }
 
 func init() {
 	// Persistent flags defined here are global for the CLI
 	rootCmd.PersistentFlags().Var(&mode, "mode", "Set execution mode, one of: `prod` (default; downloads Ghostwriter images), `local-dev`, or `local-prod` (local modes uses the Ghostwriter source code in same directory)")
+	rootCmd.PersistentFlags().VarP(&output, "output", "o", "Set the output format, one of: `table` (default), `json`, or `yaml` (progress messages are printed to stderr for `json` and `yaml`)")
 }
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"text/tabwriter"
 
@@ -18,27 +18,43 @@ var runningCmd = &cobra.Command{
 
 If containers are found, the results will include information similar
 the information provided by the "docker containers ls" command.`,
-	Run: displayRunning,
+	RunE: displayRunning,
 }
 
 func init() {
 	rootCmd.AddCommand(runningCmd)
 }
 
-func displayRunning(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
+func displayRunning(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
+	fmt.Println("[+] Collecting list of running Ghostwriter containers...")
+
+	containers, err := dockerInterface.GetRunning()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[+] Found %d running Ghostwriter containers\n", len(containers))
+
+	if containers == nil {
+		containers = internal.Containers{}
+	}
+	return printResult(containers, func(out io.Writer) {
+		printContainers(out, containers)
+	})
+}
+
+// Prints a table of containers and their ports
+func printContainers(out io.Writer, containers internal.Containers) {
 	// initialize tabwriter
 	writer := new(tabwriter.Writer)
 	// Set minwidth, tabwidth, padding, padchar, and flags
-	writer.Init(os.Stdout, 8, 8, 1, '\t', 0)
+	writer.Init(out, 8, 8, 1, '\t', 0)
 
 	defer writer.Flush()
 
-	fmt.Println("[+] Collecting list of running Ghostwriter containers...")
-
-	containers := dockerInterface.GetRunning()
-	fmt.Printf("[+] Found %d running Ghostwriter containers\n", len(containers))
-
 	if len(containers) > 0 {
 		fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s\t%s", "Name", "Container ID", "Image", "Status", "Ports")
 		fmt.Fprintf(writer, "\n %s\t%s\t%s\t%s\t%s", "––––––––––––", "––––––––––––", "––––––––––––", "––––––––––––", "––––––––––––")
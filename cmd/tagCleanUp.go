@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
-	"os"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -21,33 +19,36 @@ var tagCleanUpCmd = &cobra.Command{
 When deduplicating tags, the tag with the oldest primary key value (the first created) will be kept.
 
 Note: These commands are only available with Ghostwriter v6 or later.`,
-	Run: tagCleanUp,
+	RunE: tagCleanUp,
 }
 
 func init() {
 	rootCmd.AddCommand(tagCleanUpCmd)
 }
 
-func tagCleanUp(cmd *cobra.Command, args []string) {
-	dockerInterface := internal.GetDockerInterface(mode)
-	dockerInterface.Env.Save()
+func tagCleanUp(cmd *cobra.Command, args []string) error {
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Executing tag cleanup in the development environment...")
 	} else {
 		fmt.Println("[+] Executing tag cleanup in the production environment...")
 	}
 
-	err := dockerInterface.RunDjangoManageCommand("deduplicate_tags")
+	err = dockerInterface.RunDjangoManageCommand("deduplicate_tags")
 	if err != nil {
-		log.Fatalf("Could not deduplicate tags: %s\n", err)
+		return fmt.Errorf("could not deduplicate tags: %w", err)
 	}
 
 	c := internal.AskForConfirmation("[?] Do you want to also remove orphaned tags?")
 	if !c {
-		os.Exit(0)
+		return nil
 	}
 	err = dockerInterface.RunDjangoManageCommand("remove_orphaned_tags")
 	if err != nil {
-		log.Fatalf("Could not remove orphaned tags: %s\n", err)
+		return fmt.Errorf("could not remove orphaned tags: %w", err)
 	}
+	return nil
 }
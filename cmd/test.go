@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
 
@@ -22,30 +21,20 @@ func init() {
 }
 
 func runUnitTests(cmd *cobra.Command, args []string) error {
-	dockerInterface := internal.GetDockerInterface(mode)
-	dockerInterface.Env.Save()
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	fmt.Println("[+] Running Ghostwriter's unit and integration tests...")
 
-	// Save the current env values we're about to change
-	currentActionSecret := dockerInterface.Env.Get("HASURA_GRAPHQL_ACTION_SECRET")
-	currentSettingsModule := dockerInterface.Env.Get("DJANGO_SETTINGS_MODULE")
-
-	// Defer restoration to ensure it happens even if tests fail
-	defer func() {
-		dockerInterface.Env.Set("HASURA_GRAPHQL_ACTION_SECRET", currentActionSecret)
-		dockerInterface.Env.Set("DJANGO_SETTINGS_MODULE", currentSettingsModule)
-		dockerInterface.Env.Save()
-	}()
-
-	// Change env values for the test conditions
-	dockerInterface.Env.Set("HASURA_GRAPHQL_ACTION_SECRET", "changeme")
-	dockerInterface.Env.Set("DJANGO_SETTINGS_MODULE", "config.settings.local")
-	dockerInterface.Env.Save()
+	// Override the env values for the test conditions, without changing the environment file
+	dockerInterface.Env.Override("HASURA_GRAPHQL_ACTION_SECRET", "changeme")
+	dockerInterface.Env.Override("DJANGO_SETTINGS_MODULE", "config.settings.local")
 
 	// Run the unit tests
 	testErr := dockerInterface.RunDjangoManageCommand("test")
 	if testErr != nil {
-		return fmt.Errorf("error trying to run Ghostwriter's tests: %w", testErr)
+		return fmt.Errorf("failed to run Ghostwriter's tests: %w", testErr)
 	}
 
 	return nil
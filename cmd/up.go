@@ -8,8 +8,8 @@ import (
 var upCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Shortcut for `containers up`",
-	Run: func(cmd *cobra.Command, args []string) {
-		containersUpCmd.Run(cmd, args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return containersUpCmd.RunE(cmd, args)
 	},
 }
 
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 
 	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
@@ -23,7 +22,7 @@ If a local '--mode' is specified instead, this command will rebuild the containe
 the database, but won't actually download a new version (use git fetch+checkout instead).
 `,
 	Aliases: []string{"upgrade"},
-	Run:     updateGhostwriter,
+	RunE:    updateGhostwriter,
 }
 
 func init() {
@@ -36,25 +35,32 @@ func init() {
 	rootCmd.AddCommand(updateCmd)
 }
 
-func updateGhostwriter(cmd *cobra.Command, args []string) {
+func updateGhostwriter(cmd *cobra.Command, args []string) error {
 	var err error
 
 	if mode == internal.ModeProd {
 		// Fetch and write docker-compose.yml file
 		err = fetchAndWriteComposeFile(mode, updateVersion)
 		if err != nil {
-			log.Fatalf("%v", err)
+			return err
 		}
 	}
 
 	// Get interface
-	dockerInterface := internal.GetDockerInterface(mode)
-	dockerInterface.Env.Save()
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
+	if err := dockerInterface.Env.Save(); err != nil {
+		return err
+	}
 	if dockerInterface.UseDevInfra {
 		fmt.Println("[+] Starting development environment update")
 	} else {
 		fmt.Println("[+] Starting production environment update")
-		internal.PrepareSettingsDirectory(dockerInterface.Dir)
+		if err := internal.PrepareSettingsDirectory(dockerInterface.Dir); err != nil {
+			return err
+		}
 	}
 
 	fmt.Println("[+] Tearing down containers...")
@@ -62,12 +68,12 @@ func updateGhostwriter(cmd *cobra.Command, args []string) {
 		RemoveOrphans: true,
 	})
 	if err != nil {
-		log.Fatalf("Could not tear down containers: %v", err)
+		return fmt.Errorf("could not tear down containers: %w", err)
 	}
 
 	err = updateContainers(*dockerInterface)
 	if err != nil {
-		log.Fatalf("%v\n", err)
+		return err
 	}
 
 	fmt.Println("[+] Ghostwriter update complete!")
@@ -76,10 +82,11 @@ func updateGhostwriter(cmd *cobra.Command, args []string) {
 		fmt.Println("[*] OK, bringing down containers...")
 		err = dockerInterface.Down(nil)
 		if err != nil {
-			log.Fatalf("Error bringing down containers: %s\n", err)
+			return fmt.Errorf("failed to bring down containers: %w", err)
 		}
 		fmt.Println("[*] All containers are down. Run 'ghostwriter-cli up' when you're ready to start Ghostwriter.")
 	} else {
 		fmt.Println("[+] Ghostwriter is ready to go!")
 	}
+	return nil
 }
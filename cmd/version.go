@@ -2,11 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"text/tabwriter"
 
 	"github.com/GhostManager/Ghostwriter_CLI/cmd/config"
-	internal "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	utils "github.com/GhostManager/Ghostwriter_CLI/cmd/internal"
 	"github.com/spf13/cobra"
 )
@@ -24,17 +23,28 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 }
 
-func compareCliVersions(cmd *cobra.Command, args []string) error {
-	// initialize tabwriter
-	writer := new(tabwriter.Writer)
-	// Set minwidth, tabwidth, padding, padchar, and flags
-	writer.Init(os.Stdout, 8, 8, 1, '\t', 0)
+// VersionInfo is the local and latest released versions of Ghostwriter CLI and Ghostwriter.
+type VersionInfo struct {
+	CLI         ComponentVersion `json:"ghostwriter_cli"`
+	Ghostwriter ComponentVersion `json:"ghostwriter"`
+}
 
-	defer writer.Flush()
+// ComponentVersion is the version information for one component.
+type ComponentVersion struct {
+	LocalVersion    string `json:"local_version"`
+	BuildDate       string `json:"build_date,omitempty"`
+	LatestRelease   string `json:"latest_release"`
+	UpdateAvailable bool   `json:"update_available"`
+	DownloadUrl     string `json:"download_url,omitempty"`
+}
 
+func compareCliVersions(cmd *cobra.Command, args []string) error {
 	fmt.Println("[+] Fetching latest version information:")
 
-	dockerInterface := internal.GetDockerInterface(mode)
+	dockerInterface, err := getDockerInterface(mode)
+	if err != nil {
+		return err
+	}
 	dockerCurrentVersion, err := dockerInterface.GetVersion()
 	if err != nil {
 		return err
@@ -50,32 +60,59 @@ func compareCliVersions(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	fmt.Println()
+	info := VersionInfo{
+		CLI: ComponentVersion{
+			LocalVersion:    config.Version,
+			BuildDate:       config.BuildDate,
+			LatestRelease:   gwcliLatestVersion,
+			UpdateAvailable: gwcliLatestVersion != config.Version,
+			DownloadUrl:     htmlUrl,
+		},
+		Ghostwriter: ComponentVersion{
+			LocalVersion:    dockerCurrentVersion,
+			LatestRelease:   dockerLatestVersion,
+			UpdateAvailable: dockerLatestVersion != dockerCurrentVersion,
+		},
+	}
+
+	if dockerCurrentVersion == "latest" {
+		fmt.Println("[!] Using the `latest` tag is not recommended - pulling containers will not apply necessary changes to the docker-compose.yml file")
+	}
+
+	return printResult(info, func(out io.Writer) {
+		printVersionInfo(out, info)
+	})
+}
+
+// Prints a table comparing the local and latest versions
+func printVersionInfo(out io.Writer, info VersionInfo) {
+	// initialize tabwriter
+	writer := new(tabwriter.Writer)
+	// Set minwidth, tabwidth, padding, padchar, and flags
+	writer.Init(out, 8, 8, 1, '\t', 0)
+
+	defer writer.Flush()
+
+	fmt.Fprintln(writer)
 
 	fmt.Fprintf(writer, "Ghostwriter CLI\n")
-	if len(config.BuildDate) == 0 {
-		fmt.Fprintf(writer, "Local Version\t%s\n", config.Version)
+	if len(info.CLI.BuildDate) == 0 {
+		fmt.Fprintf(writer, "Local Version\t%s\n", info.CLI.LocalVersion)
 	} else {
-		fmt.Fprintf(writer, "Local Version\t%s (%s)\n", config.Version, config.BuildDate)
+		fmt.Fprintf(writer, "Local Version\t%s (%s)\n", info.CLI.LocalVersion, info.CLI.BuildDate)
 	}
-	fmt.Fprintf(writer, "Latest Release\t%s\n", gwcliLatestVersion)
+	fmt.Fprintf(writer, "Latest Release\t%s\n", info.CLI.LatestRelease)
 	fmt.Fprintf(writer, "\n")
 
 	fmt.Fprintf(writer, "Ghostwriter\n")
-	fmt.Fprintf(writer, "Local Version\t%s\n", dockerCurrentVersion)
-	fmt.Fprintf(writer, "Latest Release\t%s\n", dockerLatestVersion)
+	fmt.Fprintf(writer, "Local Version\t%s\n", info.Ghostwriter.LocalVersion)
+	fmt.Fprintf(writer, "Latest Release\t%s\n", info.Ghostwriter.LatestRelease)
 	fmt.Fprintf(writer, "\n")
 
-	if gwcliLatestVersion != config.Version {
-		fmt.Fprintf(writer, "Download the latest version of Ghostwriter CLI at:\t%s\n", htmlUrl)
+	if info.CLI.UpdateAvailable {
+		fmt.Fprintf(writer, "Download the latest version of Ghostwriter CLI at:\t%s\n", info.CLI.DownloadUrl)
 	}
-	if dockerLatestVersion != dockerCurrentVersion {
+	if info.Ghostwriter.UpdateAvailable {
 		fmt.Fprintf(writer, "Install the latest version of Ghostwriter using the `update` subcommand\n")
 	}
-
-	if dockerCurrentVersion == "latest" {
-		fmt.Println("[!] Using the `latest` tag is not recommended - pulling containers will not apply necessary changes to the docker-compose.yml file")
-	}
-
-	return nil
 }